@@ -0,0 +1,549 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting the server reads at startup. Values come from
+// the defaults below, then the optional YAML file, then environment
+// variables, so env always wins.
+type Config struct {
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Session   SessionConfig   `yaml:"session"`
+	Uploads   UploadsConfig   `yaml:"uploads"`
+	Mail      MailConfig      `yaml:"mail"`
+	Security  SecurityConfig  `yaml:"security"`
+	Log       LogConfig       `yaml:"log"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	CORS      CORSConfig      `yaml:"cors"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Timeouts  TimeoutConfig   `yaml:"request_timeout"`
+}
+
+type ServerConfig struct {
+	ListenAddr string `yaml:"listen_addr"`
+	// PublicURL is the externally reachable base URL of this API, used to
+	// build absolute URLs for uploaded files in responses and as the origin
+	// CORS treats as same-origin. It is required so neither depends on the
+	// Host header of a request.
+	PublicURL string `yaml:"public_url"`
+	// AppURL is the frontend URL used to build links in emails.
+	AppURL string `yaml:"app_url"`
+	
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes"`
+	// ShutdownTimeout bounds how long in-flight requests and background
+	// work may take to finish after SIGINT/SIGTERM.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// ShutdownDelay keeps serving with /readyz failing for this long after
+	// the signal, so the load balancer stops routing here before the
+	// listener closes.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+}
+
+type DatabaseConfig struct {
+	DSN             string        `yaml:"dsn"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+}
+
+// SessionConfig lists the cookie signing keys per store. The first key signs
+// new cookies; the rest are only accepted, so a key can be rotated by
+// prepending the new one and dropping the old one once its sessions expired.
+type SessionConfig struct {
+	AdminKeys    []string      `yaml:"admin_keys"`
+	UserKeys     []string      `yaml:"user_keys"`
+	AdminMaxAge  time.Duration `yaml:"admin_max_age"`
+	UserMaxAge   time.Duration `yaml:"user_max_age"`
+	CookieSecure bool          `yaml:"cookie_secure"`
+}
+
+type UploadsConfig struct {
+	Dir     string `yaml:"dir"`
+	URLPath string `yaml:"url_path"`
+}
+
+type MailConfig struct {
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+	From         string `yaml:"from"`
+	OutboxDir    string `yaml:"outbox_dir"`
+}
+
+type SecurityConfig struct {
+	RequireAdmin2FA   bool   `yaml:"require_admin_2fa"`
+	EmailVerifySecret string `yaml:"email_verify_secret"`
+}
+
+// LogConfig selects the slog handler. Format is "json" or "text"; Level is
+// one of debug, info, warn or error.
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// MetricsConfig controls the Prometheus endpoint. With ListenAddr set the
+// metrics are served on that separate listener, typically bound to an
+// internal interface; otherwise /metrics is mounted on the API listener and
+// requires Token. The endpoint is disabled when both are empty.
+type MetricsConfig struct {
+	ListenAddr string `yaml:"listen_addr"`
+	Token      string `yaml:"token"`
+}
+
+// CORSConfig lists the browser origins allowed to call the API with
+// credentials. An origin is scheme://host[:port]; a host starting with "*."
+// matches any subdomain, but not the domain itself.
+type CORSConfig struct {
+	AllowedOrigins []string      `yaml:"allowed_origins"`
+	AllowedMethods []string      `yaml:"allowed_methods"`
+	AllowedHeaders []string      `yaml:"allowed_headers"`
+	MaxAge         time.Duration `yaml:"max_age"`
+}
+
+// RateLimitConfig selects the bucket store ("memory" for a single node,
+// "postgres" when several nodes must share limits) and the limit of each
+// rate-limited route group. A rule with Requests 0 is disabled.
+type RateLimitConfig struct {
+	Backend string                   `yaml:"backend"`
+	Rules   map[string]RateLimitRule `yaml:"rules"`
+}
+
+// RateLimitRule allows Requests per Per with bursts of Burst (default
+// Requests). By is ip, user, api_key or email (the email field of a JSON
+// body); user and api_key fall back to the next broader identity the request
+// has, and email to the IP.
+type RateLimitRule struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"`
+	By       string        `yaml:"by"`
+}
+
+// TimeoutConfig caps how long a request may take. Handlers pass the request
+// context to every database call, so queries are cancelled once the deadline
+// passes. Routes overrides Default per route, keyed by the ServeMux pattern
+// ("PUT /api/v1/wisata/{id}") or by its path alone to cover every method. A
+// timeout of 0 disables the deadline.
+type TimeoutConfig struct {
+	Default time.Duration            `yaml:"default"`
+	Routes  map[string]time.Duration `yaml:"routes"`
+}
+
+// App is the configuration loaded by main.
+var App *Config
+
+const minSecretLength = 32
+
+func defaults() *Config {
+	return &Config{
+		Server: ServerConfig{
+			ListenAddr:        "0.0.0.0:8080",
+			AppURL:            "http://localhost:5173",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			MaxHeaderBytes:    1 << 20,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 5 * time.Minute,
+		},
+		Session: SessionConfig{
+			AdminMaxAge: 8 * time.Hour,
+			UserMaxAge:  7 * 24 * time.Hour,
+		},
+		Uploads: UploadsConfig{
+			Dir:     "./uploads",
+			URLPath: "/uploads/",
+		},
+		Mail: MailConfig{
+			SMTPPort:  587,
+			From:      "no-reply@localhost",
+			OutboxDir: "./outbox",
+		},
+		Security: SecurityConfig{
+			RequireAdmin2FA: true,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Requested-With", "X-Request-ID"},
+			MaxAge:         10 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Backend: "memory",
+			Rules: map[string]RateLimitRule{
+				"login":                 {Requests: 10, Per: time.Minute, By: "ip"},
+				"register":              {Requests: 5, Per: 10 * time.Minute, By: "ip"},
+				"password_forgot":       {Requests: 5, Per: 10 * time.Minute, By: "ip"},
+				"password_forgot_email": {Requests: 3, Per: time.Hour, By: "email"},
+				"email_resend":          {Requests: 5, Per: 10 * time.Minute, By: "ip"},
+				"email_resend_email":    {Requests: 3, Per: time.Hour, By: "email"},
+				"review_submit":         {Requests: 10, Per: time.Hour, By: "user"},
+				"booking_create":        {Requests: 30, Per: time.Minute, By: "api_key"},
+			},
+		},
+		Timeouts: TimeoutConfig{
+			Default: 10 * time.Second,
+			// Uploads are read before the handler touches the database,
+			// so these get as long as the server allows for reading.
+			Routes: map[string]time.Duration{
+				"POST /api/v1/wisata":         30 * time.Second,
+				"PUT /api/v1/wisata/{id}":     30 * time.Second,
+				"PUT /api/v1/profile":         30 * time.Second,
+				"POST /api/v1/blog/posts":     30 * time.Second,
+				"PUT /api/v1/blog/posts/{id}": 30 * time.Second,
+				"/api/wisata/create":          30 * time.Second,
+				"/api/wisata/update":          30 * time.Second,
+				"/api/profile/update":         30 * time.Second,
+				"/api/blog/create":            30 * time.Second,
+				"/api/blog/update":            30 * time.Second,
+			},
+		},
+	}
+}
+
+// Load builds the configuration from the file at path (skipped when path is
+// empty) and the environment, and validates the result.
+func Load(path string) (*Config, error) {
+	cfg := defaults()
+	
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parse config file %s: %w", path, err)
+		}
+	}
+	
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	
+	cfg.Server.PublicURL = strings.TrimRight(cfg.Server.PublicURL, "/")
+	cfg.Server.AppURL = strings.TrimRight(cfg.Server.AppURL, "/")
+	cfg.Uploads.URLPath = "/" + strings.Trim(cfg.Uploads.URLPath, "/") + "/"
+	
+	// Without an explicit list only the frontend may call the API.
+	if len(cfg.CORS.AllowedOrigins) == 0 {
+		if u, err := url.Parse(cfg.Server.AppURL); err == nil && u.Host != "" {
+			cfg.CORS.AllowedOrigins = []string{u.Scheme + "://" + u.Host}
+		}
+	}
+	for i, origin := range cfg.CORS.AllowedOrigins {
+		cfg.CORS.AllowedOrigins[i] = strings.ToLower(strings.TrimRight(origin, "/"))
+	}
+	
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) applyEnv() error {
+	var errs []error
+	
+	envString("LISTEN_ADDR", &c.Server.ListenAddr)
+	envString("PUBLIC_URL", &c.Server.PublicURL)
+	envString("APP_URL", &c.Server.AppURL)
+	errs = append(errs, envDuration("SERVER_READ_HEADER_TIMEOUT", &c.Server.ReadHeaderTimeout))
+	errs = append(errs, envDuration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout))
+	errs = append(errs, envDuration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout))
+	errs = append(errs, envDuration("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout))
+	errs = append(errs, envInt("SERVER_MAX_HEADER_BYTES", &c.Server.MaxHeaderBytes))
+	errs = append(errs, envDuration("SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout))
+	errs = append(errs, envDuration("SERVER_SHUTDOWN_DELAY", &c.Server.ShutdownDelay))
+	
+	envString("DATABASE_URL", &c.Database.DSN)
+	errs = append(errs, envInt("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns))
+	errs = append(errs, envInt("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns))
+	errs = append(errs, envDuration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime))
+	
+	envList("SESSION_ADMIN_KEYS", &c.Session.AdminKeys)
+	envList("SESSION_USER_KEYS", &c.Session.UserKeys)
+	errs = append(errs, envDuration("SESSION_ADMIN_MAX_AGE", &c.Session.AdminMaxAge))
+	errs = append(errs, envDuration("SESSION_USER_MAX_AGE", &c.Session.UserMaxAge))
+	errs = append(errs, envBool("COOKIE_SECURE", &c.Session.CookieSecure))
+	
+	envString("UPLOAD_DIR", &c.Uploads.Dir)
+	envString("UPLOAD_URL_PATH", &c.Uploads.URLPath)
+	
+	envString("SMTP_HOST", &c.Mail.SMTPHost)
+	errs = append(errs, envInt("SMTP_PORT", &c.Mail.SMTPPort))
+	envString("SMTP_USERNAME", &c.Mail.SMTPUsername)
+	envString("SMTP_PASSWORD", &c.Mail.SMTPPassword)
+	envString("SMTP_FROM", &c.Mail.From)
+	envString("MAIL_OUTBOX_DIR", &c.Mail.OutboxDir)
+	
+	errs = append(errs, envBool("REQUIRE_ADMIN_2FA", &c.Security.RequireAdmin2FA))
+	envString("EMAIL_VERIFY_SECRET", &c.Security.EmailVerifySecret)
+	
+	envString("LOG_LEVEL", &c.Log.Level)
+	envString("LOG_FORMAT", &c.Log.Format)
+	
+	envString("METRICS_LISTEN_ADDR", &c.Metrics.ListenAddr)
+	envString("METRICS_TOKEN", &c.Metrics.Token)
+	
+	envList("CORS_ALLOWED_ORIGINS", &c.CORS.AllowedOrigins)
+	envList("CORS_ALLOWED_METHODS", &c.CORS.AllowedMethods)
+	envList("CORS_ALLOWED_HEADERS", &c.CORS.AllowedHeaders)
+	errs = append(errs, envDuration("CORS_MAX_AGE", &c.CORS.MaxAge))
+	
+	errs = append(errs, envDuration("REQUEST_TIMEOUT", &c.Timeouts.Default))
+	
+	envString("RATE_LIMIT_BACKEND", &c.RateLimit.Backend)
+	for name, rule := range c.RateLimit.Rules {
+		key := "RATE_LIMIT_" + strings.ToUpper(name)
+		v := os.Getenv(key)
+		if v == "" {
+			continue
+		}
+		requests, per, ok := strings.Cut(v, "/")
+		n, err := strconv.Atoi(requests)
+		d, err2 := time.ParseDuration(per)
+		if !ok || err != nil || err2 != nil {
+			errs = append(errs, fmt.Errorf("%s: expected <requests>/<duration>, e.g. 10/1m", key))
+			continue
+		}
+		rule.Requests, rule.Per = n, d
+		c.RateLimit.Rules[name] = rule
+	}
+	
+	return errors.Join(errs...)
+}
+
+// Validate reports every invalid setting at once so a bad deploy can be
+// fixed in one go.
+func (c *Config) Validate() error {
+	var errs []error
+	
+	if c.Server.ListenAddr == "" {
+		errs = append(errs, errors.New("server.listen_addr is required"))
+	}
+	if c.Server.PublicURL == "" {
+		errs = append(errs, errors.New("server.public_url (PUBLIC_URL) is required"))
+	} else {
+		errs = append(errs, validateURL("server.public_url", c.Server.PublicURL))
+	}
+	errs = append(errs, validateURL("server.app_url", c.Server.AppURL))
+	if c.Server.ReadHeaderTimeout <= 0 || c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 ||
+		c.Server.IdleTimeout <= 0 || c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server timeouts must be positive"))
+	}
+	if c.Server.ShutdownDelay < 0 {
+		errs = append(errs, errors.New("server.shutdown_delay must not be negative"))
+	}
+	if c.Server.MaxHeaderBytes < 4096 {
+		errs = append(errs, errors.New("server.max_header_bytes must be at least 4096"))
+	}
+	
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("database.dsn (DATABASE_URL) is required"))
+	}
+	if c.Database.MaxOpenConns < 1 {
+		errs = append(errs, errors.New("database.max_open_conns must be at least 1"))
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, errors.New("database.max_idle_conns must be between 0 and max_open_conns"))
+	}
+	
+	errs = append(errs, validateKeys("session.admin_keys (SESSION_ADMIN_KEYS)", c.Session.AdminKeys))
+	errs = append(errs, validateKeys("session.user_keys (SESSION_USER_KEYS)", c.Session.UserKeys))
+	if c.Session.AdminMaxAge <= 0 || c.Session.UserMaxAge <= 0 {
+		errs = append(errs, errors.New("session max ages must be positive"))
+	}
+	
+	if c.Uploads.Dir == "" {
+		errs = append(errs, errors.New("uploads.dir is required"))
+	}
+	
+	if c.Mail.SMTPHost != "" && (c.Mail.SMTPPort < 1 || c.Mail.SMTPPort > 65535) {
+		errs = append(errs, errors.New("mail.smtp_port is out of range"))
+	}
+	
+	if len(c.Security.EmailVerifySecret) < minSecretLength {
+		errs = append(errs, fmt.Errorf("security.email_verify_secret (EMAIL_VERIFY_SECRET) must be at least %d characters", minSecretLength))
+	}
+	
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, errors.New("log.level (LOG_LEVEL) must be debug, info, warn or error"))
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		errs = append(errs, errors.New("log.format (LOG_FORMAT) must be json or text"))
+	}
+	
+	if c.Metrics.Token != "" && len(c.Metrics.Token) < minSecretLength {
+		errs = append(errs, fmt.Errorf("metrics.token (METRICS_TOKEN) must be at least %d characters", minSecretLength))
+	}
+	if c.Metrics.ListenAddr != "" && c.Metrics.ListenAddr == c.Server.ListenAddr {
+		errs = append(errs, errors.New("metrics.listen_addr must differ from server.listen_addr"))
+	}
+	
+	if c.RateLimit.Backend != "memory" && c.RateLimit.Backend != "postgres" {
+		errs = append(errs, errors.New("rate_limit.backend (RATE_LIMIT_BACKEND) must be memory or postgres"))
+	}
+	for name, rule := range c.RateLimit.Rules {
+		if rule.Requests < 0 || rule.Burst < 0 || (rule.Requests > 0 && rule.Per <= 0) {
+			errs = append(errs, fmt.Errorf("rate_limit.rules.%s: requests, per and burst must be positive", name))
+		}
+		if rule.By != "ip" && rule.By != "user" && rule.By != "api_key" && rule.By != "email" {
+			errs = append(errs, fmt.Errorf("rate_limit.rules.%s: by must be ip, user, api_key or email", name))
+		}
+	}
+	
+	if c.Timeouts.Default < 0 {
+		errs = append(errs, errors.New("request_timeout.default (REQUEST_TIMEOUT) must not be negative"))
+	}
+	for route, timeout := range c.Timeouts.Routes {
+		method, path, hasMethod := strings.Cut(route, " ")
+		if !hasMethod {
+			path = method
+		}
+		if (hasMethod && method == "") || !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Errorf("request_timeout.routes: %q must look like \"GET /api/v1/wisata\" or \"/api/v1/wisata\"", route))
+		}
+		if timeout < 0 {
+			errs = append(errs, fmt.Errorf("request_timeout.routes.%s must not be negative", route))
+		}
+	}
+	
+	for _, origin := range c.CORS.AllowedOrigins {
+		errs = append(errs, validateOrigin(origin))
+	}
+	if len(c.CORS.AllowedMethods) == 0 {
+		errs = append(errs, errors.New("cors.allowed_methods (CORS_ALLOWED_METHODS) must not be empty"))
+	}
+	if c.CORS.MaxAge < 0 {
+		errs = append(errs, errors.New("cors.max_age must not be negative"))
+	}
+	
+	return errors.Join(errs...)
+}
+
+// UploadURL returns the path under which a file stored in the upload
+// directory is served. Paths are stored relative so that changing
+// PublicURL does not invalidate existing rows.
+func (c *Config) UploadURL(name string) string {
+	return c.Uploads.URLPath + name
+}
+
+func validateURL(name, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s must be an absolute http(s) URL", name)
+	}
+	return nil
+}
+
+// validateOrigin accepts scheme://host[:port] where host may start with
+// "*.". A bare "*" is refused because the API sends credentials.
+func validateOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.Path != "" || u.RawQuery != "" || u.User != nil || strings.Contains(strings.TrimPrefix(u.Host, "*."), "*") {
+		return fmt.Errorf("cors.allowed_origins: %q must look like https://example.com or https://*.example.com", origin)
+	}
+	return nil
+}
+
+func validateKeys(name string, keys []string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("%s needs at least one key", name)
+	}
+	for _, key := range keys {
+		if len(key) < minSecretLength {
+			return fmt.Errorf("%s: every key must be at least %d characters", name, minSecretLength)
+		}
+	}
+	return nil
+}
+
+func envString(key string, dst *string) {
+	if v := os.Getenv(key); v != "" {
+		*dst = v
+	}
+}
+
+// envList reads a comma-separated list.
+func envList(key string, dst *[]string) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+func envInt(key string, dst *int) error {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a number", key, v)
+	}
+	*dst = n
+	return nil
+}
+
+func envBool(key string, dst *bool) error {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a boolean", key, v)
+	}
+	*dst = b
+	return nil
+}
+
+func envDuration(key string, dst *time.Duration) error {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a duration (e.g. 30m, 8h)", key, v)
+	}
+	*dst = d
+	return nil
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"backend-wisata/mailer"
+)
+
+var Mailer mailer.Mailer
+
+// AppURL is the public URL of the frontend, used to build links in emails.
+// It must never be derived from request headers.
+var AppURL string
+
+func InitMailer() {
+	AppURL = App.Server.AppURL
+	
+	if App.Mail.SMTPHost != "" {
+		Mailer = &mailer.SMTPMailer{
+			Host:     App.Mail.SMTPHost,
+			Port:     App.Mail.SMTPPort,
+			Username: App.Mail.SMTPUsername,
+			Password: App.Mail.SMTPPassword,
+			From:     App.Mail.From,
+		}
+		return
+	}
+	
+	Mailer = &mailer.OutboxMailer{Dir: App.Mail.OutboxDir}
+}
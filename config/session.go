@@ -6,23 +6,48 @@ import (
 	"github.com/gorilla/sessions"
 )
 
-var AdminStore = sessions.NewCookieStore([]byte("rahasia-admin-99"))
-var UserStore = sessions.NewCookieStore([]byte("rahasia-user-88"))
+var AdminStore *PGStore
+var UserStore *PGStore
+
+// RequireAdmin2FA forces admin and superadmin accounts to enroll in TOTP
+// before they can use any admin endpoint.
+var RequireAdmin2FA bool
+
+// EmailVerifyKey signs the links sent by the email verification flow.
+var EmailVerifyKey []byte
 
 func InitSession() {
+	EmailVerifyKey = []byte(App.Security.EmailVerifySecret)
+	RequireAdmin2FA = App.Security.RequireAdmin2FA
+	
+	AdminStore = NewPGStore(DB, keyPairs(App.Session.AdminKeys)...)
 	AdminStore.Options = &sessions.Options{
 		Path:     "/",
-		MaxAge:   3600 * 8,
+		MaxAge:   int(App.Session.AdminMaxAge.Seconds()),
 		HttpOnly: true,
-		Secure:   false,
+		Secure:   App.Session.CookieSecure,
 		SameSite: http.SameSiteLaxMode,
 	}
+	AdminStore.MaxAge(AdminStore.Options.MaxAge)
 	
+	UserStore = NewPGStore(DB, keyPairs(App.Session.UserKeys)...)
 	UserStore.Options = &sessions.Options{
 		Path:     "/",
-		MaxAge:   86400 * 7,
+		MaxAge:   int(App.Session.UserMaxAge.Seconds()),
 		HttpOnly: true,
-		Secure:   false,
+		Secure:   App.Session.CookieSecure,
 		SameSite: http.SameSiteLaxMode,
 	}
+	UserStore.MaxAge(UserStore.Options.MaxAge)
+}
+
+// keyPairs turns the configured signing keys into securecookie hash/block
+// pairs. Cookies only carry the session ID, so no block (encryption) key is
+// used.
+func keyPairs(keys []string) [][]byte {
+	pairs := make([][]byte, 0, len(keys)*2)
+	for _, key := range keys {
+		pairs = append(pairs, []byte(key), nil)
+	}
+	return pairs
 }
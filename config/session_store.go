@@ -0,0 +1,229 @@
+package config
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+// lastSeenInterval throttles how often a session touch is written back.
+const lastSeenInterval = time.Minute
+
+// PGStore is a sessions.Store that keeps session values in the user_sessions
+// table. The cookie only carries the signed session ID, which lets us list
+// and revoke sessions server-side.
+type PGStore struct {
+	Codecs  []securecookie.Codec
+	Options *sessions.Options
+	
+	db *sql.DB
+}
+
+func NewPGStore(db *sql.DB, keyPairs ...[]byte) *PGStore {
+	store := &PGStore{
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+		db: db,
+	}
+	store.MaxAge(store.Options.MaxAge)
+	return store
+}
+
+// MaxAge sets the maximum age for the store, the cookies and the encoded
+// session values.
+func (s *PGStore) MaxAge(age int) {
+	s.Options.MaxAge = age
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(age)
+		}
+	}
+}
+
+func (s *PGStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+func (s *PGStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	options := *s.Options
+	session.Options = &options
+	session.IsNew = true
+	
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	
+	if err := securecookie.DecodeMulti(name, cookie.Value, &session.ID, s.Codecs...); err != nil {
+		session.ID = ""
+		return session, err
+	}
+	
+	found, err := s.load(r, session)
+	if err != nil || !found {
+		session.ID = ""
+		return session, err
+	}
+	
+	session.IsNew = false
+	return session, nil
+}
+
+func (s *PGStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if _, err := s.db.ExecContext(r.Context(), "DELETE FROM user_sessions WHERE token = $1", session.ID); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+	
+	if session.ID == "" {
+		session.ID = newSessionToken()
+	}
+	
+	data, err := securecookie.EncodeMulti(session.Name(), session.Values, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	
+	var userID *int
+	if id, ok := session.Values["user_id"].(int); ok {
+		userID = &id
+	}
+	
+	expiresAt := time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second)
+	
+	query := `
+		INSERT INTO user_sessions (token, name, user_id, data, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW(), $7)
+		ON CONFLICT (token) DO UPDATE
+		SET data = EXCLUDED.data, user_id = EXCLUDED.user_id, user_agent = EXCLUDED.user_agent,
+			ip_address = EXCLUDED.ip_address, last_seen_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE user_sessions.revoked_at IS NULL
+	`
+	_, err = s.db.ExecContext(
+		r.Context(), query,
+		session.ID, session.Name(), userID, data, r.UserAgent(), ClientIP(r), expiresAt,
+	)
+	if err != nil {
+		return err
+	}
+	
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// Renew drops the stored row of the session and clears its ID, so the next
+// Save stores it under a new one. Login calls it to keep a session ID that
+// was known before logging in from carrying over.
+func (s *PGStore) Renew(r *http.Request, session *sessions.Session) error {
+	if session.ID != "" {
+		if _, err := s.db.ExecContext(r.Context(), "DELETE FROM user_sessions WHERE token = $1", session.ID); err != nil {
+			return err
+		}
+	}
+	session.ID = ""
+	return nil
+}
+
+func (s *PGStore) load(r *http.Request, session *sessions.Session) (bool, error) {
+	var data string
+	var lastSeen time.Time
+	
+	query := `
+		SELECT data, last_seen_at FROM user_sessions
+		WHERE token = $1 AND name = $2 AND revoked_at IS NULL AND expires_at > NOW()
+	`
+	err := s.db.QueryRowContext(r.Context(), query, session.ID, session.Name()).Scan(&data, &lastSeen)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	
+	if err := securecookie.DecodeMulti(session.Name(), data, &session.Values, s.Codecs...); err != nil {
+		return false, err
+	}
+	
+	if time.Since(lastSeen) > lastSeenInterval {
+		_, _ = s.db.ExecContext(
+			r.Context(), "UPDATE user_sessions SET last_seen_at = NOW(), ip_address = $1, user_agent = $2 WHERE token = $3",
+			ClientIP(r), r.UserAgent(), session.ID,
+		)
+	}
+	
+	return true, nil
+}
+
+// StartSessionCleanup periodically removes expired and revoked sessions. The
+// returned function stops the worker, cancelling a running pass, and waits
+// for it to return.
+func StartSessionCleanup(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ticker.C:
+				_, err := DB.ExecContext(
+					ctx, "DELETE FROM user_sessions WHERE expires_at < NOW() OR revoked_at < NOW() - INTERVAL '7 days'",
+				)
+				if err == nil {
+					_, err = DB.ExecContext(
+						ctx, "DELETE FROM auth_tokens WHERE refresh_expires_at < NOW() OR revoked_at < NOW() - INTERVAL '30 days'",
+					)
+				}
+				if err != nil && ctx.Err() == nil {
+					slog.Error("session cleanup failed", "err", err)
+				}
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	
+	return func() {
+		cancel()
+		<-stopped
+	}
+}
+
+// ClientIP returns the address of the client that sent the request.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func newSessionToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return strings.TrimRight(base32.StdEncoding.EncodeToString(b), "=")
+}
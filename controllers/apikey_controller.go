@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	
+	"backend-wisata/logger"
+	"backend-wisata/models"
+	"backend-wisata/ratelimit"
+	"backend-wisata/store"
+)
+
+const (
+	apiKeyHeader           = "X-API-Key"
+	apiKeyPrefix           = "wk_"
+	defaultAPIKeyRateLimit = 60
+)
+
+// RateLimitError is returned by APIKeyUser when the key used up its
+// per-minute quota.
+type RateLimitError struct {
+	ratelimit.Result
+}
+
+func (e *RateLimitError) Error() string {
+	return "api key rate limit exceeded"
+}
+
+const apiKeyContextKey contextKey = "api-key"
+
+// APIKeyFromHeader returns the raw key sent in the X-API-Key header.
+func APIKeyFromHeader(r *http.Request) (string, bool) {
+	key := strings.TrimSpace(r.Header.Get(apiKeyHeader))
+	return key, key != ""
+}
+
+// APIKeyUser resolves an API key to its partner account. It returns
+// ErrUnauthenticated for unknown or revoked keys and for accounts that are
+// no longer partners, and a *RateLimitError when the key is over its limit.
+func (h *Handler) APIKeyUser(ctx context.Context, raw string) (*models.User, *models.APIKey, error) {
+	key, err := h.APIKeys.FindByHash(ctx, hashToken(raw))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil, ErrUnauthenticated
+	} else if err != nil {
+		return nil, nil, err
+	}
+	
+	// A failing limiter store lets the request through rather than locking
+	// every partner out.
+	res, err := h.RateLimits.Take(
+		ctx, "api_key:"+strconv.Itoa(key.ID),
+		ratelimit.Limit{Requests: key.RateLimit, Per: time.Minute},
+	)
+	if err != nil {
+		logger.FromContext(ctx).Error("api key rate limit failed", "err", err)
+	} else if !res.Allowed {
+		return nil, nil, &RateLimitError{res}
+	}
+	
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > time.Minute {
+		if err := h.APIKeys.TouchLastUsed(ctx, key.ID); err != nil {
+			logger.FromContext(ctx).Error("update api key last use failed", "err", err)
+		}
+	}
+	
+	user, err := h.Users.Get(ctx, key.UserID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil, ErrUnauthenticated
+	} else if err != nil {
+		return nil, nil, err
+	}
+	
+	// Keys carry no 2FA state and only the role check keeps them off
+	// unscoped routes, so they must never act with more than a partner's
+	// rights.
+	if user.Role != models.RolePartner {
+		return nil, nil, ErrUnauthenticated
+	}
+	
+	return user, key, nil
+}
+
+// WithAPIKey stores the API key that authenticated the request.
+func WithAPIKey(r *http.Request, key *models.APIKey) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), apiKeyContextKey, key))
+}
+
+// CurrentAPIKey returns the API key behind the request, or nil when the
+// caller is a logged-in human.
+func CurrentAPIKey(r *http.Request) *models.APIKey {
+	key, _ := r.Context().Value(apiKeyContextKey).(*models.APIKey)
+	return key
+}
+
+func (h *Handler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.APIKeys.List(r.Context())
+	if err != nil {
+		requestLogger(r).Error("fetch api keys failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil data API key")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(
+		models.Response{
+			Status:  200,
+			Message: "API Keys Fetched",
+			Data:    keys,
+		},
+	)
+}
+
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		UserID    int      `json:"user_id" validate:"required,min=1"`
+		Name      string   `json:"name" validate:"required,max=100"`
+		Scopes    []string `json:"scopes" validate:"required,min=1,oneof=catalog:read booking:read booking:write"`
+		RateLimit int      `json:"rate_limit_per_minute"`
+	}
+	
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+	if input.RateLimit <= 0 {
+		input.RateLimit = defaultAPIKeyRateLimit
+	}
+	
+	partner, err := h.Users.Get(r.Context(), input.UserID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && partner.Role != models.RolePartner) {
+		responseError(w, http.StatusBadRequest, "API key hanya bisa dibuat untuk akun partner")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("fetch user failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal membuat API key")
+		return
+	}
+	
+	raw := apiKeyPrefix + newToken()
+	key := models.APIKey{
+		UserID:      partner.ID,
+		PartnerName: partner.FullName,
+		Name:        input.Name,
+		Prefix:      raw[:len(apiKeyPrefix)+8],
+		Scopes:      input.Scopes,
+		RateLimit:   input.RateLimit,
+	}
+	
+	err = h.APIKeys.Create(r.Context(), &key, hashToken(raw))
+	if err != nil {
+		requestLogger(r).Error("create api key failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal membuat API key")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(
+		models.Response{
+			Status:  201,
+			Message: "API key dibuat, simpan key ini karena tidak akan ditampilkan lagi",
+			Data: map[string]interface{}{
+				"key":     raw,
+				"api_key": key,
+			},
+		},
+	)
+}
+
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(pathParam(r, "id"))
+	
+	err := h.APIKeys.Revoke(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		responseError(w, http.StatusNotFound, "API key tidak ditemukan")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("revoke api key failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mencabut API key")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.Response{Status: 200, Message: "API Key Revoked"})
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	
+	"backend-wisata/models"
+)
+
+// createdAPIKey is the data of a CreateAPIKey response.
+type createdAPIKey struct {
+	Key    string        `json:"key"`
+	APIKey models.APIKey `json:"api_key"`
+}
+
+// newAPIKey creates a key for the partner through CreateAPIKey.
+func (e *testEnv) newAPIKey(partner *models.User, rateLimit int, scopes ...string) createdAPIKey {
+	e.t.Helper()
+	admin := e.newUser(models.RoleAdmin)
+	input := map[string]any{"user_id": partner.ID, "name": "Integrasi", "scopes": scopes, "rate_limit_per_minute": rateLimit}
+	rec, res := serve(e.t, e.CreateAPIKey, asUser(newRequest(http.MethodPost, "/api/v1/admin/api-keys", input), admin))
+	expectStatus(e.t, rec, res, http.StatusCreated, "")
+	
+	var created createdAPIKey
+	decodeData(e.t, res, &created)
+	return created
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	partner := e.newUser(models.RolePartner)
+	user := e.newUser(models.RoleUser)
+	
+	tests := []struct {
+		name   string
+		input  map[string]any
+		status int
+		field  string
+	}{
+		{"partner", map[string]any{"user_id": partner.ID, "name": "Integrasi", "scopes": []string{models.ScopeCatalogRead}}, http.StatusCreated, ""},
+		{"not a partner", map[string]any{"user_id": user.ID, "name": "Integrasi", "scopes": []string{models.ScopeCatalogRead}}, http.StatusBadRequest, ""},
+		{"unknown user", map[string]any{"user_id": 9999, "name": "Integrasi", "scopes": []string{models.ScopeCatalogRead}}, http.StatusBadRequest, ""},
+		{"unknown scope", map[string]any{"user_id": partner.ID, "name": "Integrasi", "scopes": []string{"admin"}}, http.StatusUnprocessableEntity, "scopes"},
+		{"no scopes", map[string]any{"user_id": partner.ID, "name": "Integrasi"}, http.StatusUnprocessableEntity, "scopes"},
+		{"empty scopes", map[string]any{"user_id": partner.ID, "name": "Integrasi", "scopes": []string{}}, http.StatusUnprocessableEntity, "scopes"},
+		{"missing name", map[string]any{"user_id": partner.ID, "scopes": []string{models.ScopeCatalogRead}}, http.StatusUnprocessableEntity, "name"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec, res := serve(t, e.CreateAPIKey, asUser(newRequest(http.MethodPost, "/api/v1/admin/api-keys", tt.input), admin))
+				expectStatus(t, rec, res, tt.status, "")
+				if tt.field != "" && len(res.Details[tt.field]) == 0 {
+					t.Fatalf("no error for field %s: %v", tt.field, res.Details)
+				}
+				if tt.status != http.StatusCreated {
+					return
+				}
+				
+				if rec.Header().Get("Cache-Control") != "no-store" {
+					t.Fatal("the new key may be cached")
+				}
+				var created createdAPIKey
+				decodeData(t, res, &created)
+				if created.APIKey.RateLimit != defaultAPIKeyRateLimit || created.APIKey.Prefix != created.Key[:len(created.APIKey.Prefix)] {
+					t.Fatalf("created %+v", created.APIKey)
+				}
+				
+				got, key, err := e.APIKeyUser(context.Background(), created.Key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.ID != partner.ID || !key.HasScope(models.ScopeCatalogRead) {
+					t.Fatalf("key resolves to user %d with scopes %v", got.ID, key.Scopes)
+				}
+			},
+		)
+	}
+}
+
+func TestGetAPIKeys(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	partner := e.newUser(models.RolePartner)
+	created := e.newAPIKey(partner, 0, models.ScopeBookingRead)
+	
+	rec, res := serve(t, e.GetAPIKeys, asUser(newRequest(http.MethodGet, "/api/v1/admin/api-keys", nil), admin))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	
+	var list []models.APIKey
+	decodeData(t, res, &list)
+	if len(list) != 1 || list[0].ID != created.APIKey.ID || list[0].PartnerName != partner.FullName {
+		t.Fatalf("keys %+v", list)
+	}
+	if len(list[0].Scopes) != 1 || list[0].Scopes[0] != models.ScopeBookingRead {
+		t.Fatalf("scopes %v", list[0].Scopes)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	created := e.newAPIKey(e.newUser(models.RolePartner), 0, models.ScopeCatalogRead)
+	id := strconv.Itoa(created.APIKey.ID)
+	
+	tests := []struct {
+		name   string
+		id     string
+		status int
+	}{
+		{"unknown", "9999", http.StatusNotFound},
+		{"active", id, http.StatusOK},
+		{"already revoked", id, http.StatusNotFound},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := withPath(newRequest(http.MethodDelete, "/api/v1/admin/api-keys/"+tt.id, nil), "id", tt.id)
+				rec, res := serve(t, e.RevokeAPIKey, asUser(r, admin))
+				expectStatus(t, rec, res, tt.status, "")
+			},
+		)
+	}
+	
+	if _, _, err := e.APIKeyUser(context.Background(), created.Key); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("revoked key: err = %v, want ErrUnauthenticated", err)
+	}
+}
+
+func TestAPIKeyUser(t *testing.T) {
+	e := newTestEnv(t)
+	ctx := context.Background()
+	limited := e.newAPIKey(e.newUser(models.RolePartner), 2, models.ScopeCatalogRead)
+	promoted := e.newUser(models.RolePartner)
+	promotedKey := e.newAPIKey(promoted, 0, models.ScopeCatalogRead)
+	if err := e.Users.UpdateStatus(ctx, promoted.ID, true, models.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	
+	// The cases run in order, so the limited key runs out on the third.
+	tests := []struct {
+		name    string
+		key     string
+		limited bool
+		err     error
+	}{
+		{"first request", limited.Key, false, nil},
+		{"second request", limited.Key, false, nil},
+		{"over the limit", limited.Key, true, nil},
+		{"unknown key", "wk_tidak-ada", false, ErrUnauthenticated},
+		{"owner no longer a partner", promotedKey.Key, false, ErrUnauthenticated},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				_, _, err := e.APIKeyUser(ctx, tt.key)
+				var rateLimited *RateLimitError
+				if tt.limited && !errors.As(err, &rateLimited) {
+					t.Fatalf("err = %v, want *RateLimitError", err)
+				}
+				if !tt.limited && !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+			},
+		)
+	}
+}
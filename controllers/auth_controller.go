@@ -1,111 +1,255 @@
 package controllers
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
+	"sync"
 	
 	"backend-wisata/config"
+	"backend-wisata/logger"
+	"backend-wisata/metrics"
 	"backend-wisata/models"
+	"backend-wisata/store"
 	
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrUnauthenticated is returned by SessionUser when the request carries no
+// valid session or the session points at a user that no longer exists.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+type contextKey string
+
+const userContextKey contextKey = "current-user"
+
+// hashPassword is the single bcrypt path for every place that stores a
+// password.
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hashed), err
+}
+
+var dummyHash struct {
+	once sync.Once
+	hash string
+}
+
+func dummyPasswordHash() string {
+	dummyHash.once.Do(func() {
+		dummyHash.hash, _ = hashPassword("dummy-password-for-timing")
+	})
+	return dummyHash.hash
+}
+
 func checkPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
 
-func responseError(w http.ResponseWriter, code int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(
-		models.Response{
-			Status:  code,
-			Message: message,
-		},
-	)
+// pathParam returns the {name} wildcard of a /api/v1 route, falling back to
+// the ?name= query parameter used by the deprecated paths.
+func pathParam(r *http.Request, name string) string {
+	if v := r.PathValue(name); v != "" {
+		return v
+	}
+	return r.URL.Query().Get(name)
 }
 
-func Login(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		responseError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
+// requestLogger returns the logger tagged with the request ID and, once
+// authenticated, the user ID.
+func requestLogger(r *http.Request) *slog.Logger {
+	return logger.FromContext(r.Context())
+}
+
+// checkCredentials runs the password step shared by Login and IssueToken:
+// lockout checks, the password comparison and the account state checks. On
+// failure it writes the error response and returns nil.
+func (h *Handler) checkCredentials(w http.ResponseWriter, r *http.Request, login, password string) *models.User {
+	ipKey := ipThrottleKey(config.ClientIP(r))
+	if wait, err := h.lockedFor(r.Context(), ipKey); err != nil {
+		requestLogger(r).Error("check lockout failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memproses login")
+		return nil
+	} else if wait > 0 {
+		responseLocked(w, wait)
+		return nil
 	}
 	
-	var input models.LoginInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		responseError(w, http.StatusBadRequest, "Invalid JSON body")
-		return
+	user, err := h.Users.FindByLogin(r.Context(), login)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		requestLogger(r).Error("fetch login user failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memproses login")
+		return nil
 	}
 	
-	var user models.User
-	var passwordHash string
+	var userID int
+	if user != nil {
+		userID = user.ID
+	}
 	
-	query := `
-		SELECT id, uuid, username, email, full_name, phone, role, is_active, password_hash
-		FROM users
-		WHERE (username = $1 OR email = $1)
-		AND deleted_at IS NULL
-	`
+	accountKey := accountThrottleKey(userID, login)
+	if wait, err := h.lockedFor(r.Context(), accountKey); err != nil {
+		requestLogger(r).Error("check lockout failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memproses login")
+		return nil
+	} else if wait > 0 {
+		responseLocked(w, wait)
+		return nil
+	}
 	
-	err := config.DB.QueryRow(query, input.Username).Scan(
-		&user.ID, &user.UUID, &user.Username, &user.Email,
-		&user.FullName, &user.Phone, &user.Role, &user.IsActive, &passwordHash,
-	)
+	// Unknown accounts are still checked against a hash so both failure
+	// paths take the same time and return the same message.
+	passwordHash := dummyPasswordHash()
+	if user != nil {
+		passwordHash = user.Password
+	}
 	
-	if err == sql.ErrNoRows {
-		responseError(w, http.StatusUnauthorized, "Akun tidak ditemukan")
-		return
-	} else if err != nil {
-		responseError(w, http.StatusInternalServerError, err.Error())
-		return
+	if !checkPasswordHash(password, passwordHash) || user == nil {
+		h.recordLoginFailure(r.Context(), accountKey, accountFreeAttempts)
+		h.recordLoginFailure(r.Context(), ipKey, ipFreeAttempts)
+		responseErrorCode(w, http.StatusUnauthorized, "invalid_credentials", invalidCredentialsMessage)
+		return nil
 	}
+	user.Password = ""
+	
+	h.clearLoginFailures(r.Context(), accountKey)
 	
 	if !user.IsActive {
 		responseError(w, http.StatusForbidden, "Akun nonaktif")
+		return nil
+	}
+	
+	if user.EmailVerifiedAt == nil {
+		responseErrorCode(w, http.StatusForbidden, "email_not_verified", "Email belum diverifikasi, silakan cek email Anda")
+		return nil
+	}
+	
+	return user
+}
+
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var input models.LoginInput
+	if !decodeJSON(w, r, &input) {
 		return
 	}
 	
-	if !checkPasswordHash(input.Password, passwordHash) {
-		responseError(w, http.StatusUnauthorized, "Password salah")
+	user := h.checkCredentials(w, r, input.Username, input.Password)
+	if user == nil {
 		return
 	}
 	
-	_, _ = config.DB.Exec("UPDATE users SET last_login = NOW() WHERE id = $1", user.ID)
+	if user.TwoFactor {
+		if err := h.startTwoFactorChallenge(w, r, user.ID); err != nil {
+			requestLogger(r).Error("start 2fa challenge failed", "err", err)
+			responseError(w, http.StatusInternalServerError, "Gagal memproses login")
+			return
+		}
+		
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(
+			models.Response{
+				Status:  200,
+				Code:    "two_factor_required",
+				Message: "Masukkan kode autentikasi dua faktor",
+				Data:    map[string]bool{"two_factor_required": true},
+			},
+		)
+		return
+	}
 	
-	if user.Role == "admin" || user.Role == "superadmin" {
-		session, _ := config.AdminStore.Get(r, "admin-session-token")
-		session.Values["user_id"] = user.ID
-		session.Values["authenticated"] = true
-		session.Save(r, w)
-	} else {
-		session, _ := config.UserStore.Get(r, "user-session-token")
-		session.Values["user_id"] = user.ID
-		session.Values["authenticated"] = true
-		session.Save(r, w)
+	h.completeLogin(w, r, user)
+}
+
+// completeLogin creates the session once every login factor has passed.
+// Admins without 2FA get a restricted session while RequireAdmin2FA is on.
+func (h *Handler) completeLogin(w http.ResponseWriter, r *http.Request, user *models.User) {
+	if err := h.Users.TouchLastLogin(r.Context(), user.ID); err != nil {
+		requestLogger(r).Error("update last login failed", "err", err)
+	}
+	
+	setupRequired := user.IsAdmin() && !user.TwoFactor && config.RequireAdmin2FA
+	
+	// A fresh CSRF token per login, so one seen before logging in is useless.
+	csrfToken := newToken()
+	
+	cookies, name := h.UserCookies, "user-session-token"
+	if user.IsAdmin() {
+		cookies, name = h.AdminCookies, "admin-session-token"
+	}
+	
+	session, err := getSession(cookies, r, name)
+	if err != nil {
+		requestLogger(r).Error("load session failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memproses login")
+		return
+	}
+	
+	// Start from a new, empty session so an ID planted before login is
+	// useless afterwards.
+	if renewer, ok := cookies.(sessionRenewer); ok {
+		if err := renewer.Renew(r, session); err != nil {
+			requestLogger(r).Error("renew session failed", "err", err)
+			responseError(w, http.StatusInternalServerError, "Gagal memproses login")
+			return
+		}
+	}
+	clear(session.Values)
+	session.Values["user_id"] = user.ID
+	session.Values["authenticated"] = true
+	session.Values[csrfSessionKey] = csrfToken
+	if setupRequired {
+		session.Values["two_factor_setup_required"] = true
+	}
+	if err := session.Save(r, w); err != nil {
+		requestLogger(r).Error("save session failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memproses login")
+		return
+	}
+	w.Header().Set(CSRFHeader, csrfToken)
+	
+	response := models.Response{
+		Status:  200,
+		Message: "Login Berhasil",
+		Data:    user,
+	}
+	if setupRequired {
+		response.Code = "two_factor_setup_required"
+		response.Message = "Login Berhasil, aktifkan autentikasi dua faktor untuk melanjutkan"
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(
-		models.Response{
-			Status:  200,
-			Message: "Login Berhasil",
-			Data:    user,
-		},
-	)
+	json.NewEncoder(w).Encode(response)
 }
 
-func Logout(w http.ResponseWriter, r *http.Request) {
-	adminSession, _ := config.AdminStore.Get(r, "admin-session-token")
-	adminSession.Values["authenticated"] = false
-	adminSession.Options.MaxAge = -1
-	adminSession.Save(r, w)
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if token, ok := bearerToken(r); ok {
+		h.revokeBearerToken(r.Context(), token)
+	}
 	
-	userSession, _ := config.UserStore.Get(r, "user-session-token")
-	userSession.Values["authenticated"] = false
-	userSession.Options.MaxAge = -1
-	userSession.Save(r, w)
+	for _, c := range []struct {
+		cookies sessions.Store
+		name    string
+	}{
+		{h.AdminCookies, "admin-session-token"},
+		{h.UserCookies, "user-session-token"},
+	} {
+		session, err := getSession(c.cookies, r, c.name)
+		if err == nil {
+			session.Values["authenticated"] = false
+			session.Options.MaxAge = -1
+			err = session.Save(r, w)
+		}
+		if err != nil {
+			requestLogger(r).Error("end session failed", "session", c.name, "err", err)
+			responseError(w, http.StatusInternalServerError, "Gagal logout")
+			return
+		}
+	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(
@@ -116,44 +260,127 @@ func Logout(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func GetMe(w http.ResponseWriter, r *http.Request) {
-	var userID interface{}
-	var authenticated bool
-	
-	adminSession, _ := config.AdminStore.Get(r, "admin-session-token")
+// sessionRenewer is implemented by session stores that keep sessions
+// server-side under an ID, see config.PGStore.Renew.
+type sessionRenewer interface {
+	Renew(r *http.Request, session *sessions.Session) error
+}
+
+// getSession is Store.Get that treats a cookie which no longer decodes, such
+// as an expired one or one signed with a rotated key, as no session. Other
+// errors come from the store itself and are returned.
+func getSession(cookies sessions.Store, r *http.Request, name string) (*sessions.Session, error) {
+	session, err := cookies.Get(r, name)
+	var cookieErr securecookie.Error
+	if errors.As(err, &cookieErr) && cookieErr.IsDecode() {
+		return session, nil
+	}
+	return session, err
+}
+
+// authenticatedSession returns the admin or user session that is logged in,
+// or nil when neither is.
+func (h *Handler) authenticatedSession(r *http.Request) (*sessions.Session, error) {
+	adminSession, err := getSession(h.AdminCookies, r, "admin-session-token")
+	if err != nil {
+		return nil, err
+	}
 	if auth, ok := adminSession.Values["authenticated"].(bool); ok && auth {
-		userID = adminSession.Values["user_id"]
-		authenticated = true
-	} else {
-		userSession, _ := config.UserStore.Get(r, "user-session-token")
-		if auth, ok := userSession.Values["authenticated"].(bool); ok && auth {
-			userID = userSession.Values["user_id"]
-			authenticated = true
-		}
+		return adminSession, nil
 	}
 	
-	if !authenticated {
-		responseError(w, http.StatusUnauthorized, "Unauthorized")
-		return
+	userSession, err := getSession(h.UserCookies, r, "user-session-token")
+	if err != nil {
+		return nil, err
+	}
+	if auth, ok := userSession.Values["authenticated"].(bool); ok && auth {
+		return userSession, nil
 	}
 	
-	var user models.User
-	query := `
-		SELECT id, uuid, username, email, full_name, phone, role, is_active,
-		COALESCE(profile_image, '') as profile_image
-		FROM users WHERE id = $1
-	`
+	return nil, nil
+}
+
+// SessionUser resolves the user behind the admin or user session cookie.
+// Inactive users are returned as-is so callers can decide how to treat them.
+func (h *Handler) SessionUser(r *http.Request) (*models.User, error) {
+	session, err := h.authenticatedSession(r)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrUnauthenticated
+	}
 	
-	err := config.DB.QueryRow(query, userID).Scan(
-		&user.ID, &user.UUID, &user.Username, &user.Email,
-		&user.FullName, &user.Phone, &user.Role, &user.IsActive, &user.ProfileImage,
-	)
+	userID, _ := session.Values["user_id"].(int)
 	
-	if err != nil {
-		responseError(w, http.StatusNotFound, "User not found")
+	user, err := h.Users.Get(r.Context(), userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrUnauthenticated
+	}
+	return user, err
+}
+
+// AuthenticatedUser resolves the caller from a bearer token when the request
+// carries one, otherwise from the session cookie. An invalid bearer token
+// never falls back to the cookie.
+func (h *Handler) AuthenticatedUser(r *http.Request) (*models.User, error) {
+	if token, ok := bearerToken(r); ok {
+		return h.tokenUser(r.Context(), token)
+	}
+	return h.SessionUser(r)
+}
+
+// TwoFactorSetupPending reports whether the session belongs to an admin who
+// still has to enroll in two-factor authentication, see RequireAdmin2FA.
+func (h *Handler) TwoFactorSetupPending(r *http.Request) (bool, error) {
+	session, err := h.authenticatedSession(r)
+	if session == nil {
+		return false, err
+	}
+	pending, _ := session.Values["two_factor_setup_required"].(bool)
+	return pending, nil
+}
+
+// WithUser stores the authenticated user in the request context and tags
+// the request logger with the user ID.
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	logger.SetUserID(ctx, user.ID)
+	ctx = logger.With(ctx, "user_id", user.ID)
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// CurrentUser returns the user placed in the context by the role middleware,
+// or nil for unauthenticated routes.
+func CurrentUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	return user
+}
+
+// actingUserID returns the ID of the user the request acts on. Regular users
+// always act on themselves; admins may name another user through requested.
+func actingUserID(r *http.Request, requested int) int {
+	user := CurrentUser(r)
+	if requested > 0 && user.IsAdmin() {
+		return requested
+	}
+	return user.ID
+}
+
+func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
+	user := CurrentUser(r)
+	if user == nil {
+		responseError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 	
+	// Cookie sessions get the CSRF token to send back on mutations; bearer
+	// token clients do not need one.
+	if _, ok := bearerToken(r); !ok && CurrentAPIKey(r) == nil {
+		if token := h.sessionCSRFToken(w, r); token != "" {
+			w.Header().Set(CSRFHeader, token)
+		}
+	}
+	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(
 		models.Response{
@@ -164,20 +391,15 @@ func GetMe(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func Register(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		responseError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-	
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	var input models.RegisterInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		responseError(w, http.StatusBadRequest, "Invalid JSON data")
+	if !decodeJSON(w, r, &input) {
 		return
 	}
 	
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(input.Password)
 	if err != nil {
+		requestLogger(r).Error("hash password failed", "err", err)
 		responseError(w, http.StatusInternalServerError, "Gagal enkripsi password")
 		return
 	}
@@ -187,24 +409,37 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		phone = &input.Phone
 	}
 	
-	query := `
-		INSERT INTO users (username, email, password_hash, full_name, phone, role, is_active)
-		VALUES ($1, $2, $3, $4, $5, 'user', TRUE)
-	`
-	
-	_, err = config.DB.Exec(query, input.Username, input.Email, string(hashedPassword), input.FullName, phone)
+	// The email stays unverified until the link from the verification email
+	// is opened, see VerifyEmail.
+	user := models.User{
+		Username: input.Username,
+		Email:    input.Email,
+		Password: hashedPassword,
+		FullName: input.FullName,
+		Phone:    phone,
+		Role:     models.RoleUser,
+		IsActive: true,
+	}
 	
-	if err != nil {
-		responseError(w, http.StatusConflict, "Username atau Email sudah terdaftar")
+	err = h.Users.Create(r.Context(), &user)
+	if errors.Is(err, store.ErrDuplicate) {
+		responseErrorCode(w, http.StatusConflict, "user_exists", "Username atau Email sudah terdaftar")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("register failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mendaftarkan user")
 		return
 	}
+	metrics.Registrations.Inc()
+	
+	sendVerificationEmail(r.Context(), user.ID, input.Email, input.FullName)
 	
-	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(
 		models.Response{
 			Status:  201,
-			Message: "Registrasi Berhasil",
+			Message: "Registrasi Berhasil, silakan cek email untuk verifikasi akun",
 		},
 	)
 }
@@ -0,0 +1,413 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	
+	"backend-wisata/config"
+	"backend-wisata/models"
+	
+	"github.com/gorilla/sessions"
+)
+
+func TestLogin(t *testing.T) {
+	e := newTestEnv(t)
+	ctx := context.Background()
+	
+	active := e.newUser(models.RoleUser)
+	admin := e.newUser(models.RoleAdmin)
+	twoFactor := e.newUser(models.RoleUser)
+	e.enableTwoFactor(twoFactor)
+	
+	inactive := e.newUser(models.RoleUser)
+	if err := e.Users.UpdateStatus(ctx, inactive.ID, false, models.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	
+	unverified := &models.User{
+		Username: "unverified",
+		Email:    "unverified@example.test",
+		Password: testPasswordHash,
+		FullName: "Unverified",
+		Role:     models.RoleUser,
+		IsActive: true,
+	}
+	if err := e.Users.Create(ctx, unverified); err != nil {
+		t.Fatal(err)
+	}
+	
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		status   int
+		code     string
+		session  bool
+	}{
+		{"username", active.Username, testPassword, http.StatusOK, "", true},
+		{"email", active.Email, testPassword, http.StatusOK, "", true},
+		{"admin", admin.Username, testPassword, http.StatusOK, "", true},
+		{"wrong password", active.Username, "salah-salah", http.StatusUnauthorized, "invalid_credentials", false},
+		{"unknown user", "nobody", testPassword, http.StatusUnauthorized, "invalid_credentials", false},
+		{"inactive", inactive.Username, testPassword, http.StatusForbidden, "", false},
+		{"unverified email", unverified.Username, testPassword, http.StatusForbidden, "email_not_verified", false},
+		{"two-factor", twoFactor.Username, testPassword, http.StatusOK, "two_factor_required", false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec, res := serve(
+					t, e.Login, newRequest(
+						http.MethodPost, "/api/v1/login", map[string]string{"username": tt.login, "password": tt.password},
+					),
+				)
+				expectStatus(t, rec, res, tt.status, tt.code)
+				
+				r := withCookies(httptest.NewRequest(http.MethodGet, "/api/v1/me", nil), rec.Result().Cookies())
+				user, err := e.AuthenticatedUser(r)
+				if tt.session {
+					if err != nil {
+						t.Fatal("session not usable:", err)
+					}
+					if user.Username != tt.login && user.Email != tt.login {
+						t.Fatalf("session belongs to %q", user.Username)
+					}
+					if rec.Header().Get(CSRFHeader) == "" {
+						t.Fatal("no CSRF token handed out")
+					}
+				} else if !errors.Is(err, ErrUnauthenticated) {
+					t.Fatalf("got a session: user %v, err %v", user, err)
+				}
+			},
+		)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	
+	login := func(password string) (int, string) {
+		rec, res := serve(
+			t, e.Login, newRequest(
+				http.MethodPost, "/api/v1/login", map[string]string{"username": user.Username, "password": password},
+			),
+		)
+		return rec.Code, res.Code
+	}
+	
+	for i := 0; i < accountFreeAttempts; i++ {
+		if status, _ := login("salah-salah"); status != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status %d", i+1, status)
+		}
+	}
+	
+	// The right password does not help while the account is locked.
+	if status, code := login(testPassword); status != http.StatusTooManyRequests || code != "too_many_attempts" {
+		t.Fatalf("locked account: status %d, code %q", status, code)
+	}
+	
+	rec, res := serve(t, e.UnlockUser, withPath(newRequest(http.MethodPost, "/", nil), "id", strconv.Itoa(user.ID)))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	
+	if status, code := login(testPassword); status != http.StatusOK {
+		t.Fatalf("unlocked account: status %d, code %q", status, code)
+	}
+}
+
+func TestLoginRequireAdmin2FA(t *testing.T) {
+	config.RequireAdmin2FA = true
+	t.Cleanup(func() { config.RequireAdmin2FA = false })
+	
+	e := newTestEnv(t)
+	
+	tests := []struct {
+		name    string
+		role    string
+		code    string
+		pending bool
+	}{
+		{"admin without 2fa", models.RoleAdmin, "two_factor_setup_required", true},
+		{"superadmin without 2fa", models.RoleSuperadmin, "two_factor_setup_required", true},
+		{"user", models.RoleUser, "", false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				user := e.newUser(tt.role)
+				rec, res := serve(
+					t, e.Login, newRequest(
+						http.MethodPost, "/api/v1/login", map[string]string{"username": user.Username, "password": testPassword},
+					),
+				)
+				expectStatus(t, rec, res, http.StatusOK, tt.code)
+				
+				r := withCookies(httptest.NewRequest(http.MethodGet, "/", nil), rec.Result().Cookies())
+				if got, err := e.TwoFactorSetupPending(r); err != nil || got != tt.pending {
+					t.Fatalf("TwoFactorSetupPending = %v, %v, want %v", got, err, tt.pending)
+				}
+			},
+		)
+	}
+}
+
+func TestSessionEnd(t *testing.T) {
+	e := newTestEnv(t)
+	
+	// end ends the session of user logged in with cookies and returns the
+	// cookies that must no longer be logged in.
+	tests := []struct {
+		name string
+		end  func(user *models.User, cookies []*http.Cookie) [][]*http.Cookie
+	}{
+		{
+			"logout", func(user *models.User, cookies []*http.Cookie) [][]*http.Cookie {
+				rec, res := serve(t, e.Logout, withCookies(newRequest(http.MethodPost, "/api/v1/logout", nil), cookies))
+				expectStatus(t, rec, res, http.StatusOK, "")
+				if e.DB == nil {
+					// Only the session table can tell that the old cookie
+					// was logged out.
+					return [][]*http.Cookie{rec.Result().Cookies()}
+				}
+				return [][]*http.Cookie{rec.Result().Cookies(), cookies}
+			},
+		},
+		{
+			"user deleted", func(user *models.User, cookies []*http.Cookie) [][]*http.Cookie {
+				if err := e.Users.Delete(context.Background(), user.ID); err != nil {
+					t.Fatal(err)
+				}
+				return [][]*http.Cookie{cookies}
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				user := e.newUser(models.RoleUser)
+				cookies, _ := e.login(user)
+				for _, c := range tt.end(user, cookies) {
+					if status := e.sessionStatus(c); status != http.StatusUnauthorized {
+						t.Fatalf("ended session got %d, want 401", status)
+					}
+				}
+			},
+		)
+	}
+}
+
+// brokenSessions is a session store whose backend is down.
+type brokenSessions struct{}
+
+func (brokenSessions) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.NewSession(brokenSessions{}, name), errors.New("session store down")
+}
+
+func (s brokenSessions) New(r *http.Request, name string) (*sessions.Session, error) {
+	return s.Get(r, name)
+}
+
+func (brokenSessions) Save(*http.Request, http.ResponseWriter, *sessions.Session) error {
+	return errors.New("session store down")
+}
+
+func TestSessionStoreErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		broken  bool
+		cookie  *http.Cookie
+		handler func(e *testEnv) http.HandlerFunc
+		status  int
+	}{
+		{"login with stale cookie", false, &http.Cookie{Name: "user-session-token", Value: "bukan-sesi"}, func(e *testEnv) http.HandlerFunc { return e.Login }, http.StatusOK},
+		{"login with store down", true, nil, func(e *testEnv) http.HandlerFunc { return e.Login }, http.StatusInternalServerError},
+		{"logout with stale cookie", false, &http.Cookie{Name: "user-session-token", Value: "bukan-sesi"}, func(e *testEnv) http.HandlerFunc { return e.Logout }, http.StatusOK},
+		{"logout with store down", true, nil, func(e *testEnv) http.HandlerFunc { return e.Logout }, http.StatusInternalServerError},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				e := newTestEnv(t)
+				user := e.newUser(models.RoleUser)
+				if tt.broken {
+					e.UserCookies = brokenSessions{}
+				}
+				
+				r := newRequest(http.MethodPost, "/", map[string]string{"username": user.Username, "password": testPassword})
+				if tt.cookie != nil {
+					r.AddCookie(tt.cookie)
+				}
+				rec, res := serve(t, tt.handler(e), r)
+				expectStatus(t, rec, res, tt.status, "")
+			},
+		)
+	}
+}
+
+// idSessions keeps session values server-side under the ID in the cookie,
+// like config.PGStore.
+type idSessions struct {
+	rows map[string]map[any]any
+}
+
+func (s *idSessions) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+func (s *idSessions) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	session.Options = &sessions.Options{Path: "/"}
+	session.IsNew = true
+	if cookie, err := r.Cookie(name); err == nil {
+		if values, ok := s.rows[cookie.Value]; ok {
+			session.ID, session.IsNew = cookie.Value, false
+			maps.Copy(session.Values, values)
+		}
+	}
+	return session, nil
+}
+
+func (s *idSessions) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.ID == "" {
+		session.ID = newToken()
+	}
+	s.rows[session.ID] = maps.Clone(session.Values)
+	http.SetCookie(w, sessions.NewCookie(session.Name(), session.ID, session.Options))
+	return nil
+}
+
+func (s *idSessions) Renew(r *http.Request, session *sessions.Session) error {
+	delete(s.rows, session.ID)
+	session.ID = ""
+	return nil
+}
+
+func TestLoginRenewsSessionID(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	
+	// The planted session is one an attacker obtained before the victim
+	// logged in with it.
+	cookies := &idSessions{rows: map[string]map[any]any{"planted": {"theme": "gelap"}}}
+	e.UserCookies = cookies
+	
+	r := newRequest(http.MethodPost, "/api/v1/login", map[string]string{"username": user.Username, "password": testPassword})
+	r.AddCookie(&http.Cookie{Name: "user-session-token", Value: "planted"})
+	rec, res := serve(t, e.Login, r)
+	expectStatus(t, rec, res, http.StatusOK, "")
+	
+	if _, ok := cookies.rows["planted"]; ok {
+		t.Fatal("planted session survived the login")
+	}
+	var issued string
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "user-session-token" {
+			issued = c.Value
+		}
+	}
+	values, ok := cookies.rows[issued]
+	if !ok || issued == "planted" {
+		t.Fatalf("login issued session %q", issued)
+	}
+	if values["user_id"] != user.ID || values["theme"] != nil {
+		t.Fatalf("new session holds %v", values)
+	}
+}
+
+func TestGetMe(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	cookies, csrfToken := e.login(user)
+	
+	tests := []struct {
+		name string
+		r    *http.Request
+		csrf string
+	}{
+		{"cookie session", withCookies(httptest.NewRequest(http.MethodGet, "/api/v1/me", nil), cookies), csrfToken},
+		{"bearer token", withBearer(httptest.NewRequest(http.MethodGet, "/api/v1/me", nil), "token"), ""},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec, res := serve(t, e.GetMe, asUser(tt.r, user))
+				expectStatus(t, rec, res, http.StatusOK, "")
+				
+				var got models.User
+				decodeData(t, res, &got)
+				if got.ID != user.ID {
+					t.Fatalf("user %d, want %d", got.ID, user.ID)
+				}
+				if header := rec.Header().Get(CSRFHeader); header != tt.csrf {
+					t.Fatalf("CSRF header %q, want %q", header, tt.csrf)
+				}
+			},
+		)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	e := newTestEnv(t)
+	existing := e.newUser(models.RoleUser)
+	
+	valid := func() map[string]string {
+		return map[string]string{
+			"username":  "budi",
+			"email":     "budi@example.test",
+			"password":  "rahasia123",
+			"full_name": "Budi Santoso",
+		}
+	}
+	
+	tests := []struct {
+		name   string
+		edit   func(map[string]string)
+		status int
+		code   string
+		field  string
+	}{
+		{"valid", func(map[string]string) {}, http.StatusCreated, "", ""},
+		{"username taken", func(in map[string]string) { in["username"], in["email"] = existing.Username, "lain@example.test" }, http.StatusConflict, "user_exists", ""},
+		{"email taken", func(in map[string]string) { in["username"], in["email"] = "lain", existing.Email }, http.StatusConflict, "user_exists", ""},
+		{"invalid email", func(in map[string]string) { in["email"] = "bukan-email" }, http.StatusUnprocessableEntity, "validation_failed", "email"},
+		{"short username", func(in map[string]string) { in["username"] = "ab" }, http.StatusUnprocessableEntity, "validation_failed", "username"},
+		{"missing name", func(in map[string]string) { delete(in, "full_name") }, http.StatusUnprocessableEntity, "validation_failed", "full_name"},
+		// 40 characters but 80 bytes, more than bcrypt accepts.
+		{"password too long", func(in map[string]string) { in["password"] = strings.Repeat("é", 40) }, http.StatusUnprocessableEntity, "validation_failed", "password"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				input := valid()
+				tt.edit(input)
+				
+				rec, res := serve(t, e.Register, newRequest(http.MethodPost, "/api/v1/register", input))
+				expectStatus(t, rec, res, tt.status, tt.code)
+				if tt.field != "" && len(res.Details[tt.field]) == 0 {
+					t.Fatalf("no error for field %s: %v", tt.field, res.Details)
+				}
+			},
+		)
+	}
+	
+	// The new account has to verify its email before logging in.
+	if got := len(outbox.messages(t, "budi@example.test")); got != 1 {
+		t.Fatalf("%d verification emails sent, want 1", got)
+	}
+	rec, res := serve(
+		t, e.Login, newRequest(http.MethodPost, "/api/v1/login", map[string]string{"username": "budi", "password": "rahasia123"}),
+	)
+	expectStatus(t, rec, res, http.StatusForbidden, "email_not_verified")
+}
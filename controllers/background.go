@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+)
+
+// background tracks work handlers start after responding, such as sending
+// email, so shutdown can wait for it.
+var background sync.WaitGroup
+
+func goBackground(fn func()) {
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		fn()
+	}()
+}
+
+// WaitBackground blocks until all background work finished or ctx is done.
+func WaitBackground(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(done)
+	}()
+	
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
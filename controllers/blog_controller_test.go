@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	
+	"backend-wisata/config"
+	"backend-wisata/models"
+)
+
+func (e *testEnv) newBlogCategory() *models.BlogCategory {
+	e.t.Helper()
+	n := e.next()
+	c := &models.BlogCategory{Name: "Tips " + n, Slug: "tips-" + n}
+	if err := e.Blog.CreateCategory(context.Background(), c); err != nil {
+		e.t.Fatal("create blog category:", err)
+	}
+	return c
+}
+
+// newBlogPost publishes a post by author in category, related to wisata.
+func (e *testEnv) newBlogPost(author *models.User, category *models.BlogCategory, wisata ...int) *models.BlogPost {
+	e.t.Helper()
+	n := e.next()
+	p := &models.BlogPost{
+		Title:      "Artikel " + n,
+		Slug:       "artikel-" + n,
+		Content:    "Isi artikel " + n,
+		CategoryID: category.ID,
+		AuthorID:   author.ID,
+	}
+	if err := e.Blog.Create(context.Background(), p, wisata); err != nil {
+		e.t.Fatal("create blog post:", err)
+	}
+	return p
+}
+
+func TestGetBlogPosts(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	tips := e.newBlogCategory()
+	kuliner := e.newBlogCategory()
+	
+	first := e.newBlogPost(admin, tips)
+	e.newBlogPost(admin, tips)
+	e.newBlogPost(admin, kuliner)
+	
+	tests := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{"all", "/api/v1/blog/posts", 3},
+		{"by category", "/api/v1/blog/posts?category=" + tips.Slug, 2},
+		{"search by title", "/api/v1/blog/posts?q=" + url.QueryEscape(strings.ToUpper(first.Title)), 1},
+		{"unknown category", "/api/v1/blog/posts?category=tidak-ada", 0},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec, res := serve(t, e.GetBlogPosts, newRequest(http.MethodGet, tt.target, nil))
+				expectStatus(t, rec, res, http.StatusOK, "")
+				
+				var list []models.BlogPost
+				if res.Data != nil {
+					decodeData(t, res, &list)
+				}
+				if len(list) != tt.want {
+					t.Fatalf("%d posts, want %d", len(list), tt.want)
+				}
+				for _, p := range list {
+					if p.AuthorName != admin.FullName || p.PublishedAt == "" {
+						t.Fatalf("post listed as %+v", p)
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestGetBlogDetail(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	wisata := e.newWisata()
+	if err := e.Wisata.SetImage(context.Background(), wisata.ID, "/uploads/pantai.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	post := e.newBlogPost(admin, e.newBlogCategory(), wisata.ID)
+	
+	tests := []struct {
+		name   string
+		slug   string
+		status int
+	}{
+		{"existing", post.Slug, http.StatusOK},
+		{"unknown", "tidak-ada", http.StatusNotFound},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := withPath(newRequest(http.MethodGet, "/api/v1/blog/posts/"+tt.slug, nil), "slug", tt.slug)
+				rec, res := serve(t, e.GetBlogDetail, r)
+				expectStatus(t, rec, res, tt.status, "")
+				if tt.status != http.StatusOK {
+					return
+				}
+				
+				var got models.BlogPost
+				decodeData(t, res, &got)
+				if got.Content != post.Content || len(got.RelatedWisata) != 1 {
+					t.Fatalf("got %+v", got)
+				}
+				if want := config.App.Server.PublicURL + "/uploads/pantai.jpg"; got.RelatedWisata[0].ImageURL != want {
+					t.Fatalf("related image %q, want %q", got.RelatedWisata[0].ImageURL, want)
+				}
+			},
+		)
+	}
+}
+
+func TestCreateBlogPost(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	category := e.newBlogCategory()
+	wisata := e.newWisata()
+	existing := e.newBlogPost(admin, category)
+	
+	valid := func() map[string]string {
+		return map[string]string{
+			"title":              "Liburan ke Lembang",
+			"slug":               "liburan-ke-lembang",
+			"content":            "Isi artikel",
+			"category_id":        strconv.Itoa(category.ID),
+			"related_wisata_ids": strconv.Itoa(wisata.ID) + ", bukan-id",
+		}
+	}
+	
+	tests := []struct {
+		name   string
+		edit   func(map[string]string)
+		status int
+		field  string
+	}{
+		{"valid", func(map[string]string) {}, http.StatusOK, ""},
+		{"with thumbnail", func(in map[string]string) { in["slug"] = "liburan-2"; in["file:thumbnail"] = "jpeg" }, http.StatusOK, ""},
+		{"slug taken", func(in map[string]string) { in["slug"] = existing.Slug }, http.StatusConflict, "slug"},
+		{"invalid slug", func(in map[string]string) { in["slug"] = "Liburan Lembang" }, http.StatusUnprocessableEntity, "slug"},
+		{"missing content", func(in map[string]string) { delete(in, "content") }, http.StatusUnprocessableEntity, "content"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				input := valid()
+				tt.edit(input)
+				
+				rec, res := serve(t, e.CreateBlogPost, asUser(newFormRequest(http.MethodPost, "/api/v1/blog/posts", input), admin))
+				expectStatus(t, rec, res, tt.status, "")
+				if tt.field != "" {
+					if len(res.Details[tt.field]) == 0 {
+						t.Fatalf("no error for field %s: %v", tt.field, res.Details)
+					}
+					return
+				}
+				
+				got, err := e.Blog.GetBySlug(context.Background(), input["slug"])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.AuthorName != admin.FullName || len(got.RelatedWisata) != 1 || got.RelatedWisata[0].ID != wisata.ID {
+					t.Fatalf("stored %+v", got)
+				}
+				if _, hasThumbnail := input["file:thumbnail"]; hasThumbnail != (got.Thumbnail != "") {
+					t.Fatalf("thumbnail %q", got.Thumbnail)
+				}
+			},
+		)
+	}
+}
+
+func TestUpdateAndDeleteBlogPost(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	category := e.newBlogCategory()
+	post := e.newBlogPost(admin, category, e.newWisata().ID)
+	other := e.newBlogPost(admin, category)
+	id := strconv.Itoa(post.ID)
+	
+	// The cases run in order: the post is updated, then deleted.
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		id      string
+		slug    string
+		status  int
+	}{
+		{"update to a taken slug", e.UpdateBlogPost, id, other.Slug, http.StatusConflict},
+		{"update", e.UpdateBlogPost, id, "judul-baru", http.StatusOK},
+		{"update unknown", e.UpdateBlogPost, "9999", "lain", http.StatusNotFound},
+		{"update not a number", e.UpdateBlogPost, "abc", "lain", http.StatusNotFound},
+		{"delete", e.DeleteBlogPost, id, "", http.StatusOK},
+		{"update deleted", e.UpdateBlogPost, id, "lain", http.StatusNotFound},
+		{"delete again", e.DeleteBlogPost, id, "", http.StatusNotFound},
+		{"delete unknown", e.DeleteBlogPost, "9999", "", http.StatusNotFound},
+		{"delete not a number", e.DeleteBlogPost, "abc", "", http.StatusNotFound},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := newRequest(http.MethodDelete, "/api/v1/blog/posts/"+tt.id, nil)
+				if tt.slug != "" {
+					input := map[string]string{
+						"title":       "Judul Baru",
+						"slug":        tt.slug,
+						"content":     "Isi baru",
+						"category_id": strconv.Itoa(category.ID),
+					}
+					r = newFormRequest(http.MethodPut, "/api/v1/blog/posts/"+tt.id, input)
+				}
+				rec, res := serve(t, tt.handler, asUser(withPath(r, "id", tt.id), admin))
+				expectStatus(t, rec, res, tt.status, "")
+				if tt.status != http.StatusOK || tt.slug == "" {
+					return
+				}
+				
+				got, err := e.Blog.GetBySlug(context.Background(), tt.slug)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.Title != "Judul Baru" || got.Content != "Isi baru" || len(got.RelatedWisata) != 0 {
+					t.Fatalf("stored %+v", got)
+				}
+			},
+		)
+	}
+	
+	r := withPath(newRequest(http.MethodGet, "/", nil), "slug", "judul-baru")
+	rec, res := serve(t, e.GetBlogDetail, r)
+	expectStatus(t, rec, res, http.StatusNotFound, "")
+}
+
+func TestGetBlogCategories(t *testing.T) {
+	e := newTestEnv(t)
+	first := e.newBlogCategory()
+	second := e.newBlogCategory()
+	
+	rec, res := serve(t, e.GetBlogCategories, newRequest(http.MethodGet, "/api/v1/blog/categories", nil))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	
+	var list []models.BlogCategory
+	decodeData(t, res, &list)
+	if len(list) != 2 || list[0] != *first || list[1] != *second {
+		t.Fatalf("categories %+v", list)
+	}
+}
@@ -1,70 +1,77 @@
 package controllers
 
 import (
-	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 	
 	"backend-wisata/config"
+	"backend-wisata/metrics"
 	"backend-wisata/models"
+	"backend-wisata/store"
+	"backend-wisata/validate"
 )
 
-func CreateBooking(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		responseError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-	
+func (h *Handler) CreateBooking(w http.ResponseWriter, r *http.Request) {
+	// user_id is only honoured for admins booking on behalf of a customer.
 	var input struct {
-		WisataID      int    `json:"wisata_id"`
+		WisataID      int    `json:"wisata_id" validate:"required,min=1"`
 		UserID        int    `json:"user_id"`
-		VisitDate     string `json:"visit_date"`
-		Quantity      int    `json:"quantity"`
-		PaymentMethod string `json:"payment_method"`
+		VisitDate     string `json:"visit_date" validate:"required,date"`
+		Quantity      int    `json:"quantity" validate:"required,min=1,max=100"`
+		PaymentMethod string `json:"payment_method" validate:"max=50"`
 	}
 	
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		responseError(w, http.StatusBadRequest, "Invalid JSON body")
+	if !decodeJSON(w, r, &input) {
 		return
 	}
 	
-	var hargaTiket float64
-	err := config.DB.QueryRow("SELECT harga_tiket FROM wisata WHERE id = $1", input.WisataID).Scan(&hargaTiket)
-	if err != nil {
-		log.Println("ERROR FETCH WISATA:", err)
-		responseError(w, http.StatusNotFound, "Wisata tidak ditemukan")
+	// Dates in YYYY-MM-DD form compare correctly as strings.
+	if input.VisitDate < time.Now().Format(time.DateOnly) {
+		responseValidation(w, validate.Errors{"visit_date": {"tidak boleh sebelum hari ini"}})
 		return
 	}
 	
-	finalPrice := hargaTiket * float64(input.Quantity)
+	userID := actingUserID(r, input.UserID)
 	
-	query := `
-		INSERT INTO bookings (wisata_id, user_id, visit_date, quantity, total_price, final_price, status, payment_method, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, 'pending', $7, NOW())
-		RETURNING id, booking_code
-	`
+	wisata, err := h.Wisata.Get(r.Context(), input.WisataID)
+	if errors.Is(err, store.ErrNotFound) {
+		responseError(w, http.StatusNotFound, "Wisata tidak ditemukan")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("fetch wisata failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menyimpan booking")
+		return
+	}
 	
-	var newBookingID int
-	var newBookingCode string
+	finalPrice := wisata.HargaTiket * float64(input.Quantity)
 	
-	err = config.DB.QueryRow(
-		query,
-		input.WisataID,
-		input.UserID,
-		input.VisitDate,
-		input.Quantity,
-		finalPrice,
-		finalPrice,
-		input.PaymentMethod,
-	).Scan(&newBookingID, &newBookingCode)
+	// Bookings made by partner integrations remember which key created them.
+	var apiKeyID *int
+	source := "user"
+	if key := CurrentAPIKey(r); key != nil {
+		apiKeyID = &key.ID
+		source = "api_key"
+	}
 	
-	if err != nil {
-		log.Println("ERROR DATABASE:", err)
-		responseError(w, http.StatusInternalServerError, "Gagal menyimpan booking: "+err.Error())
+	booking := models.Booking{
+		WisataID:      input.WisataID,
+		UserID:        userID,
+		VisitDate:     input.VisitDate,
+		Quantity:      input.Quantity,
+		TotalPrice:    finalPrice,
+		FinalPrice:    finalPrice,
+		PaymentMethod: input.PaymentMethod,
+	}
+	
+	if err := h.Bookings.Create(r.Context(), &booking, apiKeyID); err != nil {
+		requestLogger(r).Error("create booking failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menyimpan booking")
 		return
 	}
+	metrics.BookingsCreated.WithLabelValues(source).Inc()
 	
 	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
@@ -73,87 +80,46 @@ func CreateBooking(w http.ResponseWriter, r *http.Request) {
 			Status:  201,
 			Message: "Booking Berhasil Dibuat",
 			Data: map[string]interface{}{
-				"booking_id":   newBookingID,
-				"booking_code": newBookingCode,
+				"booking_id":   booking.ID,
+				"booking_code": booking.BookingCode,
 				"final_price":  finalPrice,
 			},
 		},
 	)
 }
 
-func GetBookingHistory(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" {
-		responseError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
+func (h *Handler) GetBookingHistory(w http.ResponseWriter, r *http.Request) {
+	requestedID, _ := strconv.Atoi(r.URL.Query().Get("user_id"))
+	userID := actingUserID(r, requestedID)
 	
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		responseError(w, http.StatusBadRequest, "User ID required")
-		return
-	}
-	
-	query := `
-		SELECT
-			b.id, b.booking_code, b.wisata_id, w.nama_tempat,
-			b.visit_date, b.quantity, b.total_price, b.final_price,
-			b.status, b.created_at,
-			COALESCE((SELECT image_url FROM wisata_images WHERE wisata_id = w.id LIMIT 1), '') as wisata_image
-		FROM bookings b
-		JOIN wisata w ON b.wisata_id = w.id
-		WHERE b.user_id = $1
-		ORDER BY b.created_at DESC
-	`
-	
-	rows, err := config.DB.Query(query, userID)
+	list, err := h.Bookings.ListByUser(r.Context(), userID)
 	if err != nil {
-		log.Println("ERROR FETCH HISTORY:", err)
-		responseError(w, http.StatusInternalServerError, err.Error())
+		requestLogger(r).Error("fetch history failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil riwayat booking")
 		return
 	}
-	defer rows.Close()
 	
 	var history []map[string]interface{}
 	
-	scheme := "http"
-	if r.TLS != nil {
-		scheme = "https"
-	}
-	baseURL := scheme + "://" + r.Host
+	baseURL := config.App.Server.PublicURL
 	
-	for rows.Next() {
-		var b models.Booking
-		var visitDateRaw time.Time
-		var createdAtRaw time.Time
-		var imagePath string
-		
-		err := rows.Scan(
-			&b.ID, &b.BookingCode, &b.WisataID, &b.WisataNama,
-			&visitDateRaw, &b.Quantity, &b.TotalPrice, &b.FinalPrice,
-			&b.Status, &createdAtRaw, &imagePath,
-		)
-		
-		if err != nil {
-			log.Println("SCAN ERROR:", err)
-			continue
-		}
-		
+	for _, b := range list {
 		bookingItem := map[string]interface{}{
 			"id":           b.ID,
 			"booking_code": b.BookingCode,
 			"wisata_id":    b.WisataID,
 			"wisata_nama":  b.WisataNama,
-			"visit_date":   visitDateRaw.Format("2006-01-02"),
+			"visit_date":   b.VisitDate,
 			"quantity":     b.Quantity,
 			"total_price":  b.TotalPrice,
 			"final_price":  b.FinalPrice,
 			"status":       b.Status,
-			"created_at":   createdAtRaw,
+			"created_at":   b.CreatedAt,
 			"wisata_image": "",
 		}
 		
-		if imagePath != "" {
-			bookingItem["wisata_image"] = baseURL + imagePath
+		if b.WisataImage != "" {
+			bookingItem["wisata_image"] = baseURL + b.WisataImage
 		}
 		
 		history = append(history, bookingItem)
@@ -169,33 +135,34 @@ func GetBookingHistory(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func GetBookingDetail(w http.ResponseWriter, r *http.Request) {
-	
-	code := r.URL.Query().Get("code")
-	
-	query := `
-		SELECT b.id, b.booking_code, b.wisata_id, w.nama_tempat,
-				b.visit_date, b.quantity, b.final_price, b.status
-		FROM bookings b
-		JOIN wisata w ON b.wisata_id = w.id
-		WHERE b.booking_code = $1
-	`
-	
-	var b models.Booking
-	var visitDateRaw time.Time
-	
-	err := config.DB.QueryRow(query, code).Scan(
-		&b.ID, &b.BookingCode, &b.WisataID, &b.WisataNama,
-		&visitDateRaw, &b.Quantity, &b.FinalPrice, &b.Status,
-	)
+// bookingOfCaller fetches a booking the caller may act on: customers and
+// partner keys only reach their own bookings. Otherwise it writes the error
+// response, with failure as the message of internal errors, and returns nil.
+func (h *Handler) bookingOfCaller(w http.ResponseWriter, r *http.Request, code, failure string) *models.Booking {
+	b, err := h.Bookings.GetByCode(r.Context(), code)
+	
+	user := CurrentUser(r)
+	if err == nil && b.UserID != user.ID && !user.IsAdmin() {
+		err = store.ErrNotFound
+	}
 	
-	if err == sql.ErrNoRows {
-		http.Error(w, "Booking not found", http.StatusNotFound)
+	if errors.Is(err, store.ErrNotFound) {
+		responseError(w, http.StatusNotFound, "Booking tidak ditemukan")
+		return nil
+	} else if err != nil {
+		requestLogger(r).Error("fetch booking failed", "err", err)
+		responseError(w, http.StatusInternalServerError, failure)
+		return nil
+	}
+	return b
+}
+
+func (h *Handler) GetBookingDetail(w http.ResponseWriter, r *http.Request) {
+	b := h.bookingOfCaller(w, r, pathParam(r, "code"), "Gagal mengambil booking")
+	if b == nil {
 		return
 	}
 	
-	b.VisitDate = visitDateRaw.Format("2006-01-02")
-	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(
 		models.Response{
@@ -206,35 +173,39 @@ func GetBookingDetail(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func ProcessPayment(w http.ResponseWriter, r *http.Request) {
-	
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
+func (h *Handler) ProcessPayment(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		BookingCode string `json:"booking_code"`
 	}
 	
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, "Invalid body", http.StatusBadRequest)
-		return
+	// /api/v1 names the booking in the path; the deprecated paths send it
+	// in the body.
+	input.BookingCode = r.PathValue("code")
+	if input.BookingCode == "" {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			responseError(w, http.StatusBadRequest, "Invalid JSON body")
+			return
+		}
 	}
 	
-	query := "UPDATE bookings SET status = 'paid', updated_at = NOW() WHERE booking_code = $1"
-	res, err := config.DB.Exec(query, input.BookingCode)
-	
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	b := h.bookingOfCaller(w, r, input.BookingCode, "Gagal memproses pembayaran")
+	if b == nil {
 		return
 	}
 	
-	rowsAffected, _ := res.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Booking code not found", http.StatusNotFound)
+	// The store only changes a booking that is still pending, so a payment
+	// and a cancellation racing each other cannot both succeed.
+	err := h.Bookings.UpdatePendingStatus(r.Context(), b.ID, "paid")
+	if errors.Is(err, store.ErrNotFound) {
+		responseErrorCode(w, http.StatusConflict, "booking_not_pending", "Hanya pesanan pending yang bisa dibayar")
+		return
+	}
+	if err != nil {
+		requestLogger(r).Error("pay booking failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memproses pembayaran")
 		return
 	}
+	metrics.PaymentsProcessed.Inc()
 	
 	json.NewEncoder(w).Encode(
 		models.Response{
@@ -244,60 +215,29 @@ func ProcessPayment(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func GetAllBookings(w http.ResponseWriter, r *http.Request) {
-	
-	query := `
-		SELECT
-			b.id, b.booking_code,
-			u.full_name as user_name,
-			w.nama_tempat as wisata_nama,
-			b.visit_date, b.quantity, b.final_price, b.status, b.payment_method
-		FROM bookings b
-		JOIN users u ON b.user_id = u.id
-		JOIN wisata w ON b.wisata_id = w.id
-		ORDER BY b.created_at DESC
-	`
+func (h *Handler) GetAllBookings(w http.ResponseWriter, r *http.Request) {
 	
-	rows, err := config.DB.Query(query)
+	list, err := h.Bookings.List(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		requestLogger(r).Error("fetch bookings failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil data booking")
 		return
 	}
-	defer rows.Close()
 	
 	var bookings []map[string]interface{}
 	
-	for rows.Next() {
-		var id, quantity int
-		var code, userName, wisataNama, status, method string
-		var finalPrice float64
-		var visitDate time.Time
-		
-		if err := rows.Scan(
-			&id,
-			&code,
-			&userName,
-			&wisataNama,
-			&visitDate,
-			&quantity,
-			&finalPrice,
-			&status,
-			&method,
-		); err != nil {
-			continue
-		}
-		
+	for _, b := range list {
 		bookings = append(
 			bookings, map[string]interface{}{
-				"id":             id,
-				"booking_code":   code,
-				"user_name":      userName,
-				"wisata_nama":    wisataNama,
-				"visit_date":     visitDate.Format("2006-01-02"),
-				"quantity":       quantity,
-				"total_price":    finalPrice,
-				"status":         status,
-				"payment_method": method,
+				"id":             b.ID,
+				"booking_code":   b.BookingCode,
+				"user_name":      b.UserName,
+				"wisata_nama":    b.WisataNama,
+				"visit_date":     b.VisitDate,
+				"quantity":       b.Quantity,
+				"total_price":    b.FinalPrice,
+				"status":         b.Status,
+				"payment_method": b.PaymentMethod,
 			},
 		)
 	}
@@ -312,45 +252,37 @@ func GetAllBookings(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func CancelBooking(w http.ResponseWriter, r *http.Request) {
-	
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
+func (h *Handler) CancelBooking(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		BookingCode string `json:"booking_code"`
 	}
 	
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, "Invalid body", http.StatusBadRequest)
-		return
+	// /api/v1 names the booking in the path; the deprecated paths send it
+	// in the body.
+	input.BookingCode = r.PathValue("code")
+	if input.BookingCode == "" {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			responseError(w, http.StatusBadRequest, "Invalid JSON body")
+			return
+		}
 	}
 	
-	var currentStatus string
-	err := config.DB.QueryRow(
-		"SELECT status FROM bookings WHERE booking_code = $1",
-		input.BookingCode,
-	).Scan(&currentStatus)
-	
-	if err != nil {
-		http.Error(w, "Booking tidak ditemukan", http.StatusNotFound)
+	b := h.bookingOfCaller(w, r, input.BookingCode, "Gagal membatalkan booking")
+	if b == nil {
 		return
 	}
 	
-	if currentStatus != "pending" {
-		http.Error(w, "Hanya pesanan pending yang bisa dibatalkan", http.StatusBadRequest)
+	err := h.Bookings.UpdatePendingStatus(r.Context(), b.ID, "cancelled")
+	if errors.Is(err, store.ErrNotFound) {
+		responseErrorCode(w, http.StatusConflict, "booking_not_pending", "Hanya pesanan pending yang bisa dibatalkan")
 		return
 	}
-	
-	query := "UPDATE bookings SET status = 'cancelled', updated_at = NOW() WHERE booking_code = $1"
-	_, err = config.DB.Exec(query, input.BookingCode)
-	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		requestLogger(r).Error("cancel booking failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal membatalkan booking")
 		return
 	}
+	metrics.BookingsCancelled.Inc()
 	
 	json.NewEncoder(w).Encode(
 		models.Response{
@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+func TestCreateBooking(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	other := e.newUser(models.RoleUser)
+	admin := e.newUser(models.RoleAdmin)
+	partner := e.newUser(models.RolePartner)
+	wisata := e.newWisata()
+	
+	yesterday := time.Now().AddDate(0, 0, -1).Format(time.DateOnly)
+	
+	tests := []struct {
+		name    string
+		as      *models.User
+		apiKey  bool
+		input   map[string]any
+		status  int
+		code    string
+		field   string
+		forUser *models.User
+	}{
+		{"valid", user, false, map[string]any{"wisata_id": wisata.ID, "visit_date": tomorrow(), "quantity": 3}, http.StatusCreated, "", "", user},
+		{"today", user, false, map[string]any{"wisata_id": wisata.ID, "visit_date": time.Now().Format(time.DateOnly), "quantity": 1}, http.StatusCreated, "", "", user},
+		{"user_id ignored for users", user, false, map[string]any{"wisata_id": wisata.ID, "user_id": other.ID, "visit_date": tomorrow(), "quantity": 1}, http.StatusCreated, "", "", user},
+		{"admin on behalf of a user", admin, false, map[string]any{"wisata_id": wisata.ID, "user_id": other.ID, "visit_date": tomorrow(), "quantity": 1}, http.StatusCreated, "", "", other},
+		{"past visit date", user, false, map[string]any{"wisata_id": wisata.ID, "visit_date": yesterday, "quantity": 1}, http.StatusUnprocessableEntity, "validation_failed", "visit_date", nil},
+		{"malformed visit date", user, false, map[string]any{"wisata_id": wisata.ID, "visit_date": "besok", "quantity": 1}, http.StatusUnprocessableEntity, "validation_failed", "visit_date", nil},
+		{"no visitors", user, false, map[string]any{"wisata_id": wisata.ID, "visit_date": tomorrow(), "quantity": 0}, http.StatusUnprocessableEntity, "validation_failed", "quantity", nil},
+		{"too many visitors", user, false, map[string]any{"wisata_id": wisata.ID, "visit_date": tomorrow(), "quantity": 101}, http.StatusUnprocessableEntity, "validation_failed", "quantity", nil},
+		{"quantity as string", user, false, map[string]any{"wisata_id": wisata.ID, "visit_date": tomorrow(), "quantity": "2"}, http.StatusUnprocessableEntity, "validation_failed", "quantity", nil},
+		{"partner with an API key", partner, true, map[string]any{"wisata_id": wisata.ID, "visit_date": tomorrow(), "quantity": 1}, http.StatusCreated, "", "", partner},
+		{"unknown wisata", user, false, map[string]any{"wisata_id": wisata.ID + 1000, "visit_date": tomorrow(), "quantity": 1}, http.StatusNotFound, "", "", nil},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := newRequest(http.MethodPost, "/api/v1/bookings", tt.input)
+				if tt.apiKey {
+					r = WithAPIKey(r, &models.APIKey{ID: 1, UserID: tt.as.ID})
+				}
+				rec, res := serve(t, e.CreateBooking, asUser(r, tt.as))
+				expectStatus(t, rec, res, tt.status, tt.code)
+				if tt.field != "" && len(res.Details[tt.field]) == 0 {
+					t.Fatalf("no error for field %s: %v", tt.field, res.Details)
+				}
+				if tt.forUser == nil {
+					return
+				}
+				
+				var created struct {
+					BookingCode string  `json:"booking_code"`
+					FinalPrice  float64 `json:"final_price"`
+				}
+				decodeData(t, res, &created)
+				
+				b := e.getBooking(created.BookingCode)
+				if b.UserID != tt.forUser.ID {
+					t.Fatalf("booking belongs to user %d, want %d", b.UserID, tt.forUser.ID)
+				}
+				if b.Status != "pending" {
+					t.Fatalf("new booking is %q", b.Status)
+				}
+				want := wisata.HargaTiket * float64(b.Quantity)
+				if created.FinalPrice != want || b.FinalPrice != want {
+					t.Fatalf("final price %v (stored %v), want %v", created.FinalPrice, b.FinalPrice, want)
+				}
+			},
+		)
+	}
+}
+
+func TestBookingTransitions(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	wisata := e.newWisata()
+	
+	// The deprecated paths send the booking code in the body.
+	tests := []struct {
+		name   string
+		from   string
+		action string
+		legacy bool
+		status int
+		code   string
+		to     string
+	}{
+		{"pay pending", "pending", "pay", false, http.StatusOK, "", "paid"},
+		{"pay paid", "paid", "pay", false, http.StatusConflict, "booking_not_pending", "paid"},
+		{"pay completed", "completed", "pay", false, http.StatusConflict, "booking_not_pending", "completed"},
+		{"pay cancelled", "cancelled", "pay", false, http.StatusConflict, "booking_not_pending", "cancelled"},
+		{"cancel pending", "pending", "cancel", false, http.StatusOK, "", "cancelled"},
+		{"cancel paid", "paid", "cancel", false, http.StatusConflict, "booking_not_pending", "paid"},
+		{"cancel completed", "completed", "cancel", false, http.StatusConflict, "booking_not_pending", "completed"},
+		{"cancel cancelled", "cancelled", "cancel", false, http.StatusConflict, "booking_not_pending", "cancelled"},
+		{"legacy pay", "pending", "pay", true, http.StatusOK, "", "paid"},
+		{"legacy cancel", "pending", "cancel", true, http.StatusOK, "", "cancelled"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				b := e.newBooking(user, wisata, tt.from)
+				
+				handler := e.ProcessPayment
+				if tt.action == "cancel" {
+					handler = e.CancelBooking
+				}
+				
+				r := withPath(newRequest(http.MethodPost, "/api/v1/bookings/"+b.BookingCode+"/"+tt.action, nil), "code", b.BookingCode)
+				if tt.legacy {
+					r = newRequest(http.MethodPost, "/api/booking/"+tt.action, map[string]string{"booking_code": b.BookingCode})
+				}
+				rec, res := serve(t, handler, asUser(r, user))
+				expectStatus(t, rec, res, tt.status, tt.code)
+				
+				if got := e.getBooking(b.BookingCode).Status; got != tt.to {
+					t.Fatalf("status %q, want %q", got, tt.to)
+				}
+			},
+		)
+	}
+}
+
+// A payment and a cancellation of the same booking racing each other must
+// not both succeed.
+func TestBookingTransitionsRace(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	wisata := e.newWisata()
+	
+	for range 20 {
+		b := e.newBooking(user, wisata, "pending")
+		
+		var wg sync.WaitGroup
+		codes := make([]int, 2)
+		for i, action := range []string{"pay", "cancel"} {
+			handler := e.ProcessPayment
+			if action == "cancel" {
+				handler = e.CancelBooking
+			}
+			r := withPath(newRequest(http.MethodPost, "/api/v1/bookings/"+b.BookingCode+"/"+action, nil), "code", b.BookingCode)
+			wg.Go(func() {
+				rec := httptest.NewRecorder()
+				handler(rec, asUser(r, user))
+				codes[i] = rec.Code
+			})
+		}
+		wg.Wait()
+		
+		slices.Sort(codes)
+		if codes[0] != http.StatusOK || codes[1] != http.StatusConflict {
+			t.Fatalf("booking %s answered %v, want one 200 and one 409", b.BookingCode, codes)
+		}
+	}
+}
+
+func TestBookingOwnership(t *testing.T) {
+	e := newTestEnv(t)
+	owner := e.newUser(models.RoleUser)
+	stranger := e.newUser(models.RoleUser)
+	partner := e.newUser(models.RolePartner)
+	admin := e.newUser(models.RoleAdmin)
+	wisata := e.newWisata()
+	
+	actions := []struct {
+		name    string
+		handler http.HandlerFunc
+		// changes is the status the booking ends in when the action
+		// goes through.
+		changes string
+	}{
+		{"detail", e.GetBookingDetail, "pending"},
+		{"pay", e.ProcessPayment, "paid"},
+		{"cancel", e.CancelBooking, "cancelled"},
+	}
+	
+	callers := []struct {
+		name    string
+		as      *models.User
+		allowed bool
+	}{
+		{"owner", owner, true},
+		{"other user", stranger, false},
+		{"partner", partner, false},
+		{"admin", admin, true},
+	}
+	
+	for _, action := range actions {
+		for _, caller := range callers {
+			t.Run(
+				action.name+" by "+caller.name, func(t *testing.T) {
+					b := e.newBooking(owner, wisata, "pending")
+					r := withPath(newRequest(http.MethodPost, "/api/v1/bookings/"+b.BookingCode, nil), "code", b.BookingCode)
+					rec, res := serve(t, action.handler, asUser(r, caller.as))
+					
+					want, status := "pending", http.StatusNotFound
+					if caller.allowed {
+						want, status = action.changes, http.StatusOK
+					}
+					expectStatus(t, rec, res, status, "")
+					if got := e.getBooking(b.BookingCode).Status; got != want {
+						t.Fatalf("status %q, want %q", got, want)
+					}
+				},
+			)
+		}
+	}
+	
+	t.Run(
+		"unknown code", func(t *testing.T) {
+			r := withPath(newRequest(http.MethodGet, "/api/v1/bookings/BK000000-XXXXXX", nil), "code", "BK000000-XXXXXX")
+			rec, res := serve(t, e.GetBookingDetail, asUser(r, admin))
+			expectStatus(t, rec, res, http.StatusNotFound, "")
+		},
+	)
+}
+
+func TestListBookings(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	other := e.newUser(models.RoleUser)
+	admin := e.newUser(models.RoleAdmin)
+	wisata := e.newWisata()
+	
+	first := e.newBooking(user, wisata, "pending")
+	second := e.newBooking(user, wisata, "paid")
+	third := e.newBooking(other, wisata, "pending")
+	
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		as       *models.User
+		target   string
+		want     []*models.Booking
+		userName bool
+	}{
+		{"own history", e.GetBookingHistory, user, "/api/v1/bookings", []*models.Booking{second, first}, false},
+		{"user_id ignored for users", e.GetBookingHistory, user, "/api/v1/bookings?user_id=" + strconv.Itoa(other.ID), []*models.Booking{second, first}, false},
+		{"admin for a user", e.GetBookingHistory, admin, "/api/v1/bookings?user_id=" + strconv.Itoa(other.ID), []*models.Booking{third}, false},
+		{"admin without bookings", e.GetBookingHistory, admin, "/api/v1/bookings", nil, false},
+		{"all bookings", e.GetAllBookings, admin, "/api/v1/admin/bookings", []*models.Booking{third, second, first}, true},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec, res := serve(t, tt.handler, asUser(newRequest(http.MethodGet, tt.target, nil), tt.as))
+				expectStatus(t, rec, res, http.StatusOK, "")
+				
+				var list []models.Booking
+				if res.Data != nil {
+					decodeData(t, res, &list)
+				}
+				if len(list) != len(tt.want) {
+					t.Fatalf("%d bookings, want %d", len(list), len(tt.want))
+				}
+				// Newest first, named after the wisata and, for admins, the user.
+				for i, b := range list {
+					want := tt.want[i]
+					if b.BookingCode != want.BookingCode || b.WisataNama != wisata.NamaTempat {
+						t.Fatalf("booking %d is %s at %q, want %s at %q", i, b.BookingCode, b.WisataNama, want.BookingCode, wisata.NamaTempat)
+					}
+					if owner := e.getUser(want.UserID); tt.userName && b.UserName != owner.FullName {
+						t.Fatalf("user name %q, want %q", b.UserName, owner.FullName)
+					}
+				}
+			},
+		)
+	}
+}
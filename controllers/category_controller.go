@@ -2,55 +2,39 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	
-	"backend-wisata/config"
 	"backend-wisata/models"
+	"backend-wisata/store"
 )
 
-func GetAllCategories(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAllCategories(w http.ResponseWriter, r *http.Request) {
 	
-	query := `
-		SELECT
-			c.id, c.name, c.slug, c.icon, c.is_active,
-			(SELECT COUNT(*) FROM wisata w WHERE w.category_id = c.id AND w.deleted_at IS NULL) as count
-		FROM categories c
-		ORDER BY c.sort_order ASC, c.id ASC
-	`
-	
-	rows, err := config.DB.Query(query)
+	list, err := h.Wisata.Categories(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		requestLogger(r).Error("fetch categories failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil kategori")
 		return
 	}
-	defer rows.Close()
 	
 	var categories []map[string]interface{}
 	
-	for rows.Next() {
-		var id, count int
-		var name, slug string
-		var icon *string
-		var isActive bool
-		
-		if err := rows.Scan(&id, &name, &slug, &icon, &isActive, &count); err != nil {
-			continue
-		}
-		
+	for _, c := range list {
 		iconVal := "grid"
-		if icon != nil {
-			iconVal = *icon
+		if c.Icon != nil {
+			iconVal = *c.Icon
 		}
 		
 		categories = append(
 			categories, map[string]interface{}{
-				"id":        id,
-				"name":      name,
-				"slug":      slug,
+				"id":        c.ID,
+				"name":      c.Name,
+				"slug":      c.Slug,
 				"icon":      iconVal,
-				"count":     count,
-				"is_active": isActive,
+				"count":     c.WisataCount,
+				"is_active": c.IsActive,
 			},
 		)
 	}
@@ -65,90 +49,82 @@ func GetAllCategories(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func CreateCategory(w http.ResponseWriter, r *http.Request) {
-	
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
+func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Name     string `json:"name"`
-		Slug     string `json:"slug"`
+		Name     string `json:"name" validate:"required,max=100"`
+		Slug     string `json:"slug" validate:"required,slug,max=100"`
 		IsActive bool   `json:"is_active"`
 	}
 	
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, "Invalid body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &input) {
 		return
 	}
 	
 	icon := "grid"
 	
-	query := "INSERT INTO categories (name, slug, icon, is_active) VALUES ($1, $2, $3, $4)"
-	_, err := config.DB.Exec(query, input.Name, input.Slug, icon, input.IsActive)
-	
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	err := h.Wisata.CreateCategory(
+		r.Context(), &models.Category{Name: input.Name, Slug: input.Slug, Icon: &icon, IsActive: input.IsActive},
+	)
+	if errors.Is(err, store.ErrDuplicate) {
+		responseSlugTaken(w)
+		return
+	} else if err != nil {
+		requestLogger(r).Error("create category failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menyimpan kategori")
 		return
 	}
 	
 	json.NewEncoder(w).Encode(models.Response{Status: 201, Message: "Category Created"})
 }
 
-func UpdateCategory(w http.ResponseWriter, r *http.Request) {
-	
-	if r.Method != "PUT" && r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
-	idStr := r.URL.Query().Get("id")
+func (h *Handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	idStr := pathParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	
 	var input struct {
-		Name     string `json:"name"`
-		Slug     string `json:"slug"`
+		Name     string `json:"name" validate:"required,max=100"`
+		Slug     string `json:"slug" validate:"required,slug,max=100"`
 		IsActive bool   `json:"is_active"`
 	}
 	
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, "Invalid body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &input) {
 		return
 	}
 	
-	query := "UPDATE categories SET name=$1, slug=$2, is_active=$3, updated_at=NOW() WHERE id=$4"
-	_, err := config.DB.Exec(query, input.Name, input.Slug, input.IsActive, id)
-	
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	err := h.Wisata.UpdateCategory(
+		r.Context(), &models.Category{ID: id, Name: input.Name, Slug: input.Slug, IsActive: input.IsActive},
+	)
+	if errors.Is(err, store.ErrDuplicate) {
+		responseSlugTaken(w)
+		return
+	} else if err != nil {
+		requestLogger(r).Error("update category failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengupdate kategori")
 		return
 	}
 	
 	json.NewEncoder(w).Encode(models.Response{Status: 200, Message: "Category Updated"})
 }
 
-func DeleteCategory(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	idStr := pathParam(r, "id")
+	id, _ := strconv.Atoi(idStr)
 	
-	if r.Method != "DELETE" && r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	count, err := h.Wisata.CountInCategory(r.Context(), id)
+	if err != nil {
+		requestLogger(r).Error("count category usage failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menghapus kategori")
 		return
 	}
 	
-	idStr := r.URL.Query().Get("id")
-	id, _ := strconv.Atoi(idStr)
-	
-	var count int
-	config.DB.QueryRow("SELECT COUNT(*) FROM wisata WHERE category_id = $1 AND deleted_at IS NULL", id).Scan(&count)
-	
 	if count > 0 {
-		http.Error(w, "Kategori sedang digunakan oleh wisata aktif", http.StatusBadRequest)
+		responseErrorCode(w, http.StatusBadRequest, "category_in_use", "Kategori sedang digunakan oleh wisata aktif")
 		return
 	}
 	
-	_, err := config.DB.Exec("DELETE FROM categories WHERE id = $1", id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.Wisata.DeleteCategory(r.Context(), id); err != nil {
+		requestLogger(r).Error("delete category failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menghapus kategori")
 		return
 	}
 	
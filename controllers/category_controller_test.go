@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	
+	"backend-wisata/models"
+)
+
+type categoryItem struct {
+	ID    int    `json:"id"`
+	Slug  string `json:"slug"`
+	Name  string `json:"name"`
+	Icon  string `json:"icon"`
+	Count int    `json:"count"`
+}
+
+func (e *testEnv) listCategories() []categoryItem {
+	e.t.Helper()
+	rec, res := serve(e.t, e.GetAllCategories, newRequest(http.MethodGet, "/api/v1/categories", nil))
+	expectStatus(e.t, rec, res, http.StatusOK, "")
+	
+	var list []categoryItem
+	if res.Data != nil {
+		decodeData(e.t, res, &list)
+	}
+	return list
+}
+
+func TestCreateCategory(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	existing := e.newCategory()
+	
+	tests := []struct {
+		name   string
+		input  map[string]any
+		status int
+		field  string
+	}{
+		{"valid", map[string]any{"name": "Pantai", "slug": "pantai", "is_active": true}, http.StatusOK, ""},
+		{"slug taken", map[string]any{"name": "Lain", "slug": existing.Slug}, http.StatusConflict, "slug"},
+		{"invalid slug", map[string]any{"name": "Gunung", "slug": "Gunung Api"}, http.StatusUnprocessableEntity, "slug"},
+		{"missing name", map[string]any{"slug": "gunung"}, http.StatusUnprocessableEntity, "name"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec, res := serve(t, e.CreateCategory, asUser(newRequest(http.MethodPost, "/api/v1/categories", tt.input), admin))
+				expectStatus(t, rec, res, tt.status, "")
+				if tt.field != "" && len(res.Details[tt.field]) == 0 {
+					t.Fatalf("no error for field %s: %v", tt.field, res.Details)
+				}
+			},
+		)
+	}
+	
+	var found bool
+	for _, c := range e.listCategories() {
+		if c.Slug == "pantai" {
+			found = true
+			if c.Icon != "grid" || c.Count != 0 {
+				t.Fatalf("new category %+v", c)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("created category not listed")
+	}
+}
+
+func TestUpdateCategory(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	category := e.newCategory()
+	other := e.newCategory()
+	id := strconv.Itoa(category.ID)
+	
+	tests := []struct {
+		name   string
+		input  map[string]any
+		status int
+	}{
+		{"valid", map[string]any{"name": "Air Terjun", "slug": "air-terjun", "is_active": true}, http.StatusOK},
+		{"slug taken", map[string]any{"name": "Air Terjun", "slug": other.Slug}, http.StatusConflict},
+		{"missing slug", map[string]any{"name": "Air Terjun"}, http.StatusUnprocessableEntity},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := withPath(newRequest(http.MethodPut, "/api/v1/categories/"+id, tt.input), "id", id)
+				rec, res := serve(t, e.UpdateCategory, asUser(r, admin))
+				expectStatus(t, rec, res, tt.status, "")
+			},
+		)
+	}
+	
+	for _, c := range e.listCategories() {
+		if c.ID == category.ID && (c.Name != "Air Terjun" || c.Slug != "air-terjun") {
+			t.Fatalf("stored %+v", c)
+		}
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	
+	used := e.newCategory()
+	e.newWisataIn(used.ID)
+	
+	emptied := e.newCategory()
+	gone := e.newWisataIn(emptied.ID)
+	if err := e.Wisata.Delete(t.Context(), gone.ID); err != nil {
+		t.Fatal(err)
+	}
+	
+	unused := e.newCategory()
+	
+	tests := []struct {
+		name     string
+		category *models.Category
+		status   int
+		code     string
+	}{
+		{"in use", used, http.StatusBadRequest, "category_in_use"},
+		{"only deleted wisata", emptied, http.StatusOK, ""},
+		{"unused", unused, http.StatusOK, ""},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				id := strconv.Itoa(tt.category.ID)
+				rec, res := serve(t, e.DeleteCategory, asUser(withPath(newRequest(http.MethodDelete, "/", nil), "id", id), admin))
+				expectStatus(t, rec, res, tt.status, tt.code)
+				
+				var listed bool
+				for _, c := range e.listCategories() {
+					listed = listed || c.ID == tt.category.ID
+				}
+				if listed != (tt.status != http.StatusOK) {
+					t.Fatalf("category listed: %v", listed)
+				}
+			},
+		)
+	}
+}
+
+func TestGetAllCategoriesCount(t *testing.T) {
+	e := newTestEnv(t)
+	category := e.newCategory()
+	e.newWisataIn(category.ID)
+	e.newWisataIn(category.ID)
+	
+	list := e.listCategories()
+	if len(list) != 1 || list[0].Count != 2 {
+		t.Fatalf("categories %+v", list)
+	}
+}
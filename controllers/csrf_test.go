@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"backend-wisata/models"
+)
+
+func TestCheckCSRF(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	cookies, token := e.login(user)
+	
+	request := func(method, csrf string, cookies []*http.Cookie) *http.Request {
+		r := withCookies(httptest.NewRequest(method, "/api/v1/profile", nil), cookies)
+		if csrf != "" {
+			r.Header.Set(CSRFHeader, csrf)
+		}
+		return r
+	}
+	
+	tests := []struct {
+		name string
+		r    *http.Request
+		want bool
+	}{
+		{"safe method", request(http.MethodGet, "", cookies), true},
+		{"matching token", request(http.MethodPut, token, cookies), true},
+		{"missing token", request(http.MethodPut, "", cookies), false},
+		{"wrong token", request(http.MethodPut, "salah", cookies), false},
+		{"no session", request(http.MethodPut, "", nil), true},
+		{"bearer token", withBearer(request(http.MethodPut, "", cookies), "token"), true},
+		{"api key", WithAPIKey(request(http.MethodPut, "", cookies), &models.APIKey{ID: 1}), true},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if got := e.CheckCSRF(tt.r); got != tt.want {
+					t.Fatalf("CheckCSRF = %v, want %v", got, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestCSRFTokenChangesPerLogin(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	
+	_, first := e.login(user)
+	cookies, second := e.login(user)
+	if first == second {
+		t.Fatal("the CSRF token survived a new login")
+	}
+	
+	r := withCookies(httptest.NewRequest(http.MethodPost, "/", nil), cookies)
+	r.Header.Set(CSRFHeader, first)
+	if e.CheckCSRF(r) {
+		t.Fatal("the token of the previous login is still accepted")
+	}
+}
@@ -2,36 +2,16 @@ package controllers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
-	"time"
 	
 	"backend-wisata/config"
 	"backend-wisata/models"
 )
 
-func GetDashboardStats(w http.ResponseWriter, r *http.Request) {
-	query := `
-		SELECT
-			total_wisata, active_wisata, total_users, total_visitors,
-			total_revenue, total_bookings, average_rating
-		FROM vw_dashboard_stats
-	`
-	
-	var stats models.DashboardStats
-	
-	err := config.DB.QueryRow(query).Scan(
-		&stats.TotalWisata,
-		&stats.ActiveWisata,
-		&stats.TotalUsers,
-		&stats.TotalVisitors,
-		&stats.TotalRevenue,
-		&stats.TotalBookings,
-		&stats.AverageRating,
-	)
-	
+func (h *Handler) GetDashboardStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.Bookings.DashboardStats(r.Context())
 	if err != nil {
-		log.Println("ERROR DASHBOARD STATS:", err)
+		requestLogger(r).Error("fetch dashboard stats failed", "err", err)
 		responseError(w, http.StatusInternalServerError, "Gagal mengambil data statistik")
 		return
 	}
@@ -46,48 +26,13 @@ func GetDashboardStats(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func GetRecentBookings(w http.ResponseWriter, r *http.Request) {
-	query := `
-		SELECT
-			b.id, b.booking_code, b.wisata_id, w.nama_tempat,
-			b.user_id, b.visit_date, b.quantity,
-			b.total_price, b.final_price, b.status, b.payment_method, b.created_at
-		FROM bookings b
-		JOIN wisata w ON b.wisata_id = w.id
-		ORDER BY b.created_at DESC
-		LIMIT 5
-	`
-	
-	rows, err := config.DB.Query(query)
+func (h *Handler) GetRecentBookings(w http.ResponseWriter, r *http.Request) {
+	bookings, err := h.Bookings.Recent(r.Context(), 5)
 	if err != nil {
-		log.Println("ERROR RECENT BOOKINGS:", err)
-		responseError(w, http.StatusInternalServerError, err.Error())
+		requestLogger(r).Error("fetch recent bookings failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil booking terbaru")
 		return
 	}
-	defer rows.Close()
-	
-	var bookings []models.Booking
-	
-	for rows.Next() {
-		var b models.Booking
-		var visitDateRaw time.Time
-		var createdAtRaw time.Time
-		
-		err := rows.Scan(
-			&b.ID, &b.BookingCode, &b.WisataID, &b.WisataNama,
-			&b.UserID, &visitDateRaw, &b.Quantity,
-			&b.TotalPrice, &b.FinalPrice, &b.Status, &b.PaymentMethod, &createdAtRaw,
-		)
-		
-		if err != nil {
-			log.Println("SCAN ERROR:", err)
-			continue
-		}
-		
-		b.VisitDate = visitDateRaw.Format("2006-01-02")
-		b.CreatedAt = createdAtRaw
-		bookings = append(bookings, b)
-	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(
@@ -99,9 +44,8 @@ func GetRecentBookings(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func GetPopularWisata(w http.ResponseWriter, r *http.Request) {
-	var totalAllBookings int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM bookings").Scan(&totalAllBookings)
+func (h *Handler) GetPopularWisata(w http.ResponseWriter, r *http.Request) {
+	totalAllBookings, err := h.Bookings.Count(r.Context())
 	if err != nil {
 		totalAllBookings = 1
 	}
@@ -109,56 +53,20 @@ func GetPopularWisata(w http.ResponseWriter, r *http.Request) {
 		totalAllBookings = 1
 	}
 	
-	query := `
-		SELECT
-			w.id,
-			w.nama_tempat,
-			COALESCE(w.deskripsi, ''),
-			w.lokasi,
-			w.harga_tiket,
-			COALESCE((SELECT image_url FROM wisata_images WHERE wisata_id = w.id AND is_primary = true LIMIT 1), '') as image_url,
-			COUNT(DISTINCT b.id) as total_visits,
-			COALESCE(AVG(r.rating), 0) as rating_total,
-			COUNT(DISTINCT r.id) as total_reviews
-		FROM wisata w
-		LEFT JOIN bookings b ON w.id = b.wisata_id
-		LEFT JOIN reviews r ON w.id = r.wisata_id
-		WHERE w.deleted_at IS NULL
-		GROUP BY w.id, w.nama_tempat, w.deskripsi, w.lokasi, w.harga_tiket
-		ORDER BY total_visits DESC
-		LIMIT 5
-	`
-	
-	rows, err := config.DB.Query(query)
+	popularList, err := h.Wisata.Popular(r.Context(), 5)
 	if err != nil {
-		log.Println("ERROR POPULAR:", err)
-		responseError(w, http.StatusInternalServerError, err.Error())
+		requestLogger(r).Error("fetch popular wisata failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil wisata populer")
 		return
 	}
-	defer rows.Close()
 	
-	var popularList []models.PopularWisata
-	scheme := "http"
-	if r.TLS != nil {
-		scheme = "https"
-	}
-	baseURL := scheme + "://" + r.Host
+	baseURL := config.App.Server.PublicURL
 	
-	for rows.Next() {
-		var p models.PopularWisata
-		var imgURL string
-		
-		err := rows.Scan(
-			&p.ID, &p.NamaTempat, &p.Deskripsi, &p.Lokasi, &p.HargaTiket,
-			&imgURL, &p.TotalVisits, &p.RatingTotal, &p.TotalReviews,
-		)
-		if err != nil {
-			log.Println("SCAN ERROR POPULAR:", err)
-			continue
-		}
+	for i := range popularList {
+		p := &popularList[i]
 		
-		if imgURL != "" {
-			p.ImageURL = baseURL + imgURL
+		if p.ImageURL != "" {
+			p.ImageURL = baseURL + p.ImageURL
 		}
 		
 		p.Percentage = (float64(p.TotalVisits) / float64(totalAllBookings)) * 100
@@ -170,8 +78,6 @@ func GetPopularWisata(w http.ResponseWriter, r *http.Request) {
 		} else {
 			p.Label = "Rekomendasi"
 		}
-		
-		popularList = append(popularList, p)
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
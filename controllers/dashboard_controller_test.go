@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	
+	"backend-wisata/config"
+	"backend-wisata/models"
+)
+
+func TestGetDashboardStats(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	user := e.newUser(models.RoleUser)
+	e.newUser(models.RoleUser)
+	e.newUser(models.RolePartner)
+	wisata := e.newWisata()
+	deleted := e.newWisata()
+	
+	for _, status := range []string{"pending", "paid", "completed", "cancelled"} {
+		e.newBooking(user, wisata, status)
+	}
+	if err := e.Wisata.Delete(context.Background(), deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+	
+	rec, res := serve(t, e.GetDashboardStats, asUser(newRequest(http.MethodGet, "/api/v1/dashboard/stats", nil), admin))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	
+	var got models.DashboardStats
+	decodeData(t, res, &got)
+	
+	// Only paid and completed bookings count as visits, two visitors each.
+	want := models.DashboardStats{
+		TotalWisata:   1,
+		ActiveWisata:  1,
+		TotalUsers:    2,
+		TotalVisitors: 4,
+		TotalRevenue:  2 * wisata.HargaTiket * 2,
+		TotalBookings: 4,
+	}
+	if got != want {
+		t.Fatalf("stats %+v, want %+v", got, want)
+	}
+}
+
+func TestGetRecentBookings(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	user := e.newUser(models.RoleUser)
+	wisata := e.newWisata()
+	
+	var codes []string
+	for range 6 {
+		codes = append(codes, e.newBooking(user, wisata, "pending").BookingCode)
+	}
+	
+	rec, res := serve(t, e.GetRecentBookings, asUser(newRequest(http.MethodGet, "/api/v1/dashboard/recent-bookings", nil), admin))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	
+	var list []models.Booking
+	decodeData(t, res, &list)
+	if len(list) != 5 {
+		t.Fatalf("%d bookings, want 5", len(list))
+	}
+	for i, b := range list {
+		if want := codes[len(codes)-1-i]; b.BookingCode != want {
+			t.Fatalf("booking %d is %s, want %s", i, b.BookingCode, want)
+		}
+	}
+}
+
+func TestGetPopularWisata(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	user := e.newUser(models.RoleUser)
+	category := e.newCategory()
+	
+	tests := []struct {
+		bookings int
+		label    string
+	}{
+		{21, "Trending"},
+		{11, "Populer"},
+		{10, "Rekomendasi"},
+		{0, "Rekomendasi"},
+	}
+	
+	total := 0
+	for _, tt := range tests {
+		wisata := e.newWisataIn(category.ID)
+		for range tt.bookings {
+			e.newBooking(user, wisata, "pending")
+		}
+		total += tt.bookings
+	}
+	first := e.newWisataIn(category.ID)
+	if err := e.Wisata.SetImage(context.Background(), first.ID, "/uploads/kawah.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	for range 22 {
+		e.newBooking(user, first, "pending")
+	}
+	total += 22
+	
+	rec, res := serve(t, e.GetPopularWisata, asUser(newRequest(http.MethodGet, "/api/v1/dashboard/popular-wisata", nil), admin))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	
+	var list []models.PopularWisata
+	decodeData(t, res, &list)
+	if len(list) != 5 {
+		t.Fatalf("%d wisata, want 5", len(list))
+	}
+	if list[0].ID != first.ID || list[0].ImageURL != config.App.Server.PublicURL+"/uploads/kawah.jpg" {
+		t.Fatalf("most popular is %d with image %q, want %d", list[0].ID, list[0].ImageURL, first.ID)
+	}
+	
+	for i, tt := range tests {
+		got := list[i+1]
+		if got.TotalVisits != tt.bookings || got.Label != tt.label {
+			t.Fatalf("wisata %d: %d visits labelled %q, want %d %q", i+1, got.TotalVisits, got.Label, tt.bookings, tt.label)
+		}
+		if want := float64(tt.bookings) / float64(total) * 100; got.Percentage != want {
+			t.Fatalf("wisata %d: percentage %v, want %v", i+1, got.Percentage, want)
+		}
+	}
+}
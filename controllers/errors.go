@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	
+	"backend-wisata/logger"
+	"backend-wisata/models"
+)
+
+// WriteError writes the error envelope shared by every handler and
+// middleware: the HTTP status, a machine-readable code the frontend can
+// branch on, a message for the user and the request ID. The request ID is
+// taken from the response header set by the logging middleware so support
+// can find the matching log lines. An empty code is derived from the status.
+//
+// Messages must never contain internal error text; log the error with
+// requestLogger and send a fixed message instead.
+func WriteError(w http.ResponseWriter, status int, code, message string) {
+	writeError(w, models.Response{Status: status, Code: code, Message: message})
+}
+
+func writeError(w http.ResponseWriter, res models.Response) {
+	if res.Code == "" {
+		res.Code = StatusCode(res.Status)
+	}
+	res.RequestID = w.Header().Get(logger.RequestIDHeader)
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.Status)
+	json.NewEncoder(w).Encode(res)
+}
+
+// StatusCode is the default error code for an HTTP status, e.g. not_found.
+func StatusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
+
+func responseError(w http.ResponseWriter, status int, message string) {
+	WriteError(w, status, "", message)
+}
+
+func responseErrorCode(w http.ResponseWriter, status int, code string, message string) {
+	WriteError(w, status, code, message)
+}
+
+// responseFieldErrors rejects a request because of the listed input fields.
+func responseFieldErrors(w http.ResponseWriter, status int, message string, fields map[string][]string) {
+	writeError(w, models.Response{Status: status, Message: message, Details: fields})
+}
+
+// responseSlugTaken answers a unique violation on the slug column.
+func responseSlugTaken(w http.ResponseWriter) {
+	responseFieldErrors(w, http.StatusConflict, "Slug sudah dipakai", map[string][]string{"slug": {"Slug sudah dipakai"}})
+}
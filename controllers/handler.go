@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"database/sql"
+	
+	"backend-wisata/ratelimit"
+	"backend-wisata/store"
+	
+	"github.com/gorilla/sessions"
+)
+
+// Handler serves every endpoint of the API. The handlers are its methods
+// and reach the data only through the embedded stores, so tests can run
+// them against store.NewMemory.
+type Handler struct {
+	store.Stores
+	
+	// AdminCookies and UserCookies keep the cookie sessions of the admin
+	// panel and the public site.
+	AdminCookies sessions.Store
+	UserCookies  sessions.Store
+	
+	// RateLimits holds the buckets for the per-key quota of API keys.
+	RateLimits ratelimit.Store
+	
+	// DB is only pinged by Readyz. A nil DB reports the database as down.
+	DB *sql.DB
+}
+
+// NewHandler returns a Handler on the given stores with an in-memory rate
+// limiter and no database for Readyz.
+func NewHandler(s store.Stores, adminCookies, userCookies sessions.Store) *Handler {
+	return &Handler{
+		Stores:       s,
+		AdminCookies: adminCookies,
+		UserCookies:  userCookies,
+		RateLimits:   ratelimit.NewMemoryStore(),
+	}
+}
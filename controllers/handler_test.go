@@ -0,0 +1,476 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	
+	"backend-wisata/config"
+	"backend-wisata/mailer"
+	"backend-wisata/migrations"
+	"backend-wisata/models"
+	"backend-wisata/store"
+	"backend-wisata/totp"
+	
+	"github.com/gorilla/sessions"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// The tests run against store.NewMemory by default. With TEST_DATABASE_URL
+// set they run against Postgres instead: every test gets its own schema,
+// migrated up and dropped again afterwards, so the database may be shared
+// but should not hold anything of value.
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+// testPassword is the password of every user made by newUser.
+const testPassword = "rahasia123"
+
+var (
+	testAdminSessionKey = []byte("test-admin-session-key-0123456789")
+	testUserSessionKey  = []byte("test-user-session-key-01234567890")
+)
+
+var testPasswordHash string
+
+// outbox collects the email handlers send instead of delivering it.
+var outbox = &testMailer{}
+
+type testMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (m *testMailer) Send(msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// reset waits for the background sends and forgets the email sent so far.
+func (m *testMailer) reset(t *testing.T) {
+	m.messages(t, "")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = nil
+}
+
+// messages waits for the background sends and returns the email sent to
+// addr.
+func (m *testMailer) messages(t *testing.T, addr string) []mailer.Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitBackground(ctx); err != nil {
+		t.Fatal("background work did not finish:", err)
+	}
+	
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []mailer.Message
+	for _, msg := range m.sent {
+		if msg.To == addr {
+			list = append(list, msg)
+		}
+	}
+	return list
+}
+
+// linkToken returns the token query parameter of the link in msg.
+func linkToken(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	for _, field := range strings.Fields(msg.Body) {
+		link, err := url.Parse(field)
+		if err == nil && link.Query().Has("token") {
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatalf("no token link in %q", msg.Body)
+	return ""
+}
+
+func TestMain(m *testing.M) {
+	uploads, err := os.MkdirTemp("", "wisata-uploads-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	
+	config.App = &config.Config{}
+	config.App.Uploads.Dir = uploads
+	config.App.Uploads.URLPath = "/uploads/"
+	config.App.Server.PublicURL = "https://api.example.test"
+	config.AppURL = "https://app.example.test"
+	config.EmailVerifyKey = []byte("test-email-verify-key")
+	config.Mailer = outbox
+	
+	// The lowest cost keeps creating users fast; checkPasswordHash accepts
+	// any cost.
+	hash, _ := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	testPasswordHash = string(hash)
+	
+	code := m.Run()
+	os.RemoveAll(uploads)
+	os.Exit(code)
+}
+
+// testEnv is a Handler on empty stores with helpers to fill them.
+type testEnv struct {
+	*Handler
+	t   *testing.T
+	seq int
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	outbox.reset(t)
+	stores, db := newStores(t)
+	
+	// Postgres mode keeps cookie sessions in user_sessions like main does,
+	// so they can be listed and revoked.
+	var adminCookies, userCookies sessions.Store = sessions.NewCookieStore(testAdminSessionKey), sessions.NewCookieStore(testUserSessionKey)
+	if db != nil {
+		adminCookies, userCookies = config.NewPGStore(db, testAdminSessionKey), config.NewPGStore(db, testUserSessionKey)
+	}
+	
+	h := NewHandler(stores, adminCookies, userCookies)
+	h.DB = db
+	return &testEnv{Handler: h, t: t}
+}
+
+// newStores returns empty stores and, in Postgres mode, the database
+// behind them.
+func newStores(t *testing.T) (store.Stores, *sql.DB) {
+	t.Helper()
+	dsn := os.Getenv(testDatabaseEnv)
+	if dsn == "" {
+		return store.NewMemory(), nil
+	}
+	
+	ctx := context.Background()
+	schema := "test_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	
+	adminConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("parse %s: %v", testDatabaseEnv, err)
+	}
+	admin := stdlib.OpenDB(*adminConfig)
+	if _, err := admin.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		admin.Close()
+		t.Fatal("create test schema:", err)
+	}
+	t.Cleanup(
+		func() {
+			if _, err := admin.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+				t.Error("drop test schema:", err)
+			}
+			admin.Close()
+		},
+	)
+	
+	testConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("parse %s: %v", testDatabaseEnv, err)
+	}
+	testConfig.RuntimeParams["search_path"] = schema
+	db := stdlib.OpenDB(*testConfig)
+	t.Cleanup(func() { db.Close() })
+	
+	if _, err := migrations.Up(ctx, db); err != nil {
+		t.Fatal("migrate test schema:", err)
+	}
+	return store.NewPostgres(db), db
+}
+
+// next returns a number that is unique within the test, for usernames and
+// slugs.
+func (e *testEnv) next() string {
+	e.seq++
+	return strconv.Itoa(e.seq)
+}
+
+// newUser creates an active user with a verified email and testPassword.
+func (e *testEnv) newUser(role string) *models.User {
+	e.t.Helper()
+	ctx := context.Background()
+	n := e.next()
+	
+	u := &models.User{
+		Username: role + n,
+		Email:    role + n + "@example.test",
+		Password: testPasswordHash,
+		FullName: "Test " + role + " " + n,
+		Role:     role,
+		IsActive: true,
+	}
+	if err := e.Users.Create(ctx, u); err != nil {
+		e.t.Fatal("create user:", err)
+	}
+	if err := e.Users.VerifyEmail(ctx, u.ID, u.Email); err != nil {
+		e.t.Fatal("verify user email:", err)
+	}
+	return e.getUser(u.ID)
+}
+
+func (e *testEnv) getUser(id int) *models.User {
+	e.t.Helper()
+	u, err := e.Users.Get(context.Background(), id)
+	if err != nil {
+		e.t.Fatal("get user:", err)
+	}
+	return u
+}
+
+func (e *testEnv) newCategory() *models.Category {
+	e.t.Helper()
+	n := e.next()
+	c := &models.Category{Name: "Kategori " + n, Slug: "kategori-" + n, IsActive: true}
+	if err := e.Wisata.CreateCategory(context.Background(), c); err != nil {
+		e.t.Fatal("create category:", err)
+	}
+	return c
+}
+
+// newWisata creates a wisata priced at 25000 in a new category.
+func (e *testEnv) newWisata() *models.Wisata {
+	e.t.Helper()
+	return e.newWisataIn(e.newCategory().ID)
+}
+
+func (e *testEnv) newWisataIn(categoryID int) *models.Wisata {
+	e.t.Helper()
+	ctx := context.Background()
+	n := e.next()
+	
+	w := &models.Wisata{
+		CategoryID: categoryID,
+		NamaTempat: "Wisata " + n,
+		Slug:       "wisata-" + n,
+		Lokasi:     "Bandung",
+		HargaTiket: 25000,
+	}
+	if err := e.Wisata.Create(ctx, w); err != nil {
+		e.t.Fatal("create wisata:", err)
+	}
+	got, err := e.Wisata.Get(ctx, w.ID)
+	if err != nil {
+		e.t.Fatal("get wisata:", err)
+	}
+	return got
+}
+
+// newBooking creates a booking for two visitors tomorrow and moves it to
+// status.
+func (e *testEnv) newBooking(user *models.User, wisata *models.Wisata, status string) *models.Booking {
+	e.t.Helper()
+	ctx := context.Background()
+	
+	b := &models.Booking{
+		WisataID:   wisata.ID,
+		UserID:     user.ID,
+		VisitDate:  tomorrow(),
+		Quantity:   2,
+		TotalPrice: wisata.HargaTiket * 2,
+		FinalPrice: wisata.HargaTiket * 2,
+	}
+	if err := e.Bookings.Create(ctx, b, nil); err != nil {
+		e.t.Fatal("create booking:", err)
+	}
+	if status != "pending" {
+		if err := e.Bookings.UpdateStatus(ctx, b.ID, status); err != nil {
+			e.t.Fatal("update booking status:", err)
+		}
+	}
+	return e.getBooking(b.BookingCode)
+}
+
+func (e *testEnv) getBooking(code string) *models.Booking {
+	e.t.Helper()
+	b, err := e.Bookings.GetByCode(context.Background(), code)
+	if err != nil {
+		e.t.Fatal("get booking:", err)
+	}
+	return b
+}
+
+// enableTwoFactor turns two-factor on for the user and returns the TOTP
+// secret and the recovery codes. Codes for the current and the next step
+// are still unused.
+func (e *testEnv) enableTwoFactor(user *models.User) (string, []string) {
+	e.t.Helper()
+	ctx := context.Background()
+	
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	if err := e.Users.SetTOTPSecret(ctx, user.ID, secret); err != nil {
+		e.t.Fatal("set totp secret:", err)
+	}
+	
+	codes, hashes := newRecoveryCodes()
+	if err := e.Users.EnableTwoFactor(ctx, user.ID, totp.Step(time.Now())-2, hashes); err != nil {
+		e.t.Fatal("enable 2fa:", err)
+	}
+	return secret, codes
+}
+
+// totpCode returns the code for the current time step plus offset.
+func totpCode(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	code, err := totp.CodeAt(secret, totp.Step(time.Now())+offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// login logs the user in through Login and returns the session cookies and
+// the CSRF token.
+func (e *testEnv) login(user *models.User) ([]*http.Cookie, string) {
+	e.t.Helper()
+	rec, res := serve(
+		e.t, e.Login, newRequest(
+			http.MethodPost, "/api/v1/login", map[string]string{"username": user.Username, "password": testPassword},
+		),
+	)
+	if rec.Code != http.StatusOK || res.Code != "" {
+		e.t.Fatalf("login: status %d, code %q", rec.Code, res.Code)
+	}
+	return rec.Result().Cookies(), rec.Header().Get(CSRFHeader)
+}
+
+func tomorrow() string {
+	return time.Now().AddDate(0, 0, 1).Format(time.DateOnly)
+}
+
+// newRequest builds a request with body, if not nil, encoded as JSON.
+func newRequest(method, target string, body any) *http.Request {
+	var r io.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		r = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, target, r)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+// newFormRequest builds a multipart request from fields. A "file:<name>"
+// key adds an upload of that field with the value as content.
+func newFormRequest(method, target string, fields map[string]string) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		if name, ok := strings.CutPrefix(k, "file:"); ok {
+			fw, _ := mw.CreateFormFile(name, name+".jpg")
+			fw.Write([]byte(v))
+			continue
+		}
+		mw.WriteField(k, v)
+	}
+	mw.Close()
+	
+	req := httptest.NewRequest(method, target, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// asUser marks the request as authenticated by user, like the role
+// middleware does.
+func asUser(r *http.Request, user *models.User) *http.Request {
+	return r.WithContext(WithUser(r.Context(), user))
+}
+
+func withCookies(r *http.Request, cookies []*http.Cookie) *http.Request {
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+func withBearer(r *http.Request, token string) *http.Request {
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func withPath(r *http.Request, name, value string) *http.Request {
+	r.SetPathValue(name, value)
+	return r
+}
+
+// authenticated serves next as the caller of the session cookie or bearer
+// token, and answers 401 without a valid one, like the role middleware.
+func (e *testEnv) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := e.AuthenticatedUser(r)
+		if errors.Is(err, ErrUnauthenticated) {
+			responseError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		} else if err != nil {
+			responseError(w, http.StatusInternalServerError, "Gagal memverifikasi sesi")
+			return
+		}
+		next(w, asUser(r, user))
+	}
+}
+
+// testResponse is models.Response with Data left undecoded.
+type testResponse struct {
+	Status  int                 `json:"status"`
+	Code    string              `json:"code"`
+	Message string              `json:"message"`
+	Data    json.RawMessage     `json:"data"`
+	Details map[string][]string `json:"details"`
+}
+
+// serve runs the handler and decodes the response envelope.
+func serve(t *testing.T, handler http.HandlerFunc, r *http.Request) (*httptest.ResponseRecorder, testResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler(rec, r)
+	
+	var res testResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+	return rec, res
+}
+
+// decodeData unmarshals the data field of the response into dst.
+func decodeData(t *testing.T, res testResponse, dst any) {
+	t.Helper()
+	if err := json.Unmarshal(res.Data, dst); err != nil {
+		t.Fatalf("decode data %s: %v", res.Data, err)
+	}
+}
+
+// expectStatus fails the test unless the response has the status and, when
+// not empty, the error code.
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, res testResponse, status int, code string) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d (%s)", rec.Code, status, rec.Body.String())
+	}
+	if code != "" && res.Code != code {
+		t.Fatalf("code = %q, want %q (%s)", res.Code, code, rec.Body.String())
+	}
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+	
+	"backend-wisata/config"
+	"backend-wisata/migrations"
+	"backend-wisata/models"
+)
+
+// readinessTimeout bounds each readiness check so a hanging database does
+// not hang the probe.
+const readinessTimeout = 2 * time.Second
+
+// errNoDatabase fails the database checks of a Handler without a DB.
+var errNoDatabase = errors.New("no database configured")
+
+// shuttingDown is set once the server received SIGINT/SIGTERM.
+var shuttingDown atomic.Bool
+
+// MarkShuttingDown makes /readyz fail so the load balancer stops sending new
+// requests while in-flight ones are drained.
+func MarkShuttingDown() {
+	shuttingDown.Store(true)
+}
+
+type healthCheck struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+	Version    *int    `json:"version,omitempty"`
+}
+
+// Healthz only reports that the process is serving requests.
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(models.Response{Status: 200, Message: "ok"})
+}
+
+// Readyz reports whether this instance can serve traffic: the database
+// answers, its schema is current and uploads can be stored.
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]healthCheck{
+		"database": runCheck(
+			r, "database unreachable", func(ctx context.Context, c *healthCheck) error {
+				if h.DB == nil {
+					return errNoDatabase
+				}
+				return h.DB.PingContext(ctx)
+			},
+		),
+		"migrations": runCheck(
+			r, "schema is behind or unreadable", func(ctx context.Context, c *healthCheck) error {
+				if h.DB == nil {
+					return errNoDatabase
+				}
+				version, err := migrations.Check(ctx, h.DB)
+				c.Version = &version
+				return err
+			},
+		),
+		"uploads": runCheck(
+			r, "upload directory is not writable", func(ctx context.Context, c *healthCheck) error {
+				f, err := os.CreateTemp(config.App.Uploads.Dir, ".readyz-*")
+				if err != nil {
+					return err
+				}
+				f.Close()
+				return os.Remove(f.Name())
+			},
+		),
+	}
+	
+	ready := true
+	for _, c := range checks {
+		if c.Status != "ok" {
+			ready = false
+		}
+	}
+	if shuttingDown.Load() {
+		checks["shutdown"] = healthCheck{Status: "failing", Error: "server is shutting down"}
+		ready = false
+	}
+	
+	status, message := http.StatusOK, "ready"
+	if !ready {
+		status, message = http.StatusServiceUnavailable, "not ready"
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(
+		models.Response{
+			Status:  status,
+			Message: message,
+			Data:    checks,
+		},
+	)
+}
+
+// runCheck times one readiness check. The underlying error is logged; the
+// probe response only carries the fixed failure text since /readyz is not
+// authenticated.
+func runCheck(r *http.Request, failure string, fn func(ctx context.Context, c *healthCheck) error) healthCheck {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+	
+	start := time.Now()
+	c := healthCheck{Status: "ok"}
+	if err := fn(ctx, &c); err != nil {
+		requestLogger(r).Warn("readiness check failed", "check", failure, "err", err)
+		c.Status = "failing"
+		c.Error = failure
+	}
+	c.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+	return c
+}
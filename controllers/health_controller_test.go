@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	
+	"backend-wisata/config"
+)
+
+func TestHealthz(t *testing.T) {
+	rec, res := serve(t, Healthz, newRequest(http.MethodGet, "/healthz", nil))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Fatal("health response may be cached")
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	e := newTestEnv(t)
+	uploads := config.App.Uploads.Dir
+	
+	// The database checks only pass in Postgres mode.
+	database := "failing"
+	if e.DB != nil {
+		database = "ok"
+	}
+	
+	tests := []struct {
+		name         string
+		uploads      string
+		shuttingDown bool
+		want         map[string]string
+	}{
+		{"serving", uploads, false, map[string]string{"database": database, "migrations": database, "uploads": "ok"}},
+		{"uploads missing", filepath.Join(uploads, "tidak-ada"), false, map[string]string{"database": database, "migrations": database, "uploads": "failing"}},
+		{"shutting down", uploads, true, map[string]string{"database": database, "migrations": database, "uploads": "ok", "shutdown": "failing"}},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				config.App.Uploads.Dir = tt.uploads
+				shuttingDown.Store(tt.shuttingDown)
+				t.Cleanup(
+					func() {
+						config.App.Uploads.Dir = uploads
+						shuttingDown.Store(false)
+					},
+				)
+				
+				rec, res := serve(t, e.Readyz, newRequest(http.MethodGet, "/readyz", nil))
+				
+				ready := true
+				for _, status := range tt.want {
+					ready = ready && status == "ok"
+				}
+				status := http.StatusServiceUnavailable
+				if ready {
+					status = http.StatusOK
+				}
+				expectStatus(t, rec, res, status, "")
+				
+				var checks map[string]healthCheck
+				decodeData(t, res, &checks)
+				if len(checks) != len(tt.want) {
+					t.Fatalf("checks %+v, want %v", checks, tt.want)
+				}
+				for name, want := range tt.want {
+					got := checks[name]
+					if got.Status != want {
+						t.Fatalf("%s check %q, want %q", name, got.Status, want)
+					}
+					if want == "failing" && got.Error == "" {
+						t.Fatalf("%s check failed without a reason", name)
+					}
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	
+	"backend-wisata/logger"
+	"backend-wisata/models"
+)
+
+// Failed logins are counted per account and per client IP. Once a key goes
+// past its free attempts it is locked for lockoutBase, doubling with every
+// further failure up to lockoutMax. Counters reset after lockoutWindow
+// without failures.
+const (
+	accountFreeAttempts = 5
+	ipFreeAttempts      = 20
+	lockoutBase         = 30 * time.Second
+	lockoutMax          = time.Hour
+	lockoutWindow       = 24 * time.Hour
+)
+
+const invalidCredentialsMessage = "Username atau password salah"
+
+// accountThrottleKey keys known accounts by ID so that logging in with the
+// username or the email counts against the same account. Unknown identifiers
+// get their own key and are locked exactly like real accounts, which keeps
+// lockouts from revealing whether an account exists.
+func accountThrottleKey(userID int, identifier string) string {
+	if userID > 0 {
+		return "user:" + strconv.Itoa(userID)
+	}
+	return "login:" + strings.ToLower(strings.TrimSpace(identifier))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+func lockoutDuration(failures, freeAttempts int) time.Duration {
+	if failures < freeAttempts {
+		return 0
+	}
+	
+	d := time.Duration(float64(lockoutBase) * math.Pow(2, float64(failures-freeAttempts)))
+	if d > lockoutMax || d <= 0 {
+		return lockoutMax
+	}
+	return d
+}
+
+// lockedFor returns how long the key is still locked, or zero.
+func (h *Handler) lockedFor(ctx context.Context, key string) (time.Duration, error) {
+	lockedUntil, err := h.Lockouts.LockedUntil(ctx, key)
+	if err != nil || lockedUntil.IsZero() {
+		return 0, err
+	}
+	return time.Until(lockedUntil), nil
+}
+
+func (h *Handler) recordLoginFailure(ctx context.Context, key string, freeAttempts int) {
+	failures, err := h.Lockouts.RecordFailure(ctx, key, time.Now().Add(-lockoutWindow))
+	if err != nil {
+		logger.FromContext(ctx).Error("record login failure failed", "err", err)
+		return
+	}
+	
+	if d := lockoutDuration(failures, freeAttempts); d > 0 {
+		if err := h.Lockouts.Lock(ctx, key, time.Now().Add(d)); err != nil {
+			logger.FromContext(ctx).Error("lock account failed", "err", err)
+		}
+	}
+}
+
+func (h *Handler) clearLoginFailures(ctx context.Context, key string) {
+	if err := h.Lockouts.Clear(ctx, key); err != nil {
+		logger.FromContext(ctx).Error("clear login failures failed", "err", err)
+	}
+}
+
+func responseLocked(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	responseErrorCode(
+		w, http.StatusTooManyRequests, "too_many_attempts",
+		"Terlalu banyak percobaan login, coba lagi dalam "+strconv.Itoa(seconds)+" detik",
+	)
+}
+
+func (h *Handler) GetLockouts(w http.ResponseWriter, r *http.Request) {
+	lockouts, err := h.Lockouts.Locked(r.Context())
+	if err != nil {
+		requestLogger(r).Error("fetch lockouts failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil data lockout")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(
+		models.Response{
+			Status:  200,
+			Message: "Lockouts Fetched",
+			Data:    lockouts,
+		},
+	)
+}
+
+// UnlockUser clears the failure counter of an account (?id=) or of any
+// throttle key such as an IP address (?key=ip:203.0.113.7).
+func (h *Handler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if id, _ := strconv.Atoi(pathParam(r, "id")); id > 0 {
+		key = accountThrottleKey(id, "")
+	}
+	
+	if key == "" {
+		responseError(w, http.StatusBadRequest, "ID atau key wajib diisi")
+		return
+	}
+	
+	if err := h.Lockouts.Clear(r.Context(), key); err != nil {
+		requestLogger(r).Error("unlock account failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal membuka kunci akun")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.Response{Status: 200, Message: "Account Unlocked"})
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+// lock records a failure for key and locks it for d.
+func (e *testEnv) lock(key string, d time.Duration) {
+	e.t.Helper()
+	ctx := context.Background()
+	if _, err := e.Lockouts.RecordFailure(ctx, key, time.Now().Add(-lockoutWindow)); err != nil {
+		e.t.Fatal("record failure:", err)
+	}
+	if err := e.Lockouts.Lock(ctx, key, time.Now().Add(d)); err != nil {
+		e.t.Fatal("lock:", err)
+	}
+}
+
+func TestGetLockouts(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	user := e.newUser(models.RoleUser)
+	
+	e.lock(accountThrottleKey(user.ID, ""), time.Hour)
+	e.lock(ipThrottleKey("203.0.113.7"), time.Minute)
+	e.lock(accountThrottleKey(0, "tidak-ada"), -time.Minute)
+	
+	rec, res := serve(t, e.GetLockouts, asUser(newRequest(http.MethodGet, "/api/v1/users/lockouts", nil), admin))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	
+	var list []models.Lockout
+	decodeData(t, res, &list)
+	if len(list) != 2 {
+		t.Fatalf("%d lockouts, want 2: %+v", len(list), list)
+	}
+	
+	tests := []struct {
+		key    string
+		userID int
+	}{
+		{accountThrottleKey(user.ID, ""), user.ID},
+		{ipThrottleKey("203.0.113.7"), 0},
+	}
+	
+	// Lockouts that end last come first.
+	for i, tt := range tests {
+		got := list[i]
+		if got.Key != tt.key || got.Failures != 1 {
+			t.Fatalf("lockout %d is %s with %d failures, want %s", i, got.Key, got.Failures, tt.key)
+		}
+		if tt.userID == 0 && got.UserID != nil {
+			t.Fatalf("%s linked to user %d", got.Key, *got.UserID)
+		}
+		if tt.userID != 0 && (got.UserID == nil || *got.UserID != tt.userID || *got.FullName != user.FullName) {
+			t.Fatalf("%s not linked to user %d", got.Key, tt.userID)
+		}
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	user := e.newUser(models.RoleUser)
+	id := strconv.Itoa(user.ID)
+	
+	tests := []struct {
+		name   string
+		id     string
+		query  string
+		key    string
+		status int
+	}{
+		{"by account", id, "", accountThrottleKey(user.ID, ""), http.StatusOK},
+		{"by key", "", "?key=ip:203.0.113.7", ipThrottleKey("203.0.113.7"), http.StatusOK},
+		{"account over key", id, "?key=ip:203.0.113.8", accountThrottleKey(user.ID, ""), http.StatusOK},
+		{"neither", "", "", ipThrottleKey("203.0.113.9"), http.StatusBadRequest},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				e.lock(tt.key, time.Hour)
+				
+				r := newRequest(http.MethodPost, "/api/v1/users/lockouts/unlock"+tt.query, nil)
+				if tt.id != "" {
+					r = withPath(r, "id", tt.id)
+				}
+				rec, res := serve(t, e.UnlockUser, asUser(r, admin))
+				expectStatus(t, rec, res, tt.status, "")
+				
+				lockedUntil, err := e.Lockouts.LockedUntil(context.Background(), tt.key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if unlocked := lockedUntil.IsZero(); unlocked != (tt.status == http.StatusOK) {
+					t.Fatalf("%s locked until %v after status %d", tt.key, lockedUntil, rec.Code)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+	
+	"backend-wisata/config"
+	"backend-wisata/mailer"
+	"backend-wisata/models"
+	"backend-wisata/store"
+)
+
+const passwordResetTTL = time.Hour
+
+func newToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+	
+	// The response is the same whether or not the email exists so the
+	// endpoint cannot be used to enumerate accounts.
+	ok := models.Response{
+		Status:  200,
+		Message: "Jika email terdaftar, tautan reset password telah dikirim",
+	}
+	
+	user, err := h.Users.FindByEmail(r.Context(), input.Email)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && !user.IsActive) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ok)
+		return
+	} else if err != nil {
+		requestLogger(r).Error("fetch user failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memproses permintaan")
+		return
+	}
+	
+	token := newToken()
+	
+	err = h.Users.CreatePasswordReset(r.Context(), user.ID, hashToken(token), time.Now().Add(passwordResetTTL))
+	if err != nil {
+		requestLogger(r).Error("create reset token failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memproses permintaan")
+		return
+	}
+	
+	link := config.AppURL + "/reset-password?token=" + url.QueryEscape(token)
+	msg := mailer.Message{
+		To:      input.Email,
+		Subject: "Reset password akun Anda",
+		Body: "Halo " + user.FullName + ",\n\n" +
+			"Kami menerima permintaan untuk mereset password akun Anda. " +
+			"Buka tautan berikut dalam 1 jam untuk membuat password baru:\n\n" +
+			link + "\n\n" +
+			"Abaikan email ini jika Anda tidak merasa memintanya.",
+	}
+	
+	// Sent in the background so the response time does not reveal whether
+	// the account exists.
+	goBackground(func() {
+		if err := config.Mailer.Send(msg); err != nil {
+			requestLogger(r).Error("send reset email failed", "err", err)
+		}
+	})
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ok)
+}
+
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Token    string `json:"token" validate:"required"`
+		Password string `json:"password" validate:"required,min=8,maxbytes=72"`
+	}
+	
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+	
+	hashedPassword, err := hashPassword(input.Password)
+	if err != nil {
+		requestLogger(r).Error("hash password failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal enkripsi password")
+		return
+	}
+	
+	userID, err := h.Users.ResetPassword(r.Context(), hashToken(input.Token), hashedPassword)
+	if errors.Is(err, store.ErrNotFound) {
+		responseError(w, http.StatusBadRequest, "Token tidak valid atau sudah kedaluwarsa")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("reset password failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mereset password")
+		return
+	}
+	
+	if err := h.Sessions.RevokeUser(r.Context(), userID); err != nil {
+		requestLogger(r).Error("revoke sessions failed", "err", err)
+	}
+	h.clearLoginFailures(r.Context(), accountThrottleKey(userID, ""))
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(
+		models.Response{
+			Status:  200,
+			Message: "Password berhasil direset, silakan login kembali",
+		},
+	)
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	
+	"backend-wisata/models"
+)
+
+func TestForgotPassword(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	inactive := e.newUser(models.RoleUser)
+	if err := e.Users.UpdateStatus(t.Context(), inactive.ID, false, models.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	
+	tests := []struct {
+		name  string
+		email string
+		sent  int
+	}{
+		{"active user", user.Email, 1},
+		{"inactive user", inactive.Email, 0},
+		{"unknown email", "tidak-ada@example.test", 0},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				outbox.reset(t)
+				rec, res := serve(t, e.ForgotPassword, newRequest(http.MethodPost, "/api/v1/password/forgot", map[string]string{"email": tt.email}))
+				expectStatus(t, rec, res, http.StatusOK, "")
+				
+				if got := len(outbox.messages(t, tt.email)); got != tt.sent {
+					t.Fatalf("%d reset emails sent, want %d", got, tt.sent)
+				}
+			},
+		)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	pair := e.issueToken(user)
+	
+	rec, res := serve(t, e.ForgotPassword, newRequest(http.MethodPost, "/api/v1/password/forgot", map[string]string{"email": user.Email}))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	sent := outbox.messages(t, user.Email)
+	if len(sent) != 1 {
+		t.Fatalf("%d reset emails sent, want 1", len(sent))
+	}
+	token := linkToken(t, sent[0])
+	
+	// The cases run in order, so the token is used up by the valid one.
+	tests := []struct {
+		name     string
+		token    string
+		password string
+		status   int
+		code     string
+	}{
+		{"short password", token, "pendek", http.StatusUnprocessableEntity, "validation_failed"},
+		{"password over 72 bytes", token, strings.Repeat("é", 40), http.StatusUnprocessableEntity, "validation_failed"},
+		{"wrong token", token + "x", "password-baru", http.StatusBadRequest, ""},
+		{"valid", token, "password-baru", http.StatusOK, ""},
+		{"reused token", token, "password-lain", http.StatusBadRequest, ""},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				input := map[string]string{"token": tt.token, "password": tt.password}
+				rec, res := serve(t, e.ResetPassword, newRequest(http.MethodPost, "/api/v1/password/reset", input))
+				expectStatus(t, rec, res, tt.status, tt.code)
+			},
+		)
+	}
+	
+	// Tokens issued for the old password stop working.
+	if _, err := e.bearerUser(pair.AccessToken); err == nil {
+		t.Fatal("access token survived the password reset")
+	}
+	
+	login := func(password string) int {
+		rec, _ := serve(t, e.Login, newRequest(http.MethodPost, "/api/v1/login", map[string]string{"username": user.Username, "password": password}))
+		return rec.Code
+	}
+	if status := login(testPassword); status != http.StatusUnauthorized {
+		t.Fatalf("old password: %d", status)
+	}
+	if status := login("password-baru"); status != http.StatusOK {
+		t.Fatalf("new password: %d", status)
+	}
+}
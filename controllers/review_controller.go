@@ -5,59 +5,44 @@ import (
 	"net/http"
 	"strconv"
 	
-	"backend-wisata/config"
+	"backend-wisata/metrics"
 	"backend-wisata/models"
 )
 
-func SubmitReview(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(models.Response{Status: 405, Message: "Method not allowed"})
-		return
-	}
-	
+func (h *Handler) SubmitReview(w http.ResponseWriter, r *http.Request) {
+	// user_id is only honoured for admins, see actingUserID.
 	var input struct {
-		WisataID int    `json:"wisata_id"`
+		WisataID int    `json:"wisata_id" validate:"required,min=1"`
 		UserID   int    `json:"user_id"`
-		Rating   int    `json:"rating"`
-		Comment  string `json:"comment"`
+		Rating   int    `json:"rating" validate:"required,min=1,max=5"`
+		Comment  string `json:"comment" validate:"max=2000"`
 	}
 	
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(models.Response{Status: 400, Message: "Invalid body"})
+	if !decodeJSON(w, r, &input) {
 		return
 	}
 	
-	var hasVisited bool
-	checkQuery := `
-		SELECT EXISTS(
-			SELECT 1 FROM bookings
-			WHERE user_id = $1 AND wisata_id = $2 AND status IN ('paid', 'completed')
-		)`
-	config.DB.QueryRow(checkQuery, input.UserID, input.WisataID).Scan(&hasVisited)
+	userID := actingUserID(r, input.UserID)
 	
-	if !hasVisited {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusForbidden)
-		json.NewEncoder(w).Encode(models.Response{Status: 403, Message: "Anda harus berkunjung sebelum memberi ulasan"})
+	hasVisited, err := h.Bookings.HasVisited(r.Context(), userID, input.WisataID)
+	if err != nil {
+		requestLogger(r).Error("check visit failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menyimpan ulasan")
 		return
 	}
 	
-	query := `
-		INSERT INTO reviews (wisata_id, user_id, rating, comment, is_approved)
-		VALUES ($1, $2, $3, $4, FALSE)
-	`
-	_, err := config.DB.Exec(query, input.WisataID, input.UserID, input.Rating, input.Comment)
+	if !hasVisited {
+		responseErrorCode(w, http.StatusForbidden, "not_visited", "Anda harus berkunjung sebelum memberi ulasan")
+		return
+	}
 	
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(models.Response{Status: 500, Message: "Gagal menyimpan ulasan"})
+	review := models.Review{WisataID: input.WisataID, UserID: userID, Rating: input.Rating, Comment: input.Comment}
+	if err := h.Reviews.Create(r.Context(), &review); err != nil {
+		requestLogger(r).Error("create review failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menyimpan ulasan")
 		return
 	}
+	metrics.ReviewsSubmitted.Inc()
 	
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -69,44 +54,15 @@ func SubmitReview(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func GetReviews(w http.ResponseWriter, r *http.Request) {
-	wisataID := r.URL.Query().Get("wisata_id")
-	
-	query := `
-		SELECT r.id, r.wisata_id, r.user_id, r.rating, r.comment, r.created_at,
-			u.full_name, u.profile_image
-		FROM reviews r
-		JOIN users u ON r.user_id = u.id
-		WHERE r.wisata_id = $1 AND r.is_approved = TRUE
-		ORDER BY r.created_at DESC
-	`
+func (h *Handler) GetReviews(w http.ResponseWriter, r *http.Request) {
+	wisataID, _ := strconv.Atoi(r.URL.Query().Get("wisata_id"))
 	
-	rows, err := config.DB.Query(query, wisataID)
+	reviews, err := h.Reviews.ListApproved(r.Context(), wisataID)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(models.Response{Status: 500, Message: err.Error()})
+		requestLogger(r).Error("fetch reviews failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil ulasan")
 		return
 	}
-	defer rows.Close()
-	
-	var reviews []models.Review
-	for rows.Next() {
-		var r models.Review
-		if err := rows.Scan(
-			&r.ID,
-			&r.WisataID,
-			&r.UserID,
-			&r.Rating,
-			&r.Comment,
-			&r.CreatedAt,
-			&r.UserName,
-			&r.UserProfile,
-		); err != nil {
-			continue
-		}
-		reviews = append(reviews, r)
-	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(
@@ -117,48 +73,30 @@ func GetReviews(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func GetAdminReviews(w http.ResponseWriter, r *http.Request) {
-	query := `
-		SELECT r.id, r.rating, r.comment, r.is_approved, r.created_at,
-			u.full_name, w.nama_tempat
-		FROM reviews r
-		JOIN users u ON r.user_id = u.id
-		JOIN wisata w ON r.wisata_id = w.id
-		ORDER BY r.created_at DESC
-	`
-	
-	rows, err := config.DB.Query(query)
+func (h *Handler) GetAdminReviews(w http.ResponseWriter, r *http.Request) {
+	list, err := h.Reviews.List(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		requestLogger(r).Error("fetch reviews failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil ulasan")
 		return
 	}
-	defer rows.Close()
 	
 	var reviews []map[string]interface{}
-	for rows.Next() {
-		var id, rating int
-		var comment, userName, wisataName string
-		var isApproved bool
-		var createdAt string
-		
-		if err := rows.Scan(&id, &rating, &comment, &isApproved, &createdAt, &userName, &wisataName); err != nil {
-			continue
-		}
-		
+	for _, review := range list {
 		status := "pending"
-		if isApproved {
+		if review.IsApproved {
 			status = "approved"
 		}
 		
 		reviews = append(
 			reviews, map[string]interface{}{
-				"id":      id,
-				"rating":  rating,
-				"comment": comment,
+				"id":      review.ID,
+				"rating":  review.Rating,
+				"comment": review.Comment,
 				"status":  status,
-				"user":    userName,
-				"wisata":  wisataName,
-				"date":    createdAt,
+				"user":    review.UserName,
+				"wisata":  review.WisataName,
+				"date":    review.CreatedAt,
 			},
 		)
 	}
@@ -172,36 +110,26 @@ func GetAdminReviews(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func ApproveReview(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
-	idStr := r.URL.Query().Get("id")
+func (h *Handler) ApproveReview(w http.ResponseWriter, r *http.Request) {
+	idStr := pathParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	
-	_, err := config.DB.Exec("UPDATE reviews SET is_approved = TRUE WHERE id = $1", id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.Reviews.Approve(r.Context(), id); err != nil {
+		requestLogger(r).Error("approve review failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menyetujui ulasan")
 		return
 	}
 	
 	json.NewEncoder(w).Encode(models.Response{Status: 200, Message: "Review Approved"})
 }
 
-func DeleteReview(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "DELETE" && r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
-	idStr := r.URL.Query().Get("id")
+func (h *Handler) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	idStr := pathParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	
-	_, err := config.DB.Exec("DELETE FROM reviews WHERE id = $1", id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.Reviews.Delete(r.Context(), id); err != nil {
+		requestLogger(r).Error("delete review failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menghapus ulasan")
 		return
 	}
 	
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	
+	"backend-wisata/models"
+)
+
+func TestSubmitReviewEligibility(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	wisata := e.newWisata()
+	elsewhere := e.newWisata()
+	
+	tests := []struct {
+		name string
+		// booking is the status of the reviewer's booking, "" for none.
+		booking string
+		// bookedWisata is where that booking is for, the reviewed wisata
+		// when nil.
+		bookedWisata *models.Wisata
+		status       int
+		code         string
+	}{
+		{"no booking", "", nil, http.StatusForbidden, "not_visited"},
+		{"pending booking", "pending", nil, http.StatusForbidden, "not_visited"},
+		{"cancelled booking", "cancelled", nil, http.StatusForbidden, "not_visited"},
+		{"paid booking", "paid", nil, http.StatusCreated, ""},
+		{"completed booking", "completed", nil, http.StatusCreated, ""},
+		{"paid booking elsewhere", "paid", elsewhere, http.StatusForbidden, "not_visited"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				user := e.newUser(models.RoleUser)
+				if tt.booking != "" {
+					booked := wisata
+					if tt.bookedWisata != nil {
+						booked = tt.bookedWisata
+					}
+					e.newBooking(user, booked, tt.booking)
+				}
+				
+				input := map[string]any{"wisata_id": wisata.ID, "rating": 5, "comment": "Bagus"}
+				rec, res := serve(t, e.SubmitReview, asUser(newRequest(http.MethodPost, "/api/v1/reviews", input), user))
+				expectStatus(t, rec, res, tt.status, tt.code)
+			},
+		)
+	}
+	
+	t.Run(
+		"admin for a visitor", func(t *testing.T) {
+			visitor := e.newUser(models.RoleUser)
+			e.newBooking(visitor, wisata, "paid")
+			
+			input := map[string]any{"wisata_id": wisata.ID, "user_id": visitor.ID, "rating": 4}
+			rec, res := serve(t, e.SubmitReview, asUser(newRequest(http.MethodPost, "/api/v1/reviews", input), admin))
+			expectStatus(t, rec, res, http.StatusCreated, "")
+		},
+	)
+	
+	t.Run(
+		"user_id ignored for users", func(t *testing.T) {
+			visitor := e.newUser(models.RoleUser)
+			e.newBooking(visitor, wisata, "paid")
+			user := e.newUser(models.RoleUser)
+			
+			input := map[string]any{"wisata_id": wisata.ID, "user_id": visitor.ID, "rating": 4}
+			rec, res := serve(t, e.SubmitReview, asUser(newRequest(http.MethodPost, "/api/v1/reviews", input), user))
+			expectStatus(t, rec, res, http.StatusForbidden, "not_visited")
+		},
+	)
+}
+
+func TestSubmitReviewValidation(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	wisata := e.newWisata()
+	e.newBooking(user, wisata, "paid")
+	
+	tests := []struct {
+		name  string
+		input map[string]any
+		field string
+	}{
+		{"rating too low", map[string]any{"wisata_id": wisata.ID, "rating": 0}, "rating"},
+		{"rating too high", map[string]any{"wisata_id": wisata.ID, "rating": 6}, "rating"},
+		{"missing wisata", map[string]any{"rating": 5}, "wisata_id"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec, res := serve(t, e.SubmitReview, asUser(newRequest(http.MethodPost, "/api/v1/reviews", tt.input), user))
+				expectStatus(t, rec, res, http.StatusUnprocessableEntity, "validation_failed")
+				if len(res.Details[tt.field]) == 0 {
+					t.Fatalf("no error for field %s: %v", tt.field, res.Details)
+				}
+			},
+		)
+	}
+}
+
+func TestReviewModeration(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	admin := e.newUser(models.RoleAdmin)
+	wisata := e.newWisata()
+	e.newBooking(user, wisata, "completed")
+	
+	input := map[string]any{"wisata_id": wisata.ID, "rating": 4, "comment": "Pemandangannya indah"}
+	rec, res := serve(t, e.SubmitReview, asUser(newRequest(http.MethodPost, "/api/v1/reviews", input), user))
+	expectStatus(t, rec, res, http.StatusCreated, "")
+	
+	public := func() []models.Review {
+		rec, res := serve(t, e.GetReviews, newRequest(http.MethodGet, "/api/v1/reviews?wisata_id="+strconv.Itoa(wisata.ID), nil))
+		expectStatus(t, rec, res, http.StatusOK, "")
+		var list []models.Review
+		if res.Data != nil {
+			decodeData(t, res, &list)
+		}
+		return list
+	}
+	
+	type adminReview struct {
+		ID     int    `json:"id"`
+		Status string `json:"status"`
+		User   string `json:"user"`
+		Wisata string `json:"wisata"`
+	}
+	moderation := func() []adminReview {
+		rec, res := serve(t, e.GetAdminReviews, asUser(newRequest(http.MethodGet, "/api/v1/admin/reviews", nil), admin))
+		expectStatus(t, rec, res, http.StatusOK, "")
+		var list []adminReview
+		if res.Data != nil {
+			decodeData(t, res, &list)
+		}
+		return list
+	}
+	
+	queue := moderation()
+	if len(queue) != 1 {
+		t.Fatalf("moderation queue: %+v", queue)
+	}
+	id := strconv.Itoa(queue[0].ID)
+	
+	// The cases run in order on the same review.
+	tests := []struct {
+		name   string
+		action http.HandlerFunc
+		public bool
+		status string
+	}{
+		{"submitted", nil, false, "pending"},
+		{"approved", e.ApproveReview, true, "approved"},
+		{"deleted", e.DeleteReview, false, ""},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if tt.action != nil {
+					rec, res := serve(t, tt.action, asUser(withPath(newRequest(http.MethodPost, "/", nil), "id", id), admin))
+					expectStatus(t, rec, res, http.StatusOK, "")
+				}
+				
+				list := public()
+				if !tt.public && len(list) != 0 {
+					t.Fatalf("review is public: %+v", list)
+				}
+				if tt.public && (len(list) != 1 || list[0].Comment != "Pemandangannya indah" || list[0].UserName != user.FullName) {
+					t.Fatalf("approved reviews: %+v", list)
+				}
+				
+				queue := moderation()
+				if tt.status == "" {
+					if len(queue) != 0 {
+						t.Fatalf("deleted review still listed: %+v", queue)
+					}
+					return
+				}
+				if len(queue) != 1 || queue[0].Status != tt.status {
+					t.Fatalf("moderation queue %+v, want status %q", queue, tt.status)
+				}
+				if queue[0].User != user.FullName || queue[0].Wisata != wisata.NamaTempat {
+					t.Fatalf("review shown as by %q for %q", queue[0].User, queue[0].Wisata)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	
+	"backend-wisata/models"
+	"backend-wisata/store"
+)
+
+// currentSessionToken returns the ID of the session cookie the request was
+// made with, or "" for bearer tokens and API keys.
+func (h *Handler) currentSessionToken(r *http.Request) (string, error) {
+	session, err := h.authenticatedSession(r)
+	if session == nil {
+		return "", err
+	}
+	return session.ID, nil
+}
+
+func (h *Handler) GetMySessions(w http.ResponseWriter, r *http.Request) {
+	user := CurrentUser(r)
+	
+	currentToken, err := h.currentSessionToken(r)
+	if err != nil {
+		requestLogger(r).Error("load session failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil data sesi")
+		return
+	}
+	
+	list, err := h.Sessions.ListSessions(r.Context(), user.ID, currentToken)
+	if err != nil {
+		requestLogger(r).Error("fetch sessions failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil data sesi")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(
+		models.Response{
+			Status:  200,
+			Message: "Sessions Fetched",
+			Data:    list,
+		},
+	)
+}
+
+func (h *Handler) RevokeMySession(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(pathParam(r, "id"))
+	
+	err := h.Sessions.RevokeSession(r.Context(), CurrentUser(r).ID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		responseError(w, http.StatusNotFound, "Sesi tidak ditemukan")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("revoke session failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mencabut sesi")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.Response{Status: 200, Message: "Session Revoked"})
+}
+
+func (h *Handler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	currentToken, err := h.currentSessionToken(r)
+	if err != nil {
+		requestLogger(r).Error("load session failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mencabut sesi")
+		return
+	}
+	
+	if err := h.Sessions.RevokeOtherSessions(r.Context(), CurrentUser(r).ID, currentToken); err != nil {
+		requestLogger(r).Error("revoke sessions failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mencabut sesi")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.Response{Status: 200, Message: "Other Sessions Revoked"})
+}
@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	
+	"backend-wisata/models"
+)
+
+// requireSessionTable skips the test unless cookie sessions are kept in the
+// user_sessions table. The memory stores do not track them, so they can only
+// be listed and revoked with a database.
+func (e *testEnv) requireSessionTable() {
+	e.t.Helper()
+	if e.DB == nil {
+		e.t.Skip("cookie sessions are only tracked with " + testDatabaseEnv)
+	}
+}
+
+// sessionStatus returns the status of GET /api/v1/me made with cookies,
+// 401 once the session is gone.
+func (e *testEnv) sessionStatus(cookies []*http.Cookie) int {
+	e.t.Helper()
+	rec, _ := serve(e.t, e.authenticated(e.GetMe), withCookies(newRequest(http.MethodGet, "/api/v1/me", nil), cookies))
+	return rec.Code
+}
+
+// mySessions lists the sessions of user through GetMySessions, made with
+// cookies.
+func (e *testEnv) mySessions(user *models.User, cookies []*http.Cookie) []models.UserSession {
+	e.t.Helper()
+	r := withCookies(asUser(newRequest(http.MethodGet, "/api/v1/sessions", nil), user), cookies)
+	rec, res := serve(e.t, e.GetMySessions, r)
+	expectStatus(e.t, rec, res, http.StatusOK, "")
+	
+	var list []models.UserSession
+	if res.Data != nil {
+		decodeData(e.t, res, &list)
+	}
+	return list
+}
+
+func TestGetMySessions(t *testing.T) {
+	e := newTestEnv(t)
+	e.requireSessionTable()
+	user := e.newUser(models.RoleUser)
+	first, _ := e.login(user)
+	second, _ := e.login(user)
+	e.login(e.newUser(models.RoleUser))
+	
+	tests := []struct {
+		name    string
+		cookies []*http.Cookie
+		current int
+	}{
+		{"first session", first, 0},
+		{"second session", second, 1},
+	}
+	
+	var ids [2]int
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				list := e.mySessions(user, tt.cookies)
+				if len(list) != 2 {
+					t.Fatalf("%d sessions, want 2", len(list))
+				}
+				
+				var current []int
+				for _, s := range list {
+					if s.Current {
+						current = append(current, s.ID)
+					}
+				}
+				if len(current) != 1 {
+					t.Fatalf("%d current sessions, want 1", len(current))
+				}
+				ids[tt.current] = current[0]
+			},
+		)
+	}
+	if ids[0] == ids[1] {
+		t.Fatal("both logins marked the same session as current")
+	}
+}
+
+func TestRevokeMySession(t *testing.T) {
+	e := newTestEnv(t)
+	e.requireSessionTable()
+	user := e.newUser(models.RoleUser)
+	cookies, _ := e.login(user)
+	other, _ := e.login(user)
+	stranger := e.newUser(models.RoleUser)
+	strangerCookies, _ := e.login(stranger)
+	
+	sessionID := func(user *models.User, cookies []*http.Cookie) string {
+		for _, s := range e.mySessions(user, cookies) {
+			if s.Current {
+				return strconv.Itoa(s.ID)
+			}
+		}
+		t.Fatal("no current session")
+		return ""
+	}
+	otherID := sessionID(user, other)
+	strangerID := sessionID(stranger, strangerCookies)
+	
+	tests := []struct {
+		name   string
+		id     string
+		status int
+	}{
+		{"someone else's session", strangerID, http.StatusNotFound},
+		{"unknown session", "9999", http.StatusNotFound},
+		{"own session", otherID, http.StatusOK},
+		{"already revoked", otherID, http.StatusNotFound},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := withPath(newRequest(http.MethodDelete, "/api/v1/sessions/"+tt.id, nil), "id", tt.id)
+				rec, res := serve(t, e.RevokeMySession, withCookies(asUser(r, user), cookies))
+				expectStatus(t, rec, res, tt.status, "")
+			},
+		)
+	}
+	
+	if status := e.sessionStatus(other); status != http.StatusUnauthorized {
+		t.Fatalf("revoked session got %d, want 401", status)
+	}
+	for _, c := range [][]*http.Cookie{cookies, strangerCookies} {
+		if status := e.sessionStatus(c); status != http.StatusOK {
+			t.Fatalf("session revoked by mistake: got %d", status)
+		}
+	}
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	e := newTestEnv(t)
+	e.requireSessionTable()
+	user := e.newUser(models.RoleUser)
+	cookies, _ := e.login(user)
+	others := [][]*http.Cookie{}
+	for range 2 {
+		c, _ := e.login(user)
+		others = append(others, c)
+	}
+	stranger, _ := e.login(e.newUser(models.RoleUser))
+	
+	r := withCookies(asUser(newRequest(http.MethodPost, "/api/v1/sessions/revoke-others", nil), user), cookies)
+	rec, res := serve(t, e.RevokeOtherSessions, r)
+	expectStatus(t, rec, res, http.StatusOK, "")
+	
+	tests := []struct {
+		name    string
+		cookies []*http.Cookie
+		revoked bool
+	}{
+		{"current session", cookies, false},
+		{"other session", others[0], true},
+		{"another session", others[1], true},
+		{"someone else's session", stranger, false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				status := e.sessionStatus(tt.cookies)
+				if revoked := status == http.StatusUnauthorized; revoked != tt.revoked {
+					t.Fatalf("got %d, want revoked = %v", status, tt.revoked)
+				}
+			},
+		)
+	}
+	
+	if list := e.mySessions(user, cookies); len(list) != 1 || !list[0].Current {
+		t.Fatalf("sessions left %+v", list)
+	}
+}
+
+// Bearer tokens are not cookie sessions, so none of the sessions listed for
+// them is current and revoking the others leaves the token working.
+func TestSessionsWithBearerToken(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	pair := e.issueToken(user)
+	
+	r := withBearer(asUser(newRequest(http.MethodGet, "/api/v1/sessions", nil), user), pair.AccessToken)
+	rec, res := serve(t, e.GetMySessions, r)
+	expectStatus(t, rec, res, http.StatusOK, "")
+	if res.Data != nil {
+		var list []models.UserSession
+		decodeData(t, res, &list)
+		for _, s := range list {
+			if s.Current {
+				t.Fatalf("session %d listed as current", s.ID)
+			}
+		}
+	}
+	
+	r = withBearer(asUser(newRequest(http.MethodPost, "/api/v1/sessions/revoke-others", nil), user), pair.AccessToken)
+	rec, res = serve(t, e.RevokeOtherSessions, r)
+	expectStatus(t, rec, res, http.StatusOK, "")
+	if _, err := e.bearerUser(pair.AccessToken); err != nil {
+		t.Fatal("token revoked with the other sessions:", err)
+	}
+	
+	r = withPath(asUser(newRequest(http.MethodDelete, "/api/v1/sessions/9999", nil), user), "id", "9999")
+	rec, res = serve(t, e.RevokeMySession, r)
+	expectStatus(t, rec, res, http.StatusNotFound, "")
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	
+	"backend-wisata/config"
+	"backend-wisata/logger"
+	"backend-wisata/models"
+	"backend-wisata/store"
+)
+
+// Access tokens are short-lived; the refresh token is rotated on every use.
+// Both are opaque random strings and only their SHA-256 is stored.
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// bearerToken returns the token from "Authorization: Bearer <token>".
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// tokenUser resolves the user behind a bearer access token.
+func (h *Handler) tokenUser(ctx context.Context, token string) (*models.User, error) {
+	userID, err := h.Sessions.TokenUser(ctx, hashToken(token))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrUnauthenticated
+	} else if err != nil {
+		return nil, err
+	}
+	
+	user, err := h.Users.Get(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrUnauthenticated
+	}
+	return user, err
+}
+
+func (h *Handler) issueTokenPair(r *http.Request, userID int) (*models.TokenPair, error) {
+	pair := &models.TokenPair{
+		AccessToken:  newToken(),
+		RefreshToken: newToken(),
+		TokenType:    "Bearer",
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+	}
+	
+	now := time.Now()
+	err := h.Sessions.CreateToken(
+		r.Context(), &store.AuthToken{
+			UserID:           userID,
+			AccessHash:       hashToken(pair.AccessToken),
+			RefreshHash:      hashToken(pair.RefreshToken),
+			AccessExpiresAt:  now.Add(accessTokenTTL),
+			RefreshExpiresAt: now.Add(refreshTokenTTL),
+			UserAgent:        r.UserAgent(),
+			IPAddress:        config.ClientIP(r),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	
+	return pair, nil
+}
+
+func responseTokenPair(w http.ResponseWriter, pair *models.TokenPair) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(
+		models.Response{
+			Status:  200,
+			Message: "Token Issued",
+			Data:    pair,
+		},
+	)
+}
+
+func (h *Handler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Username string `json:"username" validate:"required"`
+		Password string `json:"password" validate:"required"`
+		Code     string `json:"code" validate:"max=32"`
+	}
+	
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+	
+	user := h.checkCredentials(w, r, input.Username, input.Password)
+	if user == nil {
+		return
+	}
+	
+	if user.TwoFactor {
+		if input.Code == "" {
+			responseErrorCode(w, http.StatusUnauthorized, "two_factor_required", "Masukkan kode autentikasi dua faktor")
+			return
+		}
+		
+		valid, err := h.verifySecondFactor(r.Context(), user.ID, input.Code)
+		if err != nil {
+			requestLogger(r).Error("verify 2fa failed", "err", err)
+			responseError(w, http.StatusInternalServerError, "Gagal memproses login")
+			return
+		}
+		if !valid {
+			h.recordLoginFailure(r.Context(), accountThrottleKey(user.ID, ""), accountFreeAttempts)
+			h.recordLoginFailure(r.Context(), ipThrottleKey(config.ClientIP(r)), ipFreeAttempts)
+			responseErrorCode(w, http.StatusUnauthorized, "invalid_two_factor_code", "Kode autentikasi salah")
+			return
+		}
+	} else if user.IsAdmin() && config.RequireAdmin2FA {
+		// Tokens carry no restricted mode, so admins have to enroll through
+		// the regular login first.
+		responseErrorCode(w, http.StatusForbidden, "two_factor_setup_required", "Aktifkan autentikasi dua faktor terlebih dahulu")
+		return
+	}
+	
+	if err := h.Users.TouchLastLogin(r.Context(), user.ID); err != nil {
+		requestLogger(r).Error("update last login failed", "err", err)
+	}
+	
+	pair, err := h.issueTokenPair(r, user.ID)
+	if err != nil {
+		requestLogger(r).Error("issue token failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal membuat token")
+		return
+	}
+	
+	responseTokenPair(w, pair)
+}
+
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" validate:"required"`
+	}
+	
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+	
+	token, err := h.Sessions.FindRefreshToken(r.Context(), hashToken(input.RefreshToken))
+	if errors.Is(err, store.ErrNotFound) {
+		responseErrorCode(w, http.StatusUnauthorized, "invalid_refresh_token", "Refresh token tidak valid")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("fetch refresh token failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memperbarui token")
+		return
+	}
+	
+	// A rotated refresh token showing up again means it leaked; cut off the
+	// whole family of tokens for the user.
+	if token.RotatedAt != nil {
+		if err := h.Sessions.RevokeUser(r.Context(), token.UserID); err != nil {
+			requestLogger(r).Error("revoke sessions failed", "err", err)
+		}
+	}
+	if token.RevokedAt != nil {
+		responseErrorCode(w, http.StatusUnauthorized, "invalid_refresh_token", "Refresh token tidak valid")
+		return
+	}
+	
+	user, err := h.Users.Get(r.Context(), token.UserID)
+	if err != nil || !user.IsActive {
+		responseErrorCode(w, http.StatusUnauthorized, "invalid_refresh_token", "Refresh token tidak valid")
+		return
+	}
+	
+	rotated, err := h.Sessions.RotateToken(r.Context(), token.ID)
+	if err != nil {
+		requestLogger(r).Error("rotate token failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memperbarui token")
+		return
+	}
+	if !rotated {
+		responseErrorCode(w, http.StatusUnauthorized, "invalid_refresh_token", "Refresh token tidak valid")
+		return
+	}
+	
+	pair, err := h.issueTokenPair(r, user.ID)
+	if err != nil {
+		requestLogger(r).Error("issue token failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memperbarui token")
+		return
+	}
+	
+	responseTokenPair(w, pair)
+}
+
+// revokeBearerToken is used by Logout for token-authenticated clients.
+func (h *Handler) revokeBearerToken(ctx context.Context, token string) {
+	if err := h.Sessions.RevokeAccessToken(ctx, hashToken(token)); err != nil {
+		logger.FromContext(ctx).Error("revoke token failed", "err", err)
+	}
+}
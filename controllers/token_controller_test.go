@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"backend-wisata/config"
+	"backend-wisata/models"
+)
+
+// issueToken returns the token pair for the user, failing the test if none
+// is issued.
+func (e *testEnv) issueToken(user *models.User) models.TokenPair {
+	e.t.Helper()
+	rec, res := serve(
+		e.t, e.IssueToken, newRequest(
+			http.MethodPost, "/api/v1/token", map[string]string{"username": user.Username, "password": testPassword},
+		),
+	)
+	expectStatus(e.t, rec, res, http.StatusOK, "")
+	
+	var pair models.TokenPair
+	decodeData(e.t, res, &pair)
+	return pair
+}
+
+func (e *testEnv) bearerUser(token string) (*models.User, error) {
+	return e.AuthenticatedUser(withBearer(httptest.NewRequest(http.MethodGet, "/", nil), token))
+}
+
+func TestIssueToken(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	admin := e.newUser(models.RoleAdmin)
+	twoFactor := e.newUser(models.RoleUser)
+	secret, _ := e.enableTwoFactor(twoFactor)
+	
+	tests := []struct {
+		name            string
+		input           map[string]string
+		requireAdmin2FA bool
+		status          int
+		code            string
+	}{
+		{"password", map[string]string{"username": user.Username, "password": testPassword}, false, http.StatusOK, ""},
+		{"wrong password", map[string]string{"username": user.Username, "password": "salah-salah"}, false, http.StatusUnauthorized, "invalid_credentials"},
+		{"missing password", map[string]string{"username": user.Username}, false, http.StatusUnprocessableEntity, "validation_failed"},
+		{"two-factor without code", map[string]string{"username": twoFactor.Username, "password": testPassword}, false, http.StatusUnauthorized, "two_factor_required"},
+		{
+			"two-factor with wrong code",
+			map[string]string{"username": twoFactor.Username, "password": testPassword, "code": "000000"}, false,
+			http.StatusUnauthorized, "invalid_two_factor_code",
+		},
+		{
+			"two-factor with code",
+			map[string]string{"username": twoFactor.Username, "password": testPassword, "code": totpCode(t, secret, 0)}, false,
+			http.StatusOK, "",
+		},
+		{"admin without required 2FA", map[string]string{"username": admin.Username, "password": testPassword}, true, http.StatusForbidden, "two_factor_setup_required"},
+		{"admin without optional 2FA", map[string]string{"username": admin.Username, "password": testPassword}, false, http.StatusOK, ""},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				config.RequireAdmin2FA = tt.requireAdmin2FA
+				t.Cleanup(func() { config.RequireAdmin2FA = false })
+				
+				rec, res := serve(t, e.IssueToken, newRequest(http.MethodPost, "/api/v1/token", tt.input))
+				expectStatus(t, rec, res, tt.status, tt.code)
+				if tt.status != http.StatusOK {
+					return
+				}
+				
+				var pair models.TokenPair
+				decodeData(t, res, &pair)
+				got, err := e.bearerUser(pair.AccessToken)
+				if err != nil {
+					t.Fatal("access token not usable:", err)
+				}
+				if got.Username != tt.input["username"] {
+					t.Fatalf("token belongs to %q", got.Username)
+				}
+				if rec.Header().Get("Cache-Control") != "no-store" {
+					t.Fatal("token response may be cached")
+				}
+			},
+		)
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	first := e.issueToken(user)
+	inactive := e.newUser(models.RoleUser)
+	stale := e.issueToken(inactive)
+	if err := e.Users.UpdateStatus(context.Background(), inactive.ID, false, inactive.Role); err != nil {
+		t.Fatal(err)
+	}
+	
+	refresh := func(token string) (*httptest.ResponseRecorder, testResponse) {
+		return serve(t, e.RefreshToken, newRequest(http.MethodPost, "/api/v1/token/refresh", map[string]string{"refresh_token": token}))
+	}
+	
+	rec, res := refresh(first.RefreshToken)
+	expectStatus(t, rec, res, http.StatusOK, "")
+	var second models.TokenPair
+	decodeData(t, res, &second)
+	if _, err := e.bearerUser(first.AccessToken); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatal("rotated access token still valid:", err)
+	}
+	if _, err := e.bearerUser(second.AccessToken); err != nil {
+		t.Fatal("new access token not usable:", err)
+	}
+	
+	// Replaying the rotated refresh token revokes the whole family, so the
+	// cases after it find the second pair revoked as well.
+	tests := []struct {
+		name   string
+		token  string
+		status int
+		code   string
+	}{
+		{"replayed", first.RefreshToken, http.StatusUnauthorized, "invalid_refresh_token"},
+		{"revoked family", second.RefreshToken, http.StatusUnauthorized, "invalid_refresh_token"},
+		{"inactive user", stale.RefreshToken, http.StatusUnauthorized, "invalid_refresh_token"},
+		{"unknown", "tidak-dikenal", http.StatusUnauthorized, "invalid_refresh_token"},
+		{"missing", "", http.StatusUnprocessableEntity, "validation_failed"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec, res := refresh(tt.token)
+				expectStatus(t, rec, res, tt.status, tt.code)
+			},
+		)
+	}
+	
+	if _, err := e.bearerUser(second.AccessToken); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatal("token family survived a replayed refresh token:", err)
+	}
+}
+
+func TestBearerAuthentication(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	other := e.newUser(models.RoleUser)
+	pair := e.issueToken(user)
+	cookies, _ := e.login(other)
+	
+	// A bearer token wins over the session cookie, and an invalid one never
+	// falls back to it.
+	tests := []struct {
+		name  string
+		token string
+		want  *models.User
+	}{
+		{"invalid token", "tidak-dikenal", nil},
+		{"valid token", pair.AccessToken, user},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, err := e.AuthenticatedUser(withBearer(withCookies(httptest.NewRequest(http.MethodGet, "/", nil), cookies), tt.token))
+				if tt.want == nil && !errors.Is(err, ErrUnauthenticated) {
+					t.Fatalf("resolved to %v, %v", got, err)
+				}
+				if tt.want != nil && (err != nil || got.ID != tt.want.ID) {
+					t.Fatalf("resolved to %v, %v, want user %d", got, err, tt.want.ID)
+				}
+			},
+		)
+	}
+	
+	rec, res := serve(t, e.Logout, withBearer(newRequest(http.MethodPost, "/api/v1/logout", nil), pair.AccessToken))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	if _, err := e.bearerUser(pair.AccessToken); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatal("access token still valid after logout:", err)
+	}
+}
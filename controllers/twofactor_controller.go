@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	
+	"backend-wisata/config"
+	"backend-wisata/models"
+	"backend-wisata/totp"
+)
+
+const (
+	twoFactorIssuer         = "Wisata"
+	twoFactorPendingSession = "two-factor-pending"
+	twoFactorChallengeTTL   = 5 * time.Minute
+	recoveryCodeCount       = 10
+)
+
+// startTwoFactorChallenge remembers which user passed the password step.
+// The key is pending_user_id rather than user_id so the half-finished login
+// never counts as an active session.
+func (h *Handler) startTwoFactorChallenge(w http.ResponseWriter, r *http.Request, userID int) error {
+	session, err := getSession(h.UserCookies, r, twoFactorPendingSession)
+	if err != nil {
+		return err
+	}
+	session.Values["pending_user_id"] = userID
+	session.Values["expires_at"] = time.Now().Add(twoFactorChallengeTTL).Unix()
+	session.Options.MaxAge = int(twoFactorChallengeTTL.Seconds())
+	return session.Save(r, w)
+}
+
+func generateRecoveryCodes() []string {
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		rand.Read(b)
+		raw := base32.StdEncoding.EncodeToString(b)
+		codes[i] = raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+	}
+	return codes
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(code))
+}
+
+// newRecoveryCodes returns a fresh set of recovery codes to show once,
+// along with the hashes to store.
+func newRecoveryCodes() (codes, hashes []string) {
+	codes = generateRecoveryCodes()
+	for _, code := range codes {
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes
+}
+
+// verifySecondFactor accepts either a current TOTP code that has not been
+// used before or an unused recovery code, which is consumed.
+func (h *Handler) verifySecondFactor(ctx context.Context, userID int, code string) (bool, error) {
+	secret, enabled, err := h.Users.TwoFactor(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	
+	if !enabled || secret == nil {
+		return false, nil
+	}
+	
+	if step, ok := totp.Validate(code, *secret, time.Now()); ok {
+		return h.Users.UseTOTPStep(ctx, userID, step)
+	}
+	
+	return h.Users.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+}
+
+func (h *Handler) VerifyTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Code string `json:"code" validate:"required,max=32"`
+	}
+	
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+	
+	pending, err := getSession(h.UserCookies, r, twoFactorPendingSession)
+	if err != nil {
+		requestLogger(r).Error("load 2fa challenge failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memproses login")
+		return
+	}
+	userID, ok := pending.Values["pending_user_id"].(int)
+	expiresAt, _ := pending.Values["expires_at"].(int64)
+	if !ok || time.Now().Unix() > expiresAt {
+		responseErrorCode(w, http.StatusUnauthorized, "two_factor_expired", "Sesi login kedaluwarsa, silakan login ulang")
+		return
+	}
+	
+	ipKey := ipThrottleKey(config.ClientIP(r))
+	accountKey := accountThrottleKey(userID, "")
+	for _, key := range []string{ipKey, accountKey} {
+		if wait, err := h.lockedFor(r.Context(), key); err != nil {
+			requestLogger(r).Error("check lockout failed", "err", err)
+			responseError(w, http.StatusInternalServerError, "Gagal memproses login")
+			return
+		} else if wait > 0 {
+			responseLocked(w, wait)
+			return
+		}
+	}
+	
+	valid, err := h.verifySecondFactor(r.Context(), userID, input.Code)
+	if err != nil {
+		requestLogger(r).Error("verify 2fa failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memproses login")
+		return
+	}
+	
+	if !valid {
+		h.recordLoginFailure(r.Context(), accountKey, accountFreeAttempts)
+		h.recordLoginFailure(r.Context(), ipKey, ipFreeAttempts)
+		responseErrorCode(w, http.StatusUnauthorized, "invalid_two_factor_code", "Kode autentikasi salah")
+		return
+	}
+	
+	h.clearLoginFailures(r.Context(), accountKey)
+	
+	pending.Options.MaxAge = -1
+	if err := pending.Save(r, w); err != nil {
+		requestLogger(r).Error("end 2fa challenge failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memproses login")
+		return
+	}
+	
+	user, err := h.Users.Get(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusUnauthorized, "Akun tidak ditemukan")
+		return
+	}
+	
+	if !user.IsActive {
+		responseError(w, http.StatusForbidden, "Akun nonaktif")
+		return
+	}
+	
+	h.completeLogin(w, r, user)
+}
+
+func (h *Handler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user := CurrentUser(r)
+	if user.TwoFactor {
+		responseErrorCode(w, http.StatusConflict, "two_factor_enabled", "Autentikasi dua faktor sudah aktif")
+		return
+	}
+	
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		requestLogger(r).Error("generate totp secret failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal membuat secret")
+		return
+	}
+	
+	if err := h.Users.SetTOTPSecret(r.Context(), user.ID, secret); err != nil {
+		requestLogger(r).Error("setup 2fa failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menyiapkan autentikasi dua faktor")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(
+		models.Response{
+			Status:  200,
+			Message: "Pindai QR lalu konfirmasi dengan kode dari aplikasi authenticator",
+			Data: map[string]string{
+				"secret":      secret,
+				"otpauth_uri": totp.ProvisioningURI(secret, twoFactorIssuer, user.Email),
+			},
+		},
+	)
+}
+
+func (h *Handler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Code string `json:"code" validate:"required,max=32"`
+	}
+	
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+	
+	user := CurrentUser(r)
+	if user.TwoFactor {
+		responseErrorCode(w, http.StatusConflict, "two_factor_enabled", "Autentikasi dua faktor sudah aktif")
+		return
+	}
+	
+	secret, _, err := h.Users.TwoFactor(r.Context(), user.ID)
+	if err != nil {
+		requestLogger(r).Error("fetch 2fa secret failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengaktifkan autentikasi dua faktor")
+		return
+	}
+	
+	if secret == nil {
+		responseError(w, http.StatusBadRequest, "Jalankan setup autentikasi dua faktor terlebih dahulu")
+		return
+	}
+	
+	step, ok := totp.Validate(input.Code, *secret, time.Now())
+	if !ok {
+		responseErrorCode(w, http.StatusUnauthorized, "invalid_two_factor_code", "Kode autentikasi salah")
+		return
+	}
+	
+	codes, hashes := newRecoveryCodes()
+	if err := h.Users.EnableTwoFactor(r.Context(), user.ID, step, hashes); err != nil {
+		requestLogger(r).Error("confirm 2fa failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengaktifkan autentikasi dua faktor")
+		return
+	}
+	
+	session, err := h.authenticatedSession(r)
+	if err == nil && session != nil {
+		delete(session.Values, "two_factor_setup_required")
+		err = session.Save(r, w)
+	}
+	if err != nil {
+		requestLogger(r).Error("update session after 2fa failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengaktifkan autentikasi dua faktor")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(
+		models.Response{
+			Status:  200,
+			Message: "Autentikasi dua faktor aktif, simpan kode pemulihan berikut",
+			Data:    map[string][]string{"recovery_codes": codes},
+		},
+	)
+}
+
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Code string `json:"code" validate:"required,max=32"`
+	}
+	
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+	
+	user := CurrentUser(r)
+	
+	valid, err := h.verifySecondFactor(r.Context(), user.ID, input.Code)
+	if err != nil {
+		requestLogger(r).Error("verify 2fa failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal membuat kode pemulihan")
+		return
+	}
+	if !valid {
+		responseErrorCode(w, http.StatusUnauthorized, "invalid_two_factor_code", "Kode autentikasi salah")
+		return
+	}
+	
+	codes, hashes := newRecoveryCodes()
+	if err := h.Users.ReplaceRecoveryCodes(r.Context(), user.ID, hashes); err != nil {
+		requestLogger(r).Error("regenerate recovery codes failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal membuat kode pemulihan")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(
+		models.Response{
+			Status:  200,
+			Message: "Kode pemulihan baru dibuat",
+			Data:    map[string][]string{"recovery_codes": codes},
+		},
+	)
+}
+
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Password string `json:"password" validate:"required"`
+		Code     string `json:"code" validate:"required,max=32"`
+	}
+	
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+	
+	user := CurrentUser(r)
+	if user.IsAdmin() && config.RequireAdmin2FA {
+		responseErrorCode(w, http.StatusForbidden, "two_factor_required", "Autentikasi dua faktor wajib untuk akun admin")
+		return
+	}
+	
+	passwordHash, err := h.Users.PasswordHash(r.Context(), user.ID)
+	if err != nil {
+		requestLogger(r).Error("fetch user failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menonaktifkan autentikasi dua faktor")
+		return
+	}
+	
+	if !checkPasswordHash(input.Password, passwordHash) {
+		responseErrorCode(w, http.StatusUnauthorized, "invalid_credentials", invalidCredentialsMessage)
+		return
+	}
+	
+	valid, err := h.verifySecondFactor(r.Context(), user.ID, input.Code)
+	if err != nil {
+		requestLogger(r).Error("verify 2fa failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menonaktifkan autentikasi dua faktor")
+		return
+	}
+	if !valid {
+		responseErrorCode(w, http.StatusUnauthorized, "invalid_two_factor_code", "Kode autentikasi salah")
+		return
+	}
+	
+	if err := h.Users.DisableTwoFactor(r.Context(), user.ID); err != nil {
+		requestLogger(r).Error("disable 2fa failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menonaktifkan autentikasi dua faktor")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.Response{Status: 200, Message: "Autentikasi dua faktor dinonaktifkan"})
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"backend-wisata/config"
+	"backend-wisata/models"
+)
+
+// startLogin runs the password step for a two-factor user and returns the
+// pending session cookie.
+func (e *testEnv) startLogin(user *models.User) []*http.Cookie {
+	e.t.Helper()
+	rec, res := serve(
+		e.t, e.Login, newRequest(
+			http.MethodPost, "/api/v1/login", map[string]string{"username": user.Username, "password": testPassword},
+		),
+	)
+	expectStatus(e.t, rec, res, http.StatusOK, "two_factor_required")
+	return rec.Result().Cookies()
+}
+
+func TestVerifyTwoFactorLogin(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	secret, recovery := e.enableTwoFactor(user)
+	
+	current := totpCode(t, secret, 0)
+	
+	tests := []struct {
+		name    string
+		pending bool
+		code    string
+		status  int
+		errCode string
+	}{
+		{"totp code", true, current, http.StatusOK, ""},
+		{"reused totp code", true, current, http.StatusUnauthorized, "invalid_two_factor_code"},
+		{"next totp code", true, totpCode(t, secret, 1), http.StatusOK, ""},
+		{"recovery code", true, recovery[0], http.StatusOK, ""},
+		{"reused recovery code", true, recovery[0], http.StatusUnauthorized, "invalid_two_factor_code"},
+		{"recovery code without dashes", true, normalizeRecoveryCode(recovery[1]), http.StatusOK, ""},
+		{"wrong code", true, "000000", http.StatusUnauthorized, "invalid_two_factor_code"},
+		{"no pending login", false, totpCode(t, secret, 1), http.StatusUnauthorized, "two_factor_expired"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var cookies []*http.Cookie
+				if tt.pending {
+					cookies = e.startLogin(user)
+				}
+				
+				r := withCookies(newRequest(http.MethodPost, "/api/v1/login/2fa", map[string]string{"code": tt.code}), cookies)
+				rec, res := serve(t, e.VerifyTwoFactorLogin, r)
+				expectStatus(t, rec, res, tt.status, tt.errCode)
+				
+				session := withCookies(httptest.NewRequest(http.MethodGet, "/", nil), rec.Result().Cookies())
+				got, err := e.AuthenticatedUser(session)
+				if tt.status == http.StatusOK && (err != nil || got.ID != user.ID) {
+					t.Fatalf("no session after the second factor: %v, %v", got, err)
+				}
+				if tt.status != http.StatusOK && !errors.Is(err, ErrUnauthenticated) {
+					t.Fatal("got a session without the second factor:", err)
+				}
+			},
+		)
+	}
+}
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	e := newTestEnv(t)
+	
+	tests := []struct {
+		name            string
+		role            string
+		requireAdmin2FA bool
+		loginCode       string
+	}{
+		{"user", models.RoleUser, false, ""},
+		// Enrolling lifts the restriction of the admin's session.
+		{"admin while required", models.RoleAdmin, true, "two_factor_setup_required"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				config.RequireAdmin2FA = tt.requireAdmin2FA
+				t.Cleanup(func() { config.RequireAdmin2FA = false })
+				
+				user := e.newUser(tt.role)
+				rec, res := serve(
+					t, e.Login, newRequest(
+						http.MethodPost, "/api/v1/login", map[string]string{"username": user.Username, "password": testPassword},
+					),
+				)
+				expectStatus(t, rec, res, http.StatusOK, tt.loginCode)
+				cookies := rec.Result().Cookies()
+				
+				setup := func() (*httptest.ResponseRecorder, testResponse) {
+					return serve(t, e.SetupTwoFactor, asUser(withCookies(newRequest(http.MethodPost, "/api/v1/2fa/setup", nil), cookies), user))
+				}
+				confirm := func(code string) (*httptest.ResponseRecorder, testResponse) {
+					r := withCookies(newRequest(http.MethodPost, "/api/v1/2fa/confirm", map[string]string{"code": code}), cookies)
+					return serve(t, e.ConfirmTwoFactor, asUser(r, user))
+				}
+				
+				rec, res = confirm("123456")
+				expectStatus(t, rec, res, http.StatusBadRequest, "")
+				
+				rec, res = setup()
+				expectStatus(t, rec, res, http.StatusOK, "")
+				var secret struct {
+					Secret     string `json:"secret"`
+					OTPAuthURI string `json:"otpauth_uri"`
+				}
+				decodeData(t, res, &secret)
+				if secret.Secret == "" || secret.OTPAuthURI == "" {
+					t.Fatalf("setup returned %s", res.Data)
+				}
+				
+				rec, res = confirm("000000")
+				expectStatus(t, rec, res, http.StatusUnauthorized, "invalid_two_factor_code")
+				
+				rec, res = confirm(totpCode(t, secret.Secret, 0))
+				expectStatus(t, rec, res, http.StatusOK, "")
+				var codes struct {
+					RecoveryCodes []string `json:"recovery_codes"`
+				}
+				decodeData(t, res, &codes)
+				if len(codes.RecoveryCodes) != recoveryCodeCount {
+					t.Fatalf("%d recovery codes, want %d", len(codes.RecoveryCodes), recoveryCodeCount)
+				}
+				
+				pending, err := e.TwoFactorSetupPending(withCookies(httptest.NewRequest(http.MethodGet, "/", nil), rec.Result().Cookies()))
+				if err != nil || pending {
+					t.Fatalf("session still restricted after enrolling: %v", err)
+				}
+				
+				user = e.getUser(user.ID)
+				if !user.TwoFactor {
+					t.Fatal("two-factor not enabled after confirming")
+				}
+				
+				rec, res = setup()
+				expectStatus(t, rec, res, http.StatusConflict, "two_factor_enabled")
+				
+				// The code used to confirm counts as used.
+				r := withCookies(newRequest(http.MethodPost, "/api/v1/login/2fa", map[string]string{"code": totpCode(t, secret.Secret, 0)}), e.startLogin(user))
+				rec, res = serve(t, e.VerifyTwoFactorLogin, r)
+				expectStatus(t, rec, res, http.StatusUnauthorized, "invalid_two_factor_code")
+			},
+		)
+	}
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	secret, old := e.enableTwoFactor(user)
+	user = e.getUser(user.ID)
+	
+	regenerate := func(code string) (*httptest.ResponseRecorder, testResponse) {
+		return serve(t, e.RegenerateRecoveryCodes, asUser(newRequest(http.MethodPost, "/api/v1/2fa/recovery-codes", map[string]string{"code": code}), user))
+	}
+	
+	rec, res := regenerate("000000")
+	expectStatus(t, rec, res, http.StatusUnauthorized, "invalid_two_factor_code")
+	
+	rec, res = regenerate(totpCode(t, secret, 0))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	var codes struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	decodeData(t, res, &codes)
+	
+	ctx := context.Background()
+	if ok, err := e.verifySecondFactor(ctx, user.ID, old[0]); err != nil || ok {
+		t.Fatalf("old recovery code still accepted: %v, %v", ok, err)
+	}
+	if ok, err := e.verifySecondFactor(ctx, user.ID, codes.RecoveryCodes[0]); err != nil || !ok {
+		t.Fatalf("new recovery code rejected: %v, %v", ok, err)
+	}
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	e := newTestEnv(t)
+	user := e.newUser(models.RoleUser)
+	secret, _ := e.enableTwoFactor(user)
+	user = e.getUser(user.ID)
+	admin := e.newUser(models.RoleAdmin)
+	adminSecret, _ := e.enableTwoFactor(admin)
+	admin = e.getUser(admin.ID)
+	
+	tests := []struct {
+		name            string
+		as              *models.User
+		requireAdmin2FA bool
+		password        string
+		code            string
+		status          int
+		errCode         string
+	}{
+		{"wrong password", user, false, "salah-salah", totpCode(t, secret, 0), http.StatusUnauthorized, "invalid_credentials"},
+		{"wrong code", user, false, testPassword, "000000", http.StatusUnauthorized, "invalid_two_factor_code"},
+		{"missing code", user, false, testPassword, "", http.StatusUnprocessableEntity, "validation_failed"},
+		{"admin while required", admin, true, testPassword, totpCode(t, adminSecret, 0), http.StatusForbidden, "two_factor_required"},
+		{"valid", user, false, testPassword, totpCode(t, secret, 0), http.StatusOK, ""},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				config.RequireAdmin2FA = tt.requireAdmin2FA
+				t.Cleanup(func() { config.RequireAdmin2FA = false })
+				
+				input := map[string]string{"password": tt.password, "code": tt.code}
+				rec, res := serve(t, e.DisableTwoFactor, asUser(newRequest(http.MethodPost, "/api/v1/2fa/disable", input), tt.as))
+				expectStatus(t, rec, res, tt.status, tt.errCode)
+			},
+		)
+	}
+	
+	if e.getUser(user.ID).TwoFactor {
+		t.Fatal("two-factor still enabled")
+	}
+	if !e.getUser(admin.ID).TwoFactor {
+		t.Fatal("two-factor disabled although it is required")
+	}
+}
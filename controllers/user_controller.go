@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"os"
@@ -11,36 +12,18 @@ import (
 	
 	"backend-wisata/config"
 	"backend-wisata/models"
+	"backend-wisata/store"
 )
 
-func GetAllUsers(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	// HAPUS enableCors dan OPTIONS check
 	
-	query := `
-		SELECT id, full_name, email, phone, role, is_active, created_at
-		FROM users
-		WHERE deleted_at IS NULL
-		ORDER BY created_at DESC
-	`
-	
-	rows, err := config.DB.Query(query)
+	users, err := h.Users.List(r.Context())
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(models.Response{Status: 500, Message: err.Error()})
+		requestLogger(r).Error("fetch users failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil data user")
 		return
 	}
-	defer rows.Close()
-	
-	var users []models.User
-	
-	for rows.Next() {
-		var u models.User
-		if err := rows.Scan(&u.ID, &u.FullName, &u.Email, &u.Phone, &u.Role, &u.IsActive, &u.CreatedAt); err != nil {
-			continue
-		}
-		users = append(users, u)
-	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(
@@ -52,99 +35,147 @@ func GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func UpdateUserStatus(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateUserStatus(w http.ResponseWriter, r *http.Request) {
 	// HAPUS enableCors dan OPTIONS check
 	
-	if r.Method != "PUT" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
-	idStr := r.URL.Query().Get("id")
+	idStr := pathParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	
 	var input struct {
 		IsActive bool   `json:"is_active"`
-		Role     string `json:"role"`
+		Role     string `json:"role" validate:"required,oneof=user admin superadmin partner"`
 	}
 	
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, "Invalid body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &input) {
 		return
 	}
 	
-	query := "UPDATE users SET is_active=$1, role=$2, updated_at=NOW() WHERE id=$3"
-	_, err := config.DB.Exec(query, input.IsActive, input.Role, id)
+	user, err := h.Users.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		responseError(w, http.StatusNotFound, "User tidak ditemukan")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("fetch user failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengupdate user")
+		return
+	}
 	
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// Admin rights are only granted or taken away by a superadmin, and
+	// nobody changes their own role.
+	if input.Role != user.Role {
+		caller := CurrentUser(r)
+		if caller.ID == user.ID {
+			responseErrorCode(w, http.StatusForbidden, "own_role", "Tidak bisa mengubah role akun sendiri")
+			return
+		}
+		if (user.IsAdmin() || input.Role == models.RoleAdmin || input.Role == models.RoleSuperadmin) && caller.Role != models.RoleSuperadmin {
+			responseErrorCode(w, http.StatusForbidden, "superadmin_required", "Hanya superadmin yang bisa memberi atau mencabut role admin")
+			return
+		}
+	}
+	
+	err = h.Users.UpdateStatus(r.Context(), id, input.IsActive, input.Role)
+	if errors.Is(err, store.ErrNotFound) {
+		responseError(w, http.StatusNotFound, "User tidak ditemukan")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("update user failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengupdate user")
 		return
 	}
 	
+	// API keys act with the role of their owner, so they end with it.
+	if input.Role != user.Role {
+		if err := h.APIKeys.RevokeUser(r.Context(), id); err != nil {
+			requestLogger(r).Error("revoke api keys failed", "err", err)
+			responseError(w, http.StatusInternalServerError, "Gagal mencabut API key user")
+			return
+		}
+	}
+	
+	if !input.IsActive {
+		if err := h.Sessions.RevokeUser(r.Context(), id); err != nil {
+			requestLogger(r).Error("revoke sessions failed", "err", err)
+			responseError(w, http.StatusInternalServerError, "User dinonaktifkan, tetapi sesinya gagal dicabut, silakan coba lagi")
+			return
+		}
+	}
+	
 	json.NewEncoder(w).Encode(models.Response{Status: 200, Message: "User Updated"})
 }
 
-func DeleteUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	// HAPUS enableCors dan OPTIONS check
 	
-	if r.Method != "DELETE" && r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	idStr := pathParam(r, "id")
+	id, _ := strconv.Atoi(idStr)
+	
+	if _, err := h.Users.Get(r.Context(), id); errors.Is(err, store.ErrNotFound) {
+		responseError(w, http.StatusNotFound, "User tidak ditemukan")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("fetch user failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menghapus user")
 		return
 	}
 	
-	idStr := r.URL.Query().Get("id")
-	id, _ := strconv.Atoi(idStr)
-	
-	query := "UPDATE users SET deleted_at=NOW() WHERE id=$1"
-	_, err := config.DB.Exec(query, id)
+	// Sessions go first: a failed revocation leaves the user in place, so the
+	// request can simply be retried.
+	if err := h.Sessions.RevokeUser(r.Context(), id); err != nil {
+		requestLogger(r).Error("revoke sessions failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mencabut sesi user")
+		return
+	}
 	
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	err := h.Users.Delete(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		responseError(w, http.StatusNotFound, "User tidak ditemukan")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("delete user failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menghapus user")
 		return
 	}
 	
 	json.NewEncoder(w).Encode(models.Response{Status: 200, Message: "User Deleted"})
 }
 
-func GetProfile(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	// HAPUS enableCors dan OPTIONS check
 	
 	w.Header().Set("Content-Type", "application/json")
 	
-	userIDStr := r.URL.Query().Get("user_id")
-	userID, _ := strconv.Atoi(userIDStr)
-	
-	var user models.User
-	query := "SELECT id, username, email, full_name, phone, profile_image FROM users WHERE id = $1"
+	requestedID, _ := strconv.Atoi(r.URL.Query().Get("user_id"))
+	userID := actingUserID(r, requestedID)
 	
-	err := config.DB.QueryRow(query, userID).Scan(
-		&user.ID, &user.Username, &user.Email, &user.FullName, &user.Phone, &user.ProfileImage,
-	)
-	
-	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(models.Response{Status: 404, Message: "User not found"})
+	user, err := h.Users.Get(r.Context(), userID)
+	if errors.Is(err, store.ErrNotFound) {
+		responseError(w, http.StatusNotFound, "User tidak ditemukan")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("fetch profile failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil profil")
 		return
 	}
 	
 	json.NewEncoder(w).Encode(models.Response{Status: 200, Data: user})
 }
 
-func UpdateProfile(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	// HAPUS enableCors dan OPTIONS check
 	
 	w.Header().Set("Content-Type", "application/json")
 	
-	err := r.ParseMultipartForm(10 << 20)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(models.Response{Status: 400, Message: "File too big or invalid form"})
+	var input struct {
+		UserID   int    `form:"user_id"`
+		FullName string `form:"full_name" validate:"required,max=100"`
+		Phone    string `form:"phone" validate:"phone"`
+	}
+	if !decodeForm(w, r, &input) {
 		return
 	}
 	
-	userIDStr := r.FormValue("user_id")
-	fullName := r.FormValue("full_name")
-	phone := r.FormValue("phone")
+	userID := actingUserID(r, input.UserID)
 	
 	file, handler, err := r.FormFile("profile_image")
 	var imagePath string
@@ -152,53 +183,32 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	if err == nil {
 		defer file.Close()
 		
-		os.MkdirAll("./uploads/profiles", os.ModePerm)
+		profileDir := filepath.Join(config.App.Uploads.Dir, "profiles")
+		os.MkdirAll(profileDir, os.ModePerm)
 		
-		filename := "user_" + userIDStr + "_" + strconv.FormatInt(time.Now().Unix(), 10) + filepath.Ext(handler.Filename)
-		imagePath = "/uploads/profiles/" + filename
+		filename := "user_" + strconv.Itoa(userID) + "_" + strconv.FormatInt(time.Now().Unix(), 10) + filepath.Ext(handler.Filename)
+		imagePath = config.App.UploadURL("profiles/" + filename)
 		
-		dst, err := os.Create("." + imagePath)
+		dst, err := os.Create(filepath.Join(profileDir, filename))
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(models.Response{Status: 500, Message: "Gagal menyimpan gambar"})
+			requestLogger(r).Error("save profile image failed", "err", err)
+			responseError(w, http.StatusInternalServerError, "Gagal menyimpan gambar")
 			return
 		}
 		defer dst.Close()
 		io.Copy(dst, file)
 	}
 	
-	var query string
-	var args []interface{}
-	
-	if imagePath != "" {
-		query = "UPDATE users SET full_name=$1, phone=$2, profile_image=$3, updated_at=NOW() WHERE id=$4"
-		args = []interface{}{fullName, phone, imagePath, userIDStr}
-	} else {
-		query = "UPDATE users SET full_name=$1, phone=$2, updated_at=NOW() WHERE id=$3"
-		args = []interface{}{fullName, phone, userIDStr}
-	}
-	
-	_, err = config.DB.Exec(query, args...)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(models.Response{Status: 500, Message: err.Error()})
+	if err := h.Users.UpdateProfile(r.Context(), userID, input.FullName, input.Phone, imagePath); err != nil {
+		requestLogger(r).Error("update profile failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengupdate profil")
 		return
 	}
 	
-	var updatedUser models.User
-	fetchQuery := "SELECT id, username, email, full_name, phone, profile_image FROM users WHERE id = $1"
-	err = config.DB.QueryRow(fetchQuery, userIDStr).Scan(
-		&updatedUser.ID,
-		&updatedUser.Username,
-		&updatedUser.Email,
-		&updatedUser.FullName,
-		&updatedUser.Phone,
-		&updatedUser.ProfileImage,
-	)
-	
+	updatedUser, err := h.Users.Get(r.Context(), userID)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(models.Response{Status: 500, Message: "Gagal mengambil data terbaru"})
+		requestLogger(r).Error("fetch profile failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil data terbaru")
 		return
 	}
 	
@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	
+	"backend-wisata/models"
+	"backend-wisata/store"
+)
+
+func TestGetAllUsers(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	e.newUser(models.RoleUser)
+	e.newUser(models.RolePartner)
+	
+	rec, res := serve(t, e.GetAllUsers, asUser(newRequest(http.MethodGet, "/api/v1/users", nil), admin))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	
+	var list []models.User
+	decodeData(t, res, &list)
+	if len(list) != 3 {
+		t.Fatalf("%d users, want 3", len(list))
+	}
+	for _, u := range list {
+		if u.Password != "" {
+			t.Fatalf("password hash of %s listed", u.Username)
+		}
+	}
+}
+
+func TestUpdateUserStatus(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	superadmin := e.newUser(models.RoleSuperadmin)
+	
+	tests := []struct {
+		name    string
+		caller  *models.User
+		target  string // role of the updated user; empty updates the caller
+		input   map[string]any
+		status  int
+		code    string
+		revoked bool
+	}{
+		{"deactivate", admin, models.RoleUser, map[string]any{"is_active": false, "role": models.RoleUser}, http.StatusOK, "", true},
+		{"make partner", admin, models.RoleUser, map[string]any{"is_active": true, "role": models.RolePartner}, http.StatusOK, "", false},
+		{"admin promotes to admin", admin, models.RoleUser, map[string]any{"is_active": true, "role": models.RoleAdmin}, http.StatusForbidden, "superadmin_required", false},
+		{"admin promotes to superadmin", admin, models.RoleUser, map[string]any{"is_active": true, "role": models.RoleSuperadmin}, http.StatusForbidden, "superadmin_required", false},
+		{"admin demotes admin", admin, models.RoleAdmin, map[string]any{"is_active": true, "role": models.RoleUser}, http.StatusForbidden, "superadmin_required", false},
+		{"admin deactivates admin", admin, models.RoleAdmin, map[string]any{"is_active": false, "role": models.RoleAdmin}, http.StatusOK, "", true},
+		{"superadmin promotes to admin", superadmin, models.RoleUser, map[string]any{"is_active": true, "role": models.RoleAdmin}, http.StatusOK, "", false},
+		{"superadmin demotes admin", superadmin, models.RoleAdmin, map[string]any{"is_active": true, "role": models.RoleUser}, http.StatusOK, "", false},
+		{"admin promotes self", admin, "", map[string]any{"is_active": true, "role": models.RoleSuperadmin}, http.StatusForbidden, "own_role", false},
+		{"superadmin demotes self", superadmin, "", map[string]any{"is_active": true, "role": models.RoleAdmin}, http.StatusForbidden, "own_role", false},
+		{"unknown role", admin, models.RoleUser, map[string]any{"is_active": true, "role": "root"}, http.StatusUnprocessableEntity, "", false},
+		{"missing role", admin, models.RoleUser, map[string]any{"is_active": false}, http.StatusUnprocessableEntity, "", false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				user := tt.caller
+				if tt.target != "" {
+					user = e.newUser(tt.target)
+				}
+				pair := e.issueToken(user)
+				cookies, _ := e.login(user)
+				id := strconv.Itoa(user.ID)
+				
+				r := withPath(newRequest(http.MethodPut, "/api/v1/users/"+id, tt.input), "id", id)
+				rec, res := serve(t, e.UpdateUserStatus, asUser(r, tt.caller))
+				expectStatus(t, rec, res, tt.status, tt.code)
+				
+				got := e.getUser(user.ID)
+				if tt.status == http.StatusOK && (got.IsActive != tt.input["is_active"] || got.Role != tt.input["role"]) {
+					t.Fatalf("stored active %v, role %s", got.IsActive, got.Role)
+				}
+				if tt.status != http.StatusOK && (got.IsActive != user.IsActive || got.Role != user.Role) {
+					t.Fatalf("rejected update stored active %v, role %s", got.IsActive, got.Role)
+				}
+				
+				// Turn the account back on, so only revocation keeps the token out.
+				if err := e.Users.UpdateStatus(context.Background(), user.ID, true, got.Role); err != nil {
+					t.Fatal(err)
+				}
+				_, err := e.bearerUser(pair.AccessToken)
+				if revoked := errors.Is(err, ErrUnauthenticated); revoked != tt.revoked {
+					t.Fatalf("token revoked = %v, want %v (err %v)", revoked, tt.revoked, err)
+				}
+				// Only the session table can revoke cookie sessions.
+				if status := e.sessionStatus(cookies); e.DB != nil && (status == http.StatusUnauthorized) != tt.revoked {
+					t.Fatalf("session got %d, want revoked = %v", status, tt.revoked)
+				}
+			},
+		)
+	}
+	
+	for _, id := range []string{"9999", "abc"} {
+		r := withPath(newRequest(http.MethodPut, "/api/v1/users/"+id, map[string]any{"is_active": true, "role": models.RoleUser}), "id", id)
+		rec, res := serve(t, e.UpdateUserStatus, asUser(r, superadmin))
+		expectStatus(t, rec, res, http.StatusNotFound, "")
+	}
+}
+
+// revokeFailure is a SessionStore whose RevokeUser always fails.
+type revokeFailure struct {
+	store.SessionStore
+}
+
+func (revokeFailure) RevokeUser(ctx context.Context, userID int) error {
+	return errors.New("session store down")
+}
+
+// A user whose sessions could not be revoked is not reported as
+// deactivated or deleted.
+func TestUserRevocationFailure(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	e.Sessions = revokeFailure{e.Sessions}
+	
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		input   map[string]any
+	}{
+		{"deactivate", e.UpdateUserStatus, http.MethodPut, map[string]any{"is_active": false, "role": models.RoleUser}},
+		{"delete", e.DeleteUser, http.MethodDelete, nil},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				user := e.newUser(models.RoleUser)
+				id := strconv.Itoa(user.ID)
+				
+				r := withPath(newRequest(tt.method, "/api/v1/users/"+id, tt.input), "id", id)
+				rec, res := serve(t, tt.handler, asUser(r, admin))
+				expectStatus(t, rec, res, http.StatusInternalServerError, "")
+				
+				if _, err := e.Users.Get(context.Background(), user.ID); err != nil {
+					t.Fatal("user deleted although the sessions were not revoked:", err)
+				}
+			},
+		)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	user := e.newUser(models.RoleUser)
+	pair := e.issueToken(user)
+	id := strconv.Itoa(user.ID)
+	
+	tests := []struct {
+		name   string
+		id     string
+		status int
+	}{
+		{"existing", id, http.StatusOK},
+		{"already deleted", id, http.StatusNotFound},
+		{"unknown", "9999", http.StatusNotFound},
+		{"not a number", "abc", http.StatusNotFound},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := withPath(newRequest(http.MethodDelete, "/api/v1/users/"+tt.id, nil), "id", tt.id)
+				rec, res := serve(t, e.DeleteUser, asUser(r, admin))
+				expectStatus(t, rec, res, tt.status, "")
+			},
+		)
+	}
+	
+	if _, err := e.Users.Get(context.Background(), user.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Fatal("user not deleted:", err)
+	}
+	if _, err := e.bearerUser(pair.AccessToken); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatal("token of deleted user still works:", err)
+	}
+}
+
+func TestGetProfile(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	user := e.newUser(models.RoleUser)
+	other := e.newUser(models.RoleUser)
+	
+	tests := []struct {
+		name   string
+		caller *models.User
+		query  string
+		status int
+		want   *models.User
+	}{
+		{"own profile", user, "", http.StatusOK, user},
+		{"user asking for another", user, "?user_id=" + strconv.Itoa(other.ID), http.StatusOK, user},
+		{"admin asking for another", admin, "?user_id=" + strconv.Itoa(other.ID), http.StatusOK, other},
+		{"admin asking for unknown", admin, "?user_id=9999", http.StatusNotFound, nil},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec, res := serve(t, e.GetProfile, asUser(newRequest(http.MethodGet, "/api/v1/profile"+tt.query, nil), tt.caller))
+				expectStatus(t, rec, res, tt.status, "")
+				if tt.want == nil {
+					return
+				}
+				
+				var got models.User
+				decodeData(t, res, &got)
+				if got.ID != tt.want.ID {
+					t.Fatalf("profile of user %d, want %d", got.ID, tt.want.ID)
+				}
+				// Without an upload the image is null, not an empty URL.
+				if got.ProfileImage != nil {
+					t.Fatalf("profile image %q, want null", *got.ProfileImage)
+				}
+			},
+		)
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	user := e.newUser(models.RoleUser)
+	other := e.newUser(models.RoleUser)
+	
+	tests := []struct {
+		name   string
+		caller *models.User
+		input  map[string]string
+		status int
+		field  string
+		want   *models.User
+	}{
+		{"own profile", user, map[string]string{"full_name": "Nama Baru", "phone": "0812-3456-7890"}, http.StatusOK, "", user},
+		{"with image", user, map[string]string{"full_name": "Nama Baru", "file:profile_image": "jpeg"}, http.StatusOK, "", user},
+		{"user naming another", user, map[string]string{"full_name": "Nama Baru", "user_id": strconv.Itoa(other.ID)}, http.StatusOK, "", user},
+		{"admin naming another", admin, map[string]string{"full_name": "Nama Baru", "user_id": strconv.Itoa(other.ID)}, http.StatusOK, "", other},
+		{"invalid phone", user, map[string]string{"full_name": "Nama Baru", "phone": "12ab"}, http.StatusUnprocessableEntity, "phone", nil},
+		{"missing name", user, map[string]string{"phone": "081234567890"}, http.StatusUnprocessableEntity, "full_name", nil},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := newFormRequest(http.MethodPut, "/api/v1/profile", tt.input)
+				rec, res := serve(t, e.UpdateProfile, asUser(r, tt.caller))
+				expectStatus(t, rec, res, tt.status, "")
+				if tt.field != "" {
+					if len(res.Details[tt.field]) == 0 {
+						t.Fatalf("no error for field %s: %v", tt.field, res.Details)
+					}
+					return
+				}
+				
+				var got models.User
+				decodeData(t, res, &got)
+				if got.ID != tt.want.ID || got.FullName != tt.input["full_name"] {
+					t.Fatalf("updated user %d to %q, want %d", got.ID, got.FullName, tt.want.ID)
+				}
+				if _, hasImage := tt.input["file:profile_image"]; hasImage && got.ProfileImage == nil {
+					t.Fatal("profile image not stored")
+				}
+			},
+		)
+	}
+}
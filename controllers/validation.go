@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	
+	"backend-wisata/models"
+	"backend-wisata/validate"
+)
+
+// decodeJSON reads the JSON body into dst and checks its validate tags. On
+// failure it writes the error response and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			responseValidation(w, validate.Errors{typeErr.Field: {"tipe data tidak sesuai"}})
+			return false
+		}
+		responseError(w, http.StatusBadRequest, "Invalid JSON body")
+		return false
+	}
+	
+	if errs := validate.Struct(dst); errs != nil {
+		responseValidation(w, errs)
+		return false
+	}
+	return true
+}
+
+// decodeForm parses the multipart form, with files up to 10 MB, into dst
+// and checks its validate tags. Uploaded files are read with r.FormFile
+// afterwards. On failure it writes the error response and returns false.
+func decodeForm(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		responseError(w, http.StatusBadRequest, "File too big or invalid form")
+		return false
+	}
+	
+	if errs := validate.Form(r.Form, dst); errs != nil {
+		responseValidation(w, errs)
+		return false
+	}
+	return true
+}
+
+// responseValidation rejects the request with 422 and every broken rule per
+// field.
+func responseValidation(w http.ResponseWriter, errs validate.Errors) {
+	writeError(
+		w, models.Response{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    "validation_failed",
+			Message: "Data tidak valid",
+			Details: errs,
+		},
+	)
+}
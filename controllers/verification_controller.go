@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	
+	"backend-wisata/config"
+	"backend-wisata/logger"
+	"backend-wisata/mailer"
+	"backend-wisata/models"
+	"backend-wisata/store"
+)
+
+const emailVerificationTTL = 48 * time.Hour
+
+var errInvalidVerification = errors.New("invalid verification token")
+
+// signVerification builds a stateless token "<payload>.<mac>" where the
+// payload is "<user id>|<email>|<unix expiry>". Binding the email means the
+// link stops working if the address changes.
+func signVerification(userID int, email string, expires time.Time) string {
+	payload := strconv.Itoa(userID) + "|" + email + "|" + strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, config.EmailVerifyKey)
+	mac.Write([]byte(payload))
+	
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func parseVerification(token string) (int, string, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", errInvalidVerification
+	}
+	
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return 0, "", errInvalidVerification
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return 0, "", errInvalidVerification
+	}
+	
+	mac := hmac.New(sha256.New, config.EmailVerifyKey)
+	mac.Write(payload)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return 0, "", errInvalidVerification
+	}
+	
+	// The email sits in the middle because it may itself contain "|".
+	idPart, rest, _ := strings.Cut(string(payload), "|")
+	sep := strings.LastIndex(rest, "|")
+	if sep < 0 {
+		return 0, "", errInvalidVerification
+	}
+	
+	userID, err := strconv.Atoi(idPart)
+	if err != nil {
+		return 0, "", errInvalidVerification
+	}
+	expires, err := strconv.ParseInt(rest[sep+1:], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return 0, "", errInvalidVerification
+	}
+	
+	return userID, rest[:sep], nil
+}
+
+func sendVerificationEmail(ctx context.Context, userID int, email, fullName string) {
+	token := signVerification(userID, email, time.Now().Add(emailVerificationTTL))
+	link := config.AppURL + "/verify-email?token=" + url.QueryEscape(token)
+	
+	msg := mailer.Message{
+		To:      email,
+		Subject: "Verifikasi email akun Anda",
+		Body: "Halo " + fullName + ",\n\n" +
+			"Terima kasih sudah mendaftar. Buka tautan berikut dalam 48 jam untuk memverifikasi email Anda:\n\n" +
+			link,
+	}
+	
+	goBackground(func() {
+		if err := config.Mailer.Send(msg); err != nil {
+			logger.FromContext(ctx).Error("send verification email failed", "err", err)
+		}
+	})
+}
+
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	userID, email, err := parseVerification(r.URL.Query().Get("token"))
+	if err != nil {
+		responseErrorCode(w, http.StatusBadRequest, "invalid_token", "Tautan verifikasi tidak valid atau sudah kedaluwarsa")
+		return
+	}
+	
+	err = h.Users.VerifyEmail(r.Context(), userID, email)
+	if errors.Is(err, store.ErrNotFound) {
+		responseErrorCode(w, http.StatusBadRequest, "invalid_token", "Tautan verifikasi tidak valid atau sudah kedaluwarsa")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("verify email failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memverifikasi email")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.Response{Status: 200, Message: "Email berhasil diverifikasi"})
+}
+
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+	
+	user, err := h.Users.FindByEmail(r.Context(), input.Email)
+	if err == nil && user.EmailVerifiedAt == nil {
+		sendVerificationEmail(r.Context(), user.ID, input.Email, user.FullName)
+	} else if err != nil && !errors.Is(err, store.ErrNotFound) {
+		requestLogger(r).Error("fetch user failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal memproses permintaan")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(
+		models.Response{
+			Status:  200,
+			Message: "Jika email terdaftar dan belum diverifikasi, tautan verifikasi telah dikirim",
+		},
+	)
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	e := newTestEnv(t)
+	user := &models.User{
+		Username: "belum",
+		Email:    "belum@example.test",
+		Password: testPasswordHash,
+		FullName: "Belum Verifikasi",
+		Role:     models.RoleUser,
+		IsActive: true,
+	}
+	if err := e.Users.Create(t.Context(), user); err != nil {
+		t.Fatal(err)
+	}
+	
+	valid := signVerification(user.ID, user.Email, time.Now().Add(time.Hour))
+	
+	tests := []struct {
+		name   string
+		token  string
+		status int
+		code   string
+	}{
+		{"tampered", valid[:len(valid)-2] + "AA", http.StatusBadRequest, "invalid_token"},
+		{"expired", signVerification(user.ID, user.Email, time.Now().Add(-time.Minute)), http.StatusBadRequest, "invalid_token"},
+		{"old email", signVerification(user.ID, "lama@example.test", time.Now().Add(time.Hour)), http.StatusBadRequest, "invalid_token"},
+		{"malformed", "bukan-token", http.StatusBadRequest, "invalid_token"},
+		{"valid", valid, http.StatusOK, ""},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := newRequest(http.MethodGet, "/api/v1/email/verify?token="+url.QueryEscape(tt.token), nil)
+				rec, res := serve(t, e.VerifyEmail, r)
+				expectStatus(t, rec, res, tt.status, tt.code)
+			},
+		)
+	}
+	
+	input := map[string]string{"username": user.Username, "password": testPassword}
+	rec, res := serve(t, e.Login, newRequest(http.MethodPost, "/api/v1/login", input))
+	expectStatus(t, rec, res, http.StatusOK, "")
+}
+
+func TestResendVerification(t *testing.T) {
+	e := newTestEnv(t)
+	verified := e.newUser(models.RoleUser)
+	
+	rec, res := serve(
+		t, e.Register, newRequest(
+			http.MethodPost, "/api/v1/register", map[string]string{
+				"username":  "sari",
+				"email":     "sari@example.test",
+				"password":  testPassword,
+				"full_name": "Sari",
+			},
+		),
+	)
+	expectStatus(t, rec, res, http.StatusCreated, "")
+	outbox.reset(t)
+	
+	tests := []struct {
+		name  string
+		email string
+		sent  int
+	}{
+		{"unverified", "sari@example.test", 1},
+		{"already verified", verified.Email, 0},
+		{"unknown email", "tidak-ada@example.test", 0},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec, res := serve(t, e.ResendVerification, newRequest(http.MethodPost, "/api/v1/email/resend", map[string]string{"email": tt.email}))
+				expectStatus(t, rec, res, http.StatusOK, "")
+				
+				sent := outbox.messages(t, tt.email)
+				if len(sent) != tt.sent {
+					t.Fatalf("%d verification emails sent, want %d", len(sent), tt.sent)
+				}
+				if tt.sent == 0 {
+					return
+				}
+				
+				r := newRequest(http.MethodGet, "/api/v1/email/verify?token="+url.QueryEscape(linkToken(t, sent[0])), nil)
+				rec, res = serve(t, e.VerifyEmail, r)
+				expectStatus(t, rec, res, http.StatusOK, "")
+			},
+		)
+	}
+}
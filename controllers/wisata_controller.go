@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"os"
@@ -11,12 +12,13 @@ import (
 	
 	"backend-wisata/config"
 	"backend-wisata/models"
+	"backend-wisata/store"
 )
 
 func saveUploadedFile(file io.Reader, filename string) (string, error) {
-	uploadDir := "./uploads"
+	uploadDir := config.App.Uploads.Dir
 	if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-		os.Mkdir(uploadDir, 0755)
+		os.MkdirAll(uploadDir, 0755)
 	}
 	
 	uniqueName := strconv.FormatInt(time.Now().UnixNano(), 10) + filepath.Ext(filename)
@@ -33,63 +35,25 @@ func saveUploadedFile(file io.Reader, filename string) (string, error) {
 		return "", err
 	}
 	
-	return "/uploads/" + uniqueName, nil
+	return config.App.UploadURL(uniqueName), nil
 }
 
-func GetAllWisata(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAllWisata(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	
-	searchQuery := r.URL.Query().Get("q")
-	
-	query := `
-		SELECT
-			w.id, w.uuid, w.nama_tempat, w.slug, w.lokasi,
-			w.harga_tiket, w.rating_total, w.category_id,
-			COALESCE((SELECT image_url FROM wisata_images WHERE wisata_id = w.id AND is_primary = true LIMIT 1), '') as image_url,
-			c.name as category_name
-		FROM wisata w
-		JOIN categories c ON w.category_id = c.id
-		WHERE w.deleted_at IS NULL
-	`
-	
-	var args []interface{}
-	if searchQuery != "" {
-		query += " AND w.nama_tempat ILIKE '%' || $1 || '%'"
-		args = append(args, searchQuery)
-	}
-	
-	query += " ORDER BY w.created_at DESC"
-	
-	rows, err := config.DB.Query(query, args...)
+	listWisata, err := h.Wisata.List(r.Context(), r.URL.Query().Get("q"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		requestLogger(r).Error("fetch wisata failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil data wisata")
 		return
 	}
-	defer rows.Close()
-	
-	var listWisata []models.Wisata
-	scheme := "http"
-	if r.TLS != nil {
-		scheme = "https"
-	}
-	baseURL := scheme + "://" + r.Host
 	
-	for rows.Next() {
-		var w models.Wisata
-		if err := rows.Scan(
-			&w.ID, &w.UUID, &w.NamaTempat, &w.Slug, &w.Lokasi,
-			&w.HargaTiket, &w.RatingTotal, &w.CategoryID, &w.ImageURL,
-			&w.CategoryName,
-		); err != nil {
-			continue
+	baseURL := config.App.Server.PublicURL
+	for i := range listWisata {
+		if listWisata[i].ImageURL != "" {
+			listWisata[i].ImageURL = baseURL + listWisata[i].ImageURL
 		}
-		
-		if w.ImageURL != "" {
-			w.ImageURL = baseURL + w.ImageURL
-		}
-		
-		listWisata = append(listWisata, w)
 	}
 	
 	json.NewEncoder(w).Encode(
@@ -101,43 +65,27 @@ func GetAllWisata(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func GetWisataDetail(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetWisataDetail(w http.ResponseWriter, r *http.Request) {
 	
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		http.Error(w, "ID required", http.StatusBadRequest)
+	idStr := pathParam(r, "id")
+	if idStr == "" {
+		responseFieldErrors(w, http.StatusBadRequest, "ID wajib diisi", map[string][]string{"id": {"ID wajib diisi"}})
 		return
 	}
+	id, _ := strconv.Atoi(idStr)
 	
-	query := `
-		SELECT
-			w.id, w.uuid, w.nama_tempat, w.deskripsi, w.fasilitas,
-			w.harga_tiket, w.lokasi, w.category_id,
-			c.name as category_name,
-			COALESCE((SELECT image_url FROM wisata_images WHERE wisata_id = w.id AND is_primary = true LIMIT 1), '') as image_url
-		FROM wisata w
-		JOIN categories c ON w.category_id = c.id
-		WHERE w.id = $1 AND w.deleted_at IS NULL
-	`
-	
-	var data models.Wisata
-	err := config.DB.QueryRow(query, id).Scan(
-		&data.ID, &data.UUID, &data.NamaTempat, &data.Deskripsi, &data.Fasilitas,
-		&data.HargaTiket, &data.Lokasi, &data.CategoryID,
-		&data.CategoryName, &data.ImageURL,
-	)
-	
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	data, err := h.Wisata.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		responseError(w, http.StatusNotFound, "Wisata tidak ditemukan")
+		return
+	} else if err != nil {
+		requestLogger(r).Error("fetch wisata failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengambil data wisata")
 		return
 	}
 	
 	if data.ImageURL != "" {
-		scheme := "http"
-		if r.TLS != nil {
-			scheme = "https"
-		}
-		data.ImageURL = scheme + "://" + r.Host + data.ImageURL
+		data.ImageURL = config.App.Server.PublicURL + data.ImageURL
 	}
 	
 	json.NewEncoder(w).Encode(
@@ -149,120 +97,103 @@ func GetWisataDetail(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func CreateWisata(w http.ResponseWriter, r *http.Request) {
-	
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// wisataForm is the multipart form of CreateWisata and UpdateWisata, next
+// to the optional image file.
+type wisataForm struct {
+	NamaTempat string  `form:"nama_tempat" validate:"required,max=150"`
+	CategoryID int     `form:"category_id" validate:"required,min=1"`
+	Lokasi     string  `form:"lokasi" validate:"required,max=200"`
+	HargaTiket float64 `form:"harga_tiket" validate:"required,min=0"`
+	Deskripsi  string  `form:"deskripsi" validate:"max=10000"`
+	Fasilitas  string  `form:"fasilitas" validate:"max=2000"`
+}
+
+func (f *wisataForm) wisata() *models.Wisata {
+	return &models.Wisata{
+		NamaTempat: f.NamaTempat,
+		CategoryID: f.CategoryID,
+		Lokasi:     f.Lokasi,
+		HargaTiket: f.HargaTiket,
+		Deskripsi:  &f.Deskripsi,
+		Fasilitas:  &f.Fasilitas,
+	}
+}
+
+// saveWisataImage stores the optional image field as the primary image of
+// the wisata. Upload problems are ignored, the wisata itself is saved.
+func (h *Handler) saveWisataImage(r *http.Request, wisataID int) {
+	file, header, err := r.FormFile("image")
+	if err != nil {
 		return
 	}
+	defer file.Close()
 	
-	err := r.ParseMultipartForm(10 << 20)
+	imagePath, err := saveUploadedFile(file, header.Filename)
 	if err != nil {
-		http.Error(w, "File too large", http.StatusBadRequest)
+		return
+	}
+	if err := h.Wisata.SetImage(r.Context(), wisataID, imagePath); err != nil {
+		requestLogger(r).Error("save wisata image failed", "err", err)
+	}
+}
+
+func (h *Handler) CreateWisata(w http.ResponseWriter, r *http.Request) {
+	// The slug is set once; UpdateWisata does not change it.
+	var input struct {
+		wisataForm
+		Slug string `form:"slug" validate:"required,slug,max=150"`
+	}
+	if !decodeForm(w, r, &input) {
 		return
 	}
 	
-	namaTempat := r.FormValue("nama_tempat")
-	slug := r.FormValue("slug")
-	categoryID := r.FormValue("category_id")
-	lokasi := r.FormValue("lokasi")
-	hargaTiket := r.FormValue("harga_tiket")
-	deskripsi := r.FormValue("deskripsi")
-	fasilitas := r.FormValue("fasilitas")
-	
-	query := `
-		INSERT INTO wisata (nama_tempat, slug, category_id, lokasi, harga_tiket, deskripsi, fasilitas)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id
-	`
-	
-	var newID int
-	err = config.DB.QueryRow(
-		query,
-		namaTempat, slug, categoryID, lokasi, hargaTiket, deskripsi, fasilitas,
-	).Scan(&newID)
+	wisata := input.wisata()
+	wisata.Slug = input.Slug
 	
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	err := h.Wisata.Create(r.Context(), wisata)
+	if errors.Is(err, store.ErrDuplicate) {
+		responseSlugTaken(w)
+		return
+	} else if err != nil {
+		requestLogger(r).Error("create wisata failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menyimpan wisata")
 		return
 	}
 	
-	file, header, err := r.FormFile("image")
-	if err == nil {
-		defer file.Close()
-		imagePath, err := saveUploadedFile(file, header.Filename)
-		if err == nil {
-			_, _ = config.DB.Exec(
-				"INSERT INTO wisata_images (wisata_id, image_url, is_primary) VALUES ($1, $2, true)",
-				newID,
-				imagePath,
-			)
-		}
-	}
+	h.saveWisataImage(r, wisata.ID)
 	
 	json.NewEncoder(w).Encode(
 		models.Response{
 			Status:  201,
 			Message: "Wisata Created",
-			Data:    map[string]int{"id": newID},
+			Data:    map[string]int{"id": wisata.ID},
 		},
 	)
 }
 
-func UpdateWisata(w http.ResponseWriter, r *http.Request) {
-	
-	if r.Method != "PUT" && r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		http.Error(w, "ID required", http.StatusBadRequest)
+func (h *Handler) UpdateWisata(w http.ResponseWriter, r *http.Request) {
+	idStr := pathParam(r, "id")
+	if idStr == "" {
+		responseFieldErrors(w, http.StatusBadRequest, "ID wajib diisi", map[string][]string{"id": {"ID wajib diisi"}})
 		return
 	}
+	id, _ := strconv.Atoi(idStr)
 	
-	err := r.ParseMultipartForm(10 << 20)
-	if err != nil {
-		http.Error(w, "File too large", http.StatusBadRequest)
+	var input wisataForm
+	if !decodeForm(w, r, &input) {
 		return
 	}
 	
-	namaTempat := r.FormValue("nama_tempat")
-	categoryID := r.FormValue("category_id")
-	lokasi := r.FormValue("lokasi")
-	hargaTiket := r.FormValue("harga_tiket")
-	deskripsi := r.FormValue("deskripsi")
-	fasilitas := r.FormValue("fasilitas")
-	
-	query := `
-		UPDATE wisata
-		SET nama_tempat=$1, category_id=$2, lokasi=$3, harga_tiket=$4, deskripsi=$5, fasilitas=$6, updated_at=NOW()
-		WHERE id=$7
-	`
-	_, err = config.DB.Exec(
-		query,
-		namaTempat, categoryID, lokasi, hargaTiket, deskripsi, fasilitas, id,
-	)
+	wisata := input.wisata()
+	wisata.ID = id
 	
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.Wisata.Update(r.Context(), wisata); err != nil {
+		requestLogger(r).Error("update wisata failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal mengupdate wisata")
 		return
 	}
 	
-	file, header, err := r.FormFile("image")
-	if err == nil {
-		defer file.Close()
-		imagePath, err := saveUploadedFile(file, header.Filename)
-		if err == nil {
-			_, _ = config.DB.Exec("DELETE FROM wisata_images WHERE wisata_id = $1", id)
-			_, _ = config.DB.Exec(
-				"INSERT INTO wisata_images (wisata_id, image_url, is_primary) VALUES ($1, $2, true)",
-				id,
-				imagePath,
-			)
-		}
-	}
+	h.saveWisataImage(r, id)
 	
 	json.NewEncoder(w).Encode(
 		models.Response{
@@ -272,24 +203,17 @@ func UpdateWisata(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func DeleteWisata(w http.ResponseWriter, r *http.Request) {
-	
-	if r.Method != "DELETE" && r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		http.Error(w, "ID required", http.StatusBadRequest)
+func (h *Handler) DeleteWisata(w http.ResponseWriter, r *http.Request) {
+	idStr := pathParam(r, "id")
+	if idStr == "" {
+		responseFieldErrors(w, http.StatusBadRequest, "ID wajib diisi", map[string][]string{"id": {"ID wajib diisi"}})
 		return
 	}
+	id, _ := strconv.Atoi(idStr)
 	
-	query := "UPDATE wisata SET deleted_at = NOW() WHERE id = $1"
-	_, err := config.DB.Exec(query, id)
-	
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.Wisata.Delete(r.Context(), id); err != nil {
+		requestLogger(r).Error("delete wisata failed", "err", err)
+		responseError(w, http.StatusInternalServerError, "Gagal menghapus wisata")
 		return
 	}
 	
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	
+	"backend-wisata/models"
+)
+
+func TestGetAllWisata(t *testing.T) {
+	e := newTestEnv(t)
+	category := e.newCategory()
+	
+	pantai := e.newWisataIn(category.ID)
+	e.newWisataIn(category.ID)
+	deleted := e.newWisataIn(category.ID)
+	if err := e.Wisata.Delete(t.Context(), deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+	
+	tests := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{"all", "/api/v1/wisata", 2},
+		{"search by name", "/api/v1/wisata?q=" + url.QueryEscape(strings.ToUpper(pantai.NamaTempat)), 1},
+		{"no match", "/api/v1/wisata?q=tidak-ada", 0},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec, res := serve(t, e.GetAllWisata, newRequest(http.MethodGet, tt.target, nil))
+				expectStatus(t, rec, res, http.StatusOK, "")
+				
+				var list []models.Wisata
+				if res.Data != nil {
+					decodeData(t, res, &list)
+				}
+				if len(list) != tt.want {
+					t.Fatalf("%d wisata, want %d", len(list), tt.want)
+				}
+				for _, w := range list {
+					if w.ID == deleted.ID {
+						t.Fatal("deleted wisata listed")
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestGetWisataDetail(t *testing.T) {
+	e := newTestEnv(t)
+	wisata := e.newWisata()
+	
+	tests := []struct {
+		name   string
+		id     string
+		status int
+	}{
+		{"existing", strconv.Itoa(wisata.ID), http.StatusOK},
+		{"unknown", strconv.Itoa(wisata.ID + 1000), http.StatusNotFound},
+		{"missing id", "", http.StatusBadRequest},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec, res := serve(t, e.GetWisataDetail, withPath(newRequest(http.MethodGet, "/api/v1/wisata/"+tt.id, nil), "id", tt.id))
+				expectStatus(t, rec, res, tt.status, "")
+				if tt.status != http.StatusOK {
+					return
+				}
+				
+				var got models.Wisata
+				decodeData(t, res, &got)
+				if got.ID != wisata.ID || got.NamaTempat != wisata.NamaTempat {
+					t.Fatalf("got wisata %d %q", got.ID, got.NamaTempat)
+				}
+			},
+		)
+	}
+}
+
+func TestCreateWisata(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	category := e.newCategory()
+	
+	valid := func() map[string]string {
+		return map[string]string{
+			"nama_tempat": "Kawah Putih",
+			"slug":        "kawah-putih",
+			"category_id": strconv.Itoa(category.ID),
+			"lokasi":      "Ciwidey",
+			"harga_tiket": "30000",
+			"deskripsi":   "Danau kawah",
+		}
+	}
+	
+	tests := []struct {
+		name   string
+		edit   func(map[string]string)
+		status int
+		field  string
+	}{
+		{"valid", func(map[string]string) {}, http.StatusOK, ""},
+		{"with image", func(in map[string]string) { in["slug"] = "kawah-putih-2"; in["file:image"] = "jpeg" }, http.StatusOK, ""},
+		{"invalid slug", func(in map[string]string) { in["slug"] = "Kawah Putih" }, http.StatusUnprocessableEntity, "slug"},
+		{"missing name", func(in map[string]string) { delete(in, "nama_tempat") }, http.StatusUnprocessableEntity, "nama_tempat"},
+		{"price not a number", func(in map[string]string) { in["harga_tiket"] = "gratis" }, http.StatusUnprocessableEntity, "harga_tiket"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				input := valid()
+				tt.edit(input)
+				
+				rec, res := serve(t, e.CreateWisata, asUser(newFormRequest(http.MethodPost, "/api/v1/wisata", input), admin))
+				expectStatus(t, rec, res, tt.status, "")
+				if tt.field != "" {
+					if len(res.Details[tt.field]) == 0 {
+						t.Fatalf("no error for field %s: %v", tt.field, res.Details)
+					}
+					return
+				}
+				
+				var created struct {
+					ID int `json:"id"`
+				}
+				decodeData(t, res, &created)
+				got, err := e.Wisata.Get(t.Context(), created.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.Slug != input["slug"] || got.HargaTiket != 30000 || got.CategoryID != category.ID {
+					t.Fatalf("stored %+v", got)
+				}
+				if _, hasImage := input["file:image"]; hasImage != (got.ImageURL != "") {
+					t.Fatalf("image URL %q", got.ImageURL)
+				}
+			},
+		)
+	}
+}
+
+func TestUpdateWisata(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	moved := e.newCategory()
+	
+	valid := map[string]string{
+		"nama_tempat": "Nama Baru",
+		"category_id": strconv.Itoa(moved.ID),
+		"lokasi":      "Garut",
+		"harga_tiket": "40000",
+	}
+	withImage := maps.Clone(valid)
+	withImage["file:image"] = "jpeg"
+	
+	tests := []struct {
+		name   string
+		input  map[string]string
+		status int
+		code   string
+	}{
+		{"with image", withImage, http.StatusOK, ""},
+		{"without image", valid, http.StatusOK, ""},
+		{"missing fields", map[string]string{"lokasi": "Garut"}, http.StatusUnprocessableEntity, "validation_failed"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				wisata := e.newWisata()
+				id := strconv.Itoa(wisata.ID)
+				r := withPath(newFormRequest(http.MethodPut, "/api/v1/wisata/"+id, tt.input), "id", id)
+				rec, res := serve(t, e.UpdateWisata, asUser(r, admin))
+				expectStatus(t, rec, res, tt.status, tt.code)
+				
+				got, err := e.Wisata.Get(t.Context(), wisata.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.status != http.StatusOK {
+					if got.NamaTempat != wisata.NamaTempat || got.Lokasi != wisata.Lokasi {
+						t.Fatalf("rejected update stored %+v", got)
+					}
+					return
+				}
+				if got.NamaTempat != "Nama Baru" || got.Lokasi != "Garut" || got.HargaTiket != 40000 || got.CategoryID != moved.ID {
+					t.Fatalf("stored %+v", got)
+				}
+				if got.Slug != wisata.Slug {
+					t.Fatalf("slug changed to %q", got.Slug)
+				}
+				if _, hasImage := tt.input["file:image"]; hasImage != (got.ImageURL != "") {
+					t.Fatalf("image URL %q", got.ImageURL)
+				}
+			},
+		)
+	}
+}
+
+func TestDeleteWisata(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.newUser(models.RoleAdmin)
+	wisata := e.newWisata()
+	
+	id := strconv.Itoa(wisata.ID)
+	rec, res := serve(t, e.DeleteWisata, asUser(withPath(newRequest(http.MethodDelete, "/api/v1/wisata/"+id, nil), "id", id), admin))
+	expectStatus(t, rec, res, http.StatusOK, "")
+	
+	rec, res = serve(t, e.GetWisataDetail, withPath(newRequest(http.MethodGet, "/api/v1/wisata/"+id, nil), "id", id))
+	expectStatus(t, rec, res, http.StatusNotFound, "")
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	
+	"backend-wisata/config"
+)
+
+// exposedHeaders are response headers the frontend may read.
+var exposedHeaders = []string{
+	"Retry-After", "X-CSRF-Token", "X-Request-ID",
+	"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+}
+
+// corsPolicy answers CORS requests for the origins in config.CORSConfig.
+// Cookies make every allowed origin a trusted one, so requests from any
+// other origin are refused instead of just being left without headers.
+type corsPolicy struct {
+	// self is the origin of server.public_url. The Host header is set by
+	// the client, so it cannot tell a same-origin request apart.
+	self      string
+	exact     map[string]bool
+	wildcards []wildcardOrigin
+	methods   []string
+	headers   []string
+	maxAge    string
+}
+
+// wildcardOrigin is https://*.example.com split around the "*".
+type wildcardOrigin struct {
+	prefix, suffix string
+}
+
+func newCORSPolicy(cfg config.CORSConfig, publicURL string) *corsPolicy {
+	p := &corsPolicy{
+		self:   publicOrigin(publicURL),
+		exact:  map[string]bool{},
+		maxAge: strconv.Itoa(int(cfg.MaxAge.Seconds())),
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		if prefix, suffix, ok := strings.Cut(origin, "*"); ok {
+			p.wildcards = append(p.wildcards, wildcardOrigin{prefix: prefix, suffix: suffix})
+		} else {
+			p.exact[origin] = true
+		}
+	}
+	for _, method := range cfg.AllowedMethods {
+		p.methods = append(p.methods, strings.ToUpper(method))
+	}
+	for _, header := range cfg.AllowedHeaders {
+		p.headers = append(p.headers, http.CanonicalHeaderKey(header))
+	}
+	return p
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	if p.exact[origin] {
+		return true
+	}
+	for _, w := range p.wildcards {
+		// The part matched by "*" must be a plain subdomain, so
+		// https://evil.com/.example.com or a different port cannot match.
+		sub, ok := strings.CutPrefix(origin, w.prefix)
+		if !ok {
+			continue
+		}
+		sub, ok = strings.CutSuffix(sub, w.suffix)
+		if ok && sub != "" && strings.Trim(sub, "abcdefghijklmnopqrstuvwxyz0123456789-.") == "" &&
+			!strings.HasPrefix(sub, ".") && !strings.HasSuffix(sub, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// allowHeaders reports whether every header named in a preflight's
+// Access-Control-Request-Headers is allowed.
+func (p *corsPolicy) allowHeaders(requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !slices.Contains(p.headers, http.CanonicalHeaderKey(header)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *corsPolicy) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			w.Header().Add("Vary", "Origin")
+			
+			// Same-origin requests, e.g. a frontend served behind the public
+			// URL of the API, need no CORS headers.
+			if origin == "" || strings.EqualFold(origin, p.self) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			
+			if !p.allowOrigin(origin) {
+				respondErrorCode(w, http.StatusForbidden, "origin_not_allowed", "Origin tidak diizinkan")
+				return
+			}
+			
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			
+			requestedMethod := r.Header.Get("Access-Control-Request-Method")
+			if r.Method != http.MethodOptions || requestedMethod == "" {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(exposedHeaders, ", "))
+				next.ServeHTTP(w, r)
+				return
+			}
+			
+			// Preflight.
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			
+			requestedHeaders := r.Header.Get("Access-Control-Request-Headers")
+			if !slices.Contains(p.methods, requestedMethod) {
+				respondErrorCode(w, http.StatusForbidden, "method_not_allowed", "Method "+requestedMethod+" tidak diizinkan")
+				return
+			}
+			if !p.allowHeaders(requestedHeaders) {
+				respondErrorCode(w, http.StatusForbidden, "header_not_allowed", "Header tidak diizinkan: "+requestedHeaders)
+				return
+			}
+			
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(p.methods, ", "))
+			if len(p.headers) > 0 {
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(p.headers, ", "))
+			}
+			w.Header().Set("Access-Control-Max-Age", p.maxAge)
+			w.WriteHeader(http.StatusNoContent)
+		},
+	)
+}
+
+// publicOrigin returns the scheme and host of the public URL, the form
+// browsers send in the Origin header.
+func publicOrigin(publicURL string) string {
+	u, err := url.Parse(publicURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"backend-wisata/config"
+)
+
+func TestCORSSameOrigin(t *testing.T) {
+	policy := newCORSPolicy(config.CORSConfig{AllowedOrigins: []string{"https://wisata.example.test"}}, "https://API.example.test/base")
+	handler := policy.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	
+	tests := []struct {
+		name   string
+		origin string
+		host   string
+		status int
+		cors   bool
+	}{
+		{"public URL", "https://api.example.test", "internal:8080", http.StatusOK, false},
+		{"allowed origin", "https://wisata.example.test", "api.example.test", http.StatusOK, true},
+		// The Host header comes from the client and proves nothing.
+		{"origin matching the Host header", "https://evil.example.test", "evil.example.test", http.StatusForbidden, false},
+		{"public host over http", "http://api.example.test", "api.example.test", http.StatusForbidden, false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, "/api/v1/wisata", nil)
+				r.Host = tt.host
+				r.Header.Set("Origin", tt.origin)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, r)
+				
+				if rec.Code != tt.status {
+					t.Fatalf("status %d, want %d", rec.Code, tt.status)
+				}
+				if got := rec.Header().Get("Access-Control-Allow-Origin") != ""; got != tt.cors {
+					t.Fatalf("CORS headers sent: %v, want %v", got, tt.cors)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,169 @@
+// Package logger configures log/slog and carries a request-scoped logger
+// through the context.
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// RequestIDHeader is read from incoming requests and echoed on responses.
+const RequestIDHeader = "X-Request-ID"
+
+type contextKey string
+
+const (
+	loggerKey  contextKey = "logger"
+	requestKey contextKey = "request"
+)
+
+// Init installs the default logger. format is "json" or "text"; the standard
+// log package is routed through the same handler.
+func Init(w io.Writer, format, level string) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelInfo
+	}
+	
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	if format == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// FromContext returns the request logger, or the default logger outside a
+// request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// With returns a context whose logger carries the extra attributes.
+func With(ctx context.Context, args ...any) context.Context {
+	return context.WithValue(ctx, loggerKey, FromContext(ctx).With(args...))
+}
+
+// requestInfo is filled in by inner handlers and read by Middleware once the
+// request finished.
+type requestInfo struct {
+	id     string
+	userID atomic.Int64
+}
+
+// RequestID returns the ID assigned to the request, or "".
+func RequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(requestKey).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+// SetUserID records the authenticated user for the access log line.
+func SetUserID(ctx context.Context, id int) {
+	if info, ok := ctx.Value(requestKey).(*requestInfo); ok {
+		info.userID.Store(int64(id))
+	}
+}
+
+// validRequestID accepts IDs from upstream proxies only if they are short
+// and cannot inject anything into logs or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	return strings.IndexFunc(
+		id, func(c rune) bool {
+			return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.')
+		},
+	) < 0
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Middleware assigns or propagates X-Request-ID, puts a logger tagged with
+// it into the context and writes one access log line per request.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			
+			info := &requestInfo{id: id}
+			ctx := context.WithValue(r.Context(), requestKey, info)
+			log := slog.Default().With("request_id", id)
+			ctx = context.WithValue(ctx, loggerKey, log)
+			
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+			
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			
+			attrs := []any{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rec.status,
+				"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+				"bytes", rec.bytes,
+			}
+			if userID := info.userID.Load(); userID > 0 {
+				attrs = append(attrs, "user_id", userID)
+			}
+			
+			level := slog.LevelInfo
+			if rec.status >= 500 {
+				level = slog.LevelError
+			}
+			log.Log(ctx, level, "request", attrs...)
+		},
+	)
+}
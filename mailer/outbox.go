@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// OutboxMailer writes every message to a file in Dir instead of sending it,
+// for local development and tests. With an empty Dir it only logs.
+type OutboxMailer struct {
+	Dir string
+}
+
+func (m *OutboxMailer) Send(msg Message) error {
+	slog.Info("mail written to outbox", "to", msg.To, "subject", msg.Subject)
+	
+	if m.Dir == "" {
+		return nil
+	}
+	
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return err
+	}
+	
+	name := strconv.FormatInt(time.Now().UnixNano(), 10) + ".eml"
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+	
+	return os.WriteFile(filepath.Join(m.Dir, name), []byte(content), 0644)
+}
@@ -1,96 +1,307 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
 	
 	"backend-wisata/config"
 	"backend-wisata/controllers"
+	"backend-wisata/logger"
+	"backend-wisata/metrics"
+	"backend-wisata/migrations"
+	"backend-wisata/models"
+	"backend-wisata/ratelimit"
+	"backend-wisata/store"
 )
 
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(
-		func(w http.ResponseWriter, r *http.Request) {
-			origin := r.Header.Get("Origin")
+func respondError(w http.ResponseWriter, code int, message string) {
+	respondErrorCode(w, code, "", message)
+}
+
+func respondErrorCode(w http.ResponseWriter, status int, code, message string) {
+	controllers.WriteError(w, status, code, message)
+}
+
+// requireRole only lets the request through when the session cookie or
+// bearer token belongs to an active user holding one of the given roles. The
+// resolved user is stored in the request context, see controllers.CurrentUser.
+func requireRole(h *controllers.Handler, roles ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return authorize(h, roles, false)
+}
+
+// requireRoleDuringSetup is requireRole for the few routes an admin may use
+// before finishing the mandatory two-factor enrollment.
+func requireRoleDuringSetup(h *controllers.Handler, roles ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return authorize(h, roles, true)
+}
+
+// authenticateAPIKey resolves the X-API-Key header, if any, and returns the
+// request carrying the key. It writes the error response and returns false
+// when the key is invalid or over its limit.
+func authenticateAPIKey(h *controllers.Handler, w http.ResponseWriter, r *http.Request) (*http.Request, *models.User, bool) {
+	raw, ok := controllers.APIKeyFromHeader(r)
+	if !ok {
+		return r, nil, true
+	}
+	
+	user, key, err := h.APIKeyUser(r.Context(), raw)
+	
+	var limited *controllers.RateLimitError
+	if errors.As(err, &limited) {
+		ratelimit.SetHeaders(w, limited.Result)
+		respondErrorCode(w, http.StatusTooManyRequests, "rate_limited", "Batas request API key terlampaui")
+		return r, nil, false
+	} else if errors.Is(err, controllers.ErrUnauthenticated) {
+		respondError(w, http.StatusUnauthorized, "API key tidak valid")
+		return r, nil, false
+	} else if err != nil {
+		logger.FromContext(r.Context()).Error("resolve api key failed", "err", err)
+		respondError(w, http.StatusInternalServerError, "Gagal memverifikasi API key")
+		return r, nil, false
+	}
+	
+	if !user.IsActive {
+		respondError(w, http.StatusForbidden, "Akun nonaktif")
+		return r, nil, false
+	}
+	
+	return controllers.WithAPIKey(r, key), user, true
+}
+
+func authorize(h *controllers.Handler, roles []string, allowPendingSetup bool) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			r, user, ok := authenticateAPIKey(h, w, r)
+			if !ok {
+				return
+			}
+			
+			var err error
+			if user == nil {
+				user, err = h.AuthenticatedUser(r)
+			}
 			
-			if origin != "" {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			if errors.Is(err, controllers.ErrUnauthenticated) {
+				respondError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			} else if err != nil {
+				logger.FromContext(r.Context()).Error("resolve session failed", "err", err)
+				respondError(w, http.StatusInternalServerError, "Gagal memverifikasi sesi")
+				return
 			}
 			
-			w.Header().Set("Vary", "Origin")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept")
+			if !user.IsActive {
+				respondError(w, http.StatusForbidden, "Akun nonaktif")
+				return
+			}
+			
+			if !h.CheckCSRF(r) {
+				respondErrorCode(w, http.StatusForbidden, "csrf_token_invalid", "Token CSRF tidak valid, muat ulang halaman lalu coba lagi")
+				return
+			}
+			
+			if !slices.Contains(roles, user.Role) {
+				respondError(w, http.StatusForbidden, "Forbidden")
+				return
+			}
+			
+			if !allowPendingSetup {
+				pending, err := h.TwoFactorSetupPending(r)
+				if err != nil {
+					logger.FromContext(r.Context()).Error("resolve session failed", "err", err)
+					respondError(w, http.StatusInternalServerError, "Gagal memverifikasi sesi")
+					return
+				}
+				if pending {
+					respondErrorCode(w, http.StatusForbidden, "two_factor_setup_required", "Aktifkan autentikasi dua faktor terlebih dahulu")
+					return
+				}
+			}
+			
+			next(w, r.WithContext(controllers.WithUser(r.Context(), user)))
+		}
+	}
+}
+
+// requireScope guards a route that partner API keys may call. Requests made
+// with a key must hold the scope; logged-in users and anonymous callers of
+// public routes pass through. Routes without requireScope reject API keys
+// through the role check, since partner accounts only appear in the role
+// lists of scoped routes.
+func requireScope(h *controllers.Handler, scope string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := controllers.CurrentAPIKey(r)
+			if key == nil {
+				var ok bool
+				if r, _, ok = authenticateAPIKey(h, w, r); !ok {
+					return
+				}
+				key = controllers.CurrentAPIKey(r)
+			}
 			
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusNoContent)
+			if key != nil && !key.HasScope(scope) {
+				respondErrorCode(w, http.StatusForbidden, "insufficient_scope", "API key tidak memiliki scope "+scope)
 				return
 			}
 			
-			next.ServeHTTP(w, r)
-		},
-	)
+			next(w, r)
+		}
+	}
+}
+
+// requireCSRF applies the CSRF check of authorize to routes that also work
+// without a login, such as logout: a request that does carry a session
+// cookie still has to echo its token.
+func requireCSRF(h *controllers.Handler) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !h.CheckCSRF(r) {
+				respondErrorCode(w, http.StatusForbidden, "csrf_token_invalid", "Token CSRF tidak valid, muat ulang halaman lalu coba lagi")
+				return
+			}
+			next(w, r)
+		}
+	}
 }
 
 func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to an optional YAML config file")
+	flag.Parse()
+	
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("invalid configuration:\n", err)
+	}
+	config.App = cfg
+	logger.Init(os.Stdout, cfg.Log.Format, cfg.Log.Level)
+	
 	config.ConnectDB()
+	
+	if args := flag.Args(); len(args) > 0 {
+		var err error
+		switch args[0] {
+		case "migrate":
+			err = runMigrate(args[1:])
+		case "seed":
+			err = runSeed(args[1:])
+		default:
+			err = fmt.Errorf("unknown command %q", args[0])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	
+	version, err := migrations.Check(context.Background(), config.DB)
+	if err != nil {
+		log.Fatal(err, "; run `migrate up` first")
+	}
+	slog.Info("database schema checked", "version", version)
+	
 	config.InitSession()
+	config.InitMailer()
+	stopSessionCleanup := config.StartSessionCleanup(time.Hour)
+	
+	var limiterStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Backend == "postgres" {
+		limiterStore = ratelimit.NewPostgresStore(config.DB)
+	}
+	stopRateLimitSweeper := ratelimit.StartSweeper(limiterStore, 5*time.Minute)
+	limiter := &rateLimiter{store: limiterStore, rules: cfg.RateLimit.Rules}
+	
+	h := controllers.NewHandler(store.NewPostgres(config.DB), config.AdminStore, config.UserStore)
+	h.RateLimits = limiterStore
+	h.DB = config.DB
 	
 	mux := http.NewServeMux()
 	
-	fileServer := http.FileServer(http.Dir("./uploads"))
-	mux.Handle("/uploads/", http.StripPrefix("/uploads/", fileServer))
-	
-	mux.HandleFunc("/api/login", controllers.Login)
-	mux.HandleFunc("/api/logout", controllers.Logout)
-	mux.HandleFunc("/api/register", controllers.Register)
-	mux.HandleFunc("/api/me", controllers.GetMe)
-	mux.HandleFunc("/api/profile", controllers.GetProfile)
-	mux.HandleFunc("/api/profile/update", controllers.UpdateProfile)
-	
-	mux.HandleFunc("/api/wisata", controllers.GetAllWisata)
-	mux.HandleFunc("/api/wisata/detail", controllers.GetWisataDetail)
-	mux.HandleFunc("/api/wisata/create", controllers.CreateWisata)
-	mux.HandleFunc("/api/wisata/update", controllers.UpdateWisata)
-	mux.HandleFunc("/api/wisata/delete", controllers.DeleteWisata)
-	
-	mux.HandleFunc("/api/categories", controllers.GetAllCategories)
-	mux.HandleFunc("/api/categories/create", controllers.CreateCategory)
-	mux.HandleFunc("/api/categories/update", controllers.UpdateCategory)
-	mux.HandleFunc("/api/categories/delete", controllers.DeleteCategory)
-	
-	mux.HandleFunc("/api/booking/create", controllers.CreateBooking)
-	mux.HandleFunc("/api/booking/history", controllers.GetBookingHistory)
-	mux.HandleFunc("/api/booking/detail", controllers.GetBookingDetail)
-	mux.HandleFunc("/api/booking/pay", controllers.ProcessPayment)
-	mux.HandleFunc("/api/booking/cancel", controllers.CancelBooking)
-	
-	mux.HandleFunc("/api/dashboard/stats", controllers.GetDashboardStats)
-	mux.HandleFunc("/api/dashboard/recent-bookings", controllers.GetRecentBookings)
-	mux.HandleFunc("/api/dashboard/popular-wisata", controllers.GetPopularWisata)
-	
-	mux.HandleFunc("/api/users", controllers.GetAllUsers)
-	mux.HandleFunc("/api/users/update", controllers.UpdateUserStatus)
-	mux.HandleFunc("/api/users/delete", controllers.DeleteUser)
-	
-	mux.HandleFunc("/api/reviews/submit", controllers.SubmitReview)
-	mux.HandleFunc("/api/reviews/list", controllers.GetReviews)
-	
-	mux.HandleFunc("/api/admin/reviews", controllers.GetAdminReviews)
-	mux.HandleFunc("/api/admin/reviews/approve", controllers.ApproveReview)
-	mux.HandleFunc("/api/admin/reviews/delete", controllers.DeleteReview)
-	mux.HandleFunc("/api/bookings", controllers.GetAllBookings)
-	
-	mux.HandleFunc("/api/blog/posts", controllers.GetBlogPosts)
-	mux.HandleFunc("/api/blog/detail", controllers.GetBlogDetail)
-	mux.HandleFunc("/api/blog/categories", controllers.GetBlogCategories)
-	mux.HandleFunc("/api/blog/create", controllers.CreateBlogPost)
-	mux.HandleFunc("/api/blog/update", controllers.UpdateBlogPost)
-	mux.HandleFunc("/api/blog/delete", controllers.DeleteBlogPost)
-	
-	log.Println("Server running on http://localhost:8080")
-	
-	if err := http.ListenAndServe("0.0.0.0:8080", corsMiddleware(mux)); err != nil {
-		log.Fatal(err)
+	fileServer := http.FileServer(http.Dir(cfg.Uploads.Dir))
+	mux.Handle("GET "+cfg.Uploads.URLPath, http.StripPrefix(cfg.Uploads.URLPath, fileServer))
+	registerRoutes(mux, h, limiter)
+	
+	metrics.RegisterDBStats(config.DB)
+	var metricsSrv *http.Server
+	if cfg.Metrics.ListenAddr != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", metrics.Handler(cfg.Metrics.Token))
+		metricsSrv = &http.Server{
+			Addr:              cfg.Metrics.ListenAddr,
+			Handler:           metricsMux,
+			ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		}
+	} else if cfg.Metrics.Token != "" {
+		mux.Handle("GET /metrics", metrics.Handler(cfg.Metrics.Token))
+	}
+	
+	srv := &http.Server{
+		Addr:              cfg.Server.ListenAddr,
+		Handler:           logger.Middleware(newCORSPolicy(cfg.CORS, cfg.Server.PublicURL).middleware(metrics.Middleware(unmatchedErrors(requestDeadlines(mux, cfg.Timeouts))))),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
 	}
+	
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	
+	serverErr := make(chan error, 2)
+	go func() {
+		slog.Info("server listening", "addr", cfg.Server.ListenAddr)
+		serverErr <- srv.ListenAndServe()
+	}()
+	if metricsSrv != nil {
+		go func() {
+			slog.Info("metrics listening", "addr", cfg.Metrics.ListenAddr)
+			serverErr <- metricsSrv.ListenAndServe()
+		}()
+	}
+	
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("server failed", "err", err)
+		}
+	case <-ctx.Done():
+		slog.Info("shutting down", "timeout", cfg.Server.ShutdownTimeout.String())
+		controllers.MarkShuttingDown()
+		if cfg.Server.ShutdownDelay > 0 {
+			slog.Info("failing readiness before closing the listener", "delay", cfg.Server.ShutdownDelay.String())
+			time.Sleep(cfg.Server.ShutdownDelay)
+		}
+	}
+	stop()
+	
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("shutdown failed", "err", err)
+	}
+	if metricsSrv != nil {
+		metricsSrv.Close()
+	}
+	stopSessionCleanup()
+	stopRateLimitSweeper()
+	if err := controllers.WaitBackground(shutdownCtx); err != nil {
+		slog.Error("background work did not finish", "err", err)
+	}
+	if err := config.DB.Close(); err != nil {
+		slog.Error("close database failed", "err", err)
+	}
+	
+	slog.Info("server stopped")
 }
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	
+	"backend-wisata/config"
+	"backend-wisata/controllers"
+	"backend-wisata/models"
+)
+
+// caller authenticates a request, or leaves it anonymous when nil.
+type caller func(r *http.Request)
+
+// session returns a caller that sends the cookies and CSRF token of a
+// login as user.
+func (e *routeEnv) session(user *models.User) caller {
+	cookies, token := e.login(user)
+	return func(r *http.Request) {
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		r.Header.Set(controllers.CSRFHeader, token)
+	}
+}
+
+// bearer returns a caller that sends an access token issued to user.
+func (e *routeEnv) bearer(user *models.User) caller {
+	e.t.Helper()
+	rec, _ := e.do(newJSONRequest(http.MethodPost, "/api/v1/token", map[string]string{"username": user.Username, "password": testPassword}))
+	var res struct {
+		Data models.TokenPair `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || rec.Code != http.StatusOK {
+		e.t.Fatalf("issue token: status %d, err %v", rec.Code, err)
+	}
+	return func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+res.Data.AccessToken)
+	}
+}
+
+// apiKey returns a caller that sends a new API key of partner with scopes,
+// created by an admin through the API.
+func (e *routeEnv) apiKey(partner *models.User, scopes ...string) caller {
+	e.t.Helper()
+	r := newJSONRequest(http.MethodPost, "/api/v1/admin/api-keys", map[string]any{"user_id": partner.ID, "name": "Integrasi", "scopes": scopes})
+	e.session(e.newUser(models.RoleAdmin))(r)
+	rec, _ := e.do(r)
+	var res struct {
+		Data struct {
+			Key string `json:"key"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || rec.Code != http.StatusCreated {
+		e.t.Fatalf("create api key: status %d, err %v", rec.Code, err)
+	}
+	return func(r *http.Request) {
+		r.Header.Set("X-API-Key", res.Data.Key)
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	e := newRouteEnv(t)
+	inactive := e.newUser(models.RoleUser)
+	inactiveSession := e.session(inactive)
+	if err := e.Users.UpdateStatus(context.Background(), inactive.ID, false, inactive.Role); err != nil {
+		t.Fatal(err)
+	}
+	
+	tests := []struct {
+		name   string
+		target string
+		caller caller
+		status int
+		code   string
+	}{
+		{"anonymous", "/api/v1/users", nil, http.StatusUnauthorized, "unauthorized"},
+		{"user on admin route", "/api/v1/users", e.session(e.newUser(models.RoleUser)), http.StatusForbidden, "forbidden"},
+		{"admin", "/api/v1/users", e.session(e.newUser(models.RoleAdmin)), http.StatusOK, ""},
+		{"superadmin", "/api/v1/users", e.session(e.newUser(models.RoleSuperadmin)), http.StatusOK, ""},
+		{"user with token", "/api/v1/profile", e.bearer(e.newUser(models.RoleUser)), http.StatusOK, ""},
+		{"user token on admin route", "/api/v1/users", e.bearer(e.newUser(models.RoleUser)), http.StatusForbidden, "forbidden"},
+		{"partner on user route", "/api/v1/profile", e.session(e.newUser(models.RolePartner)), http.StatusForbidden, "forbidden"},
+		{"inactive user", "/api/v1/profile", inactiveSession, http.StatusForbidden, "forbidden"},
+		{"invalid token", "/api/v1/profile", func(r *http.Request) { r.Header.Set("Authorization", "Bearer tidak-valid") }, http.StatusUnauthorized, "unauthorized"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := newJSONRequest(http.MethodGet, tt.target, nil)
+				if tt.caller != nil {
+					tt.caller(r)
+				}
+				rec, res := e.do(r)
+				if rec.Code != tt.status || res.Code != tt.code {
+					t.Fatalf("got %d %q, want %d %q (%s)", rec.Code, res.Code, tt.status, tt.code, rec.Body)
+				}
+			},
+		)
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	e := newRouteEnv(t)
+	partner := e.newUser(models.RolePartner)
+	catalog := e.apiKey(partner, models.ScopeCatalogRead)
+	booking := e.apiKey(partner, models.ScopeBookingRead, models.ScopeBookingWrite)
+	
+	ctx := context.Background()
+	category := &models.Category{Name: "Alam", Slug: "alam", IsActive: true}
+	if err := e.Wisata.CreateCategory(ctx, category); err != nil {
+		t.Fatal(err)
+	}
+	wisata := &models.Wisata{CategoryID: category.ID, NamaTempat: "Kawah Putih", Slug: "kawah-putih", Lokasi: "Bandung", HargaTiket: 25000}
+	if err := e.Wisata.Create(ctx, wisata); err != nil {
+		t.Fatal(err)
+	}
+	bookingInput := map[string]any{"wisata_id": wisata.ID, "visit_date": "2099-01-01", "quantity": 1}
+	
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   any
+		caller caller
+		status int
+		code   string
+	}{
+		{"catalog key reads catalog", http.MethodGet, "/api/v1/categories", nil, catalog, http.StatusOK, ""},
+		{"catalog key books", http.MethodPost, "/api/v1/bookings", bookingInput, catalog, http.StatusForbidden, "insufficient_scope"},
+		{"catalog key reads bookings", http.MethodGet, "/api/v1/bookings/WST-TIDAK-ADA", nil, catalog, http.StatusForbidden, "insufficient_scope"},
+		{"booking key reads catalog", http.MethodGet, "/api/v1/categories", nil, booking, http.StatusForbidden, "insufficient_scope"},
+		{"booking key books", http.MethodPost, "/api/v1/bookings", bookingInput, booking, http.StatusCreated, ""},
+		{"key on user route", http.MethodGet, "/api/v1/profile", nil, booking, http.StatusForbidden, "forbidden"},
+		{"key on admin route", http.MethodGet, "/api/v1/users", nil, booking, http.StatusForbidden, "forbidden"},
+		{"unknown key", http.MethodGet, "/api/v1/categories", nil, func(r *http.Request) { r.Header.Set("X-API-Key", "wk_tidak-ada") }, http.StatusUnauthorized, "unauthorized"},
+		{"anonymous reads catalog", http.MethodGet, "/api/v1/categories", nil, nil, http.StatusOK, ""},
+		{"user session books", http.MethodPost, "/api/v1/bookings", bookingInput, e.session(e.newUser(models.RoleUser)), http.StatusCreated, ""},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := newJSONRequest(tt.method, tt.target, tt.body)
+				if tt.caller != nil {
+					tt.caller(r)
+				}
+				rec, res := e.do(r)
+				if rec.Code != tt.status || res.Code != tt.code {
+					t.Fatalf("got %d %q, want %d %q (%s)", rec.Code, res.Code, tt.status, tt.code, rec.Body)
+				}
+			},
+		)
+	}
+}
+
+func TestTwoFactorSetupRequired(t *testing.T) {
+	e := newRouteEnv(t)
+	config.RequireAdmin2FA = true
+	t.Cleanup(func() { config.RequireAdmin2FA = false })
+	
+	admin := e.session(e.newUser(models.RoleAdmin))
+	user := e.session(e.newUser(models.RoleUser))
+	
+	tests := []struct {
+		name   string
+		method string
+		target string
+		caller caller
+		status int
+		code   string
+	}{
+		{"admin route", http.MethodGet, "/api/v1/users", admin, http.StatusForbidden, "two_factor_setup_required"},
+		{"user route", http.MethodGet, "/api/v1/profile", admin, http.StatusForbidden, "two_factor_setup_required"},
+		{"me", http.MethodGet, "/api/v1/me", admin, http.StatusOK, ""},
+		{"setup", http.MethodPost, "/api/v1/2fa/setup", admin, http.StatusOK, ""},
+		{"regular user", http.MethodGet, "/api/v1/profile", user, http.StatusOK, ""},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := newJSONRequest(tt.method, tt.target, nil)
+				tt.caller(r)
+				rec, res := e.do(r)
+				if rec.Code != tt.status || res.Code != tt.code {
+					t.Fatalf("got %d %q, want %d %q (%s)", rec.Code, res.Code, tt.status, tt.code, rec.Body)
+				}
+			},
+		)
+	}
+}
+
+// An API key acts as its owner, so it must stop working once the owner is
+// no longer a partner, even when the key was not revoked.
+func TestAPIKeyOwnerPromoted(t *testing.T) {
+	e := newRouteEnv(t)
+	superadmin := e.session(e.newUser(models.RoleSuperadmin))
+	
+	tests := []struct {
+		name    string
+		promote func(partner *models.User)
+	}{
+		{
+			"through the API", func(partner *models.User) {
+				r := newJSONRequest(http.MethodPut, "/api/v1/users/"+strconv.Itoa(partner.ID), map[string]any{"is_active": true, "role": models.RoleAdmin})
+				superadmin(r)
+				if rec, res := e.do(r); rec.Code != http.StatusOK {
+					t.Fatalf("promote: status %d, code %q", rec.Code, res.Code)
+				}
+			},
+		},
+		{
+			"in the database", func(partner *models.User) {
+				if err := e.Users.UpdateStatus(context.Background(), partner.ID, true, models.RoleAdmin); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				partner := e.newUser(models.RolePartner)
+				key := e.apiKey(partner, models.ScopeCatalogRead)
+				tt.promote(partner)
+				
+				for _, target := range []string{"/api/v1/admin/api-keys", "/api/v1/categories"} {
+					r := newJSONRequest(http.MethodGet, target, nil)
+					key(r)
+					if rec, res := e.do(r); rec.Code != http.StatusUnauthorized {
+						t.Fatalf("%s: got %d %q, want 401", target, rec.Code, res.Code)
+					}
+				}
+			},
+		)
+	}
+}
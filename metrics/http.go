@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+	
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	httpDuration = factory.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time spent serving HTTP requests, by route pattern.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "route", "status"},
+	)
+	httpInFlight = factory.NewGauge(
+		prometheus.GaugeOpts{Name: "http_requests_in_flight", Help: "HTTP requests currently being served."},
+	)
+	httpTimeouts = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_request_timeouts_total",
+			Help: "Requests answered with a timeout because their deadline passed, by route pattern.",
+		},
+		[]string{"method", "route"},
+	)
+)
+
+// RequestTimedOut counts a request that ran past its deadline.
+func RequestTimedOut(r *http.Request, route string) {
+	if route == "" {
+		route = "unmatched"
+	}
+	httpTimeouts.WithLabelValues(methodLabel(r.Method), route).Inc()
+}
+
+// Business events, incremented by the controllers once the change is stored.
+var (
+	BookingsCreated = factory.NewCounterVec(
+		prometheus.CounterOpts{Name: "wisata_bookings_created_total", Help: "Bookings created, by source (user or api_key)."},
+		[]string{"source"},
+	)
+	PaymentsProcessed = factory.NewCounter(
+		prometheus.CounterOpts{Name: "wisata_payments_processed_total", Help: "Bookings marked as paid."},
+	)
+	BookingsCancelled = factory.NewCounter(
+		prometheus.CounterOpts{Name: "wisata_bookings_cancelled_total", Help: "Bookings cancelled by their owner."},
+	)
+	Registrations = factory.NewCounter(
+		prometheus.CounterOpts{Name: "wisata_registrations_total", Help: "Accounts created through registration."},
+	)
+	ReviewsSubmitted = factory.NewCounter(
+		prometheus.CounterOpts{Name: "wisata_reviews_submitted_total", Help: "Reviews submitted for moderation."},
+	)
+)
+
+// RegisterDBStats exposes the connection pool statistics of db.
+func RegisterDBStats(db *sql.DB) {
+	gauge := func(name, help string, fn func(sql.DBStats) float64) {
+		factory.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, func() float64 { return fn(db.Stats()) })
+	}
+	counter := func(name, help string, fn func(sql.DBStats) float64) {
+		factory.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, func() float64 { return fn(db.Stats()) })
+	}
+	
+	gauge("db_max_open_connections", "Maximum number of open connections to the database.",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	gauge("db_open_connections", "Established connections, both in use and idle.",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	gauge("db_in_use_connections", "Connections currently in use.",
+		func(s sql.DBStats) float64 { return float64(s.InUse) })
+	gauge("db_idle_connections", "Idle connections.",
+		func(s sql.DBStats) float64 { return float64(s.Idle) })
+	counter("db_wait_count_total", "Connections waited for because the pool was exhausted.",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	counter("db_wait_duration_seconds_total", "Time blocked waiting for a new connection.",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+	counter("db_max_idle_closed_total", "Connections closed due to max_idle_conns.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })
+	counter("db_max_idle_time_closed_total", "Connections closed due to the idle timeout.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) })
+	counter("db_max_lifetime_closed_total", "Connections closed due to conn_max_lifetime.",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Middleware records the duration of every request, labelled with the
+// matched route pattern instead of the raw path to keep the number of series
+// bounded. The pattern is read from r.Pattern once next returns, so next must
+// set it on the request it was given. The ServeMux does, but a middleware in
+// between that hands the mux a copy of the request hides it; in main that is
+// requestDeadlines, which therefore stores the pattern on its own request.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			httpInFlight.Inc()
+			defer httpInFlight.Dec()
+			
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			route := r.Pattern
+			if route == "" {
+				route = "unmatched"
+			}
+			httpDuration.WithLabelValues(methodLabel(r.Method), route, strconv.Itoa(rec.status)).Observe(time.Since(start).Seconds())
+		},
+	)
+}
+
+// methodLabel folds unknown methods into one series, since clients can send
+// any token as the method.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
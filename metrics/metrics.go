@@ -0,0 +1,38 @@
+// Package metrics exposes the API metrics to Prometheus. Every metric is
+// registered at package level on one registry, which Handler serves.
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	registry = prometheus.NewRegistry()
+	factory  = promauto.With(registry)
+)
+
+// Handler serves the metrics. When token is not empty the scraper must send
+// it as a bearer token.
+func Handler(token string) http.Handler {
+	metrics := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if token != "" {
+				given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+				if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+			}
+			
+			metrics.ServeHTTP(w, r)
+		},
+	)
+}
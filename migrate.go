@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	
+	"backend-wisata/config"
+	"backend-wisata/migrations"
+)
+
+// runMigrate implements `migrate up`, `migrate down [steps]` and
+// `migrate status`.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up | down [steps] | status")
+	}
+	
+	ctx := context.Background()
+	
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(ctx, config.DB)
+		for _, m := range applied {
+			fmt.Printf("applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		
+		reverted, err := migrations.Down(ctx, config.DB, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("nothing to roll back")
+		}
+	
+	case "status":
+		list, err := migrations.StatusList(ctx, config.DB)
+		if err != nil {
+			return err
+		}
+		for _, s := range list {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-24s %s\n", s.Version, s.Name, applied)
+		}
+	
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+	
+	return nil
+}
@@ -0,0 +1,326 @@
+// Package migrations applies the versioned SQL files embedded in the binary.
+// Files live in sql/ and are named <version>_<name>.up.sql with a matching
+// .down.sql; the applied versions are recorded in schema_migrations.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockID is the Postgres advisory lock that keeps two processes from
+// migrating the same database at once.
+const lockID = 7243911
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// ErrSchemaBehind is returned by Check when migrations are pending.
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+// All returns the embedded migrations ordered by version.
+func All() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+	
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := cutDirection(name)
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>.up.sql or .down.sql", name)
+		}
+		
+		versionPart, label, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionPart)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", name)
+		}
+		
+		body, err := fs.ReadFile(files, path.Join("sql", name))
+		if err != nil {
+			return nil, err
+		}
+		
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, label)
+		}
+		
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+	
+	all := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		all = append(all, *m)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	
+	return all, nil
+}
+
+func cutDirection(name string) (string, string, bool) {
+	if base, ok := strings.CutSuffix(name, ".up.sql"); ok {
+		return base, "up", true
+	}
+	if base, ok := strings.CutSuffix(name, ".down.sql"); ok {
+		return base, "down", true
+	}
+	return "", "", false
+}
+
+// Latest is the version the binary expects.
+func Latest() int {
+	all, err := All()
+	if err != nil || len(all) == 0 {
+		return 0
+	}
+	return all[len(all)-1].Version
+}
+
+func ensureTable(ctx context.Context, q interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}) error {
+	_, err := q.ExecContext(
+		ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT        NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`,
+	)
+	return err
+}
+
+// Current returns the highest applied version, or 0 on an empty database.
+func Current(ctx context.Context, db *sql.DB) (int, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists)
+	if err != nil || !exists {
+		return 0, err
+	}
+	
+	var version int
+	err = db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+// Check returns ErrSchemaBehind when the database is older than the binary.
+// A newer database is accepted so an old binary keeps running during a
+// rolling deploy.
+func Check(ctx context.Context, db *sql.DB) (int, error) {
+	current, err := Current(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	if current < Latest() {
+		return current, fmt.Errorf("%w: at version %d, binary expects %d", ErrSchemaBehind, current, Latest())
+	}
+	return current, nil
+}
+
+// StatusList reports every known migration and when it was applied.
+func StatusList(ctx context.Context, db *sql.DB) ([]Status, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+	
+	applied := map[int]time.Time{}
+	current, err := Current(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if current > 0 {
+		rows, err := db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		
+		for rows.Next() {
+			var version int
+			var at time.Time
+			if err := rows.Scan(&version, &at); err != nil {
+				return nil, err
+			}
+			applied[version] = at
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	
+	list := make([]Status, 0, len(all))
+	for _, m := range all {
+		s := Status{Version: m.Version, Name: m.Name}
+		if at, ok := applied[m.Version]; ok {
+			s.AppliedAt = &at
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and
+// returns the ones it applied.
+func Up(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+	
+	var done []Migration
+	err = withLock(
+		ctx, db, func(conn *sql.Conn) error {
+			if err := ensureTable(ctx, conn); err != nil {
+				return err
+			}
+			
+			applied, err := appliedVersions(ctx, conn)
+			if err != nil {
+				return err
+			}
+			
+			for _, m := range all {
+				if applied[m.Version] {
+					continue
+				}
+				if err := apply(
+					ctx, conn, m.Up,
+					"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name,
+				); err != nil {
+					return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+				}
+				done = append(done, m)
+			}
+			return nil
+		},
+	)
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations, newest first.
+func Down(ctx context.Context, db *sql.DB, steps int) ([]Migration, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+	
+	var done []Migration
+	err = withLock(
+		ctx, db, func(conn *sql.Conn) error {
+			if err := ensureTable(ctx, conn); err != nil {
+				return err
+			}
+			
+			applied, err := appliedVersions(ctx, conn)
+			if err != nil {
+				return err
+			}
+			
+			for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+				m := all[i]
+				if !applied[m.Version] {
+					continue
+				}
+				if m.Down == "" {
+					return fmt.Errorf("migration %d_%s cannot be rolled back: no down file", m.Version, m.Name)
+				}
+				if err := apply(
+					ctx, conn, m.Down,
+					"DELETE FROM schema_migrations WHERE version = $1", m.Version,
+				); err != nil {
+					return fmt.Errorf("rollback %d_%s: %w", m.Version, m.Name, err)
+				}
+				done = append(done, m)
+			}
+			return nil
+		},
+	)
+	return done, err
+}
+
+func withLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+	
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]bool, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// apply runs a migration body and its bookkeeping statement atomically.
+func apply(ctx context.Context, conn *sql.Conn, body, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
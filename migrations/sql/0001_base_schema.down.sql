@@ -0,0 +1,10 @@
+DROP VIEW IF EXISTS vw_dashboard_stats;
+DROP TABLE IF EXISTS blog_related_wisata;
+DROP TABLE IF EXISTS blog_posts;
+DROP TABLE IF EXISTS blog_categories;
+DROP TABLE IF EXISTS reviews;
+DROP TABLE IF EXISTS bookings;
+DROP TABLE IF EXISTS wisata_images;
+DROP TABLE IF EXISTS wisata;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,140 @@
+-- Base schema the application was originally written against. Every
+-- statement is idempotent so that databases created before migrations
+-- existed can be brought under version control with `migrate up`.
+
+CREATE TABLE IF NOT EXISTS users (
+    id            SERIAL PRIMARY KEY,
+    uuid          UUID        NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    username      TEXT        NOT NULL UNIQUE,
+    email         TEXT        NOT NULL UNIQUE,
+    password_hash TEXT        NOT NULL,
+    full_name     TEXT        NOT NULL,
+    phone         TEXT,
+    profile_image TEXT,
+    role          TEXT        NOT NULL DEFAULT 'user',
+    is_active     BOOLEAN     NOT NULL DEFAULT TRUE,
+    last_login    TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at    TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS categories (
+    id         SERIAL PRIMARY KEY,
+    name       TEXT        NOT NULL,
+    slug       TEXT        NOT NULL UNIQUE,
+    icon       TEXT,
+    sort_order INTEGER     NOT NULL DEFAULT 0,
+    is_active  BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Wisata are soft-deleted, so a category can be removed while deleted
+-- wisata still point at it.
+CREATE TABLE IF NOT EXISTS wisata (
+    id             SERIAL PRIMARY KEY,
+    uuid           UUID          NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    category_id    INTEGER       REFERENCES categories (id) ON DELETE SET NULL,
+    nama_tempat    TEXT          NOT NULL,
+    slug           TEXT          NOT NULL DEFAULT '',
+    lokasi         TEXT          NOT NULL DEFAULT '',
+    latitude       NUMERIC(9, 6),
+    longitude      NUMERIC(9, 6),
+    alamat_lengkap TEXT,
+    deskripsi      TEXT,
+    fasilitas      TEXT,
+    harga_tiket    NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    rating_total   NUMERIC(3, 2)  NOT NULL DEFAULT 0,
+    total_reviews  INTEGER       NOT NULL DEFAULT 0,
+    is_active      BOOLEAN       NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    deleted_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_wisata_category_id ON wisata (category_id);
+CREATE INDEX IF NOT EXISTS idx_wisata_slug ON wisata (slug);
+
+CREATE TABLE IF NOT EXISTS wisata_images (
+    id         SERIAL PRIMARY KEY,
+    wisata_id  INTEGER     NOT NULL REFERENCES wisata (id) ON DELETE CASCADE,
+    image_url  TEXT        NOT NULL,
+    is_primary BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_wisata_images_wisata_id ON wisata_images (wisata_id);
+
+-- booking_code is generated by the database, e.g. BK250314-3FA9C1.
+CREATE TABLE IF NOT EXISTS bookings (
+    id             SERIAL PRIMARY KEY,
+    booking_code   TEXT           NOT NULL UNIQUE
+        DEFAULT ('BK' || to_char(NOW(), 'YYMMDD') || '-' || upper(substr(md5(gen_random_uuid()::text), 1, 6))),
+    wisata_id      INTEGER        NOT NULL REFERENCES wisata (id),
+    user_id        INTEGER        NOT NULL REFERENCES users (id),
+    visit_date     DATE           NOT NULL,
+    quantity       INTEGER        NOT NULL CHECK (quantity > 0),
+    total_price    NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    final_price    NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    status         TEXT           NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'paid', 'completed', 'cancelled')),
+    payment_method TEXT           NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookings_user_id ON bookings (user_id);
+CREATE INDEX IF NOT EXISTS idx_bookings_wisata_id ON bookings (wisata_id);
+
+CREATE TABLE IF NOT EXISTS reviews (
+    id          SERIAL PRIMARY KEY,
+    wisata_id   INTEGER     NOT NULL REFERENCES wisata (id) ON DELETE CASCADE,
+    user_id     INTEGER     NOT NULL REFERENCES users (id),
+    rating      INTEGER     NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment     TEXT        NOT NULL DEFAULT '',
+    is_approved BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_wisata_id ON reviews (wisata_id);
+
+CREATE TABLE IF NOT EXISTS blog_categories (
+    id   SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS blog_posts (
+    id               SERIAL PRIMARY KEY,
+    title            TEXT        NOT NULL,
+    slug             TEXT        NOT NULL UNIQUE,
+    excerpt          TEXT        NOT NULL DEFAULT '',
+    content          TEXT        NOT NULL DEFAULT '',
+    thumbnail        TEXT,
+    author_id        INTEGER     REFERENCES users (id) ON DELETE SET NULL,
+    blog_category_id INTEGER     REFERENCES blog_categories (id) ON DELETE SET NULL,
+    status           TEXT        NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'published')),
+    published_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS blog_related_wisata (
+    blog_post_id INTEGER NOT NULL REFERENCES blog_posts (id) ON DELETE CASCADE,
+    wisata_id    INTEGER NOT NULL REFERENCES wisata (id) ON DELETE CASCADE,
+    PRIMARY KEY (blog_post_id, wisata_id)
+);
+
+-- Numbers shown on the admin dashboard. Visitors and revenue only count
+-- bookings that were paid for.
+CREATE OR REPLACE VIEW vw_dashboard_stats AS
+SELECT
+    (SELECT COUNT(*) FROM wisata WHERE deleted_at IS NULL) AS total_wisata,
+    (SELECT COUNT(*) FROM wisata WHERE deleted_at IS NULL AND is_active) AS active_wisata,
+    (SELECT COUNT(*) FROM users WHERE deleted_at IS NULL AND role = 'user') AS total_users,
+    (SELECT COALESCE(SUM(quantity), 0) FROM bookings WHERE status IN ('paid', 'completed')) AS total_visitors,
+    (SELECT COALESCE(SUM(final_price), 0) FROM bookings WHERE status IN ('paid', 'completed')) AS total_revenue,
+    (SELECT COUNT(*) FROM bookings) AS total_bookings,
+    (SELECT COALESCE(ROUND(AVG(rating), 2), 0) FROM reviews WHERE is_approved) AS average_rating;
@@ -0,0 +1,17 @@
+-- Server-side sessions used by config.PGStore.
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id           BIGSERIAL PRIMARY KEY,
+    token        TEXT        NOT NULL UNIQUE,
+    name         TEXT        NOT NULL,
+    user_id      INTEGER     REFERENCES users (id) ON DELETE CASCADE,
+    data         TEXT        NOT NULL,
+    user_agent   TEXT        NOT NULL DEFAULT '',
+    ip_address   TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ NOT NULL,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_expires_at ON user_sessions (expires_at);
@@ -0,0 +1,8 @@
+-- Failed login counters for Login. Keys are "user:<id>", "login:<identifier>"
+-- for unknown accounts and "ip:<address>".
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key             TEXT PRIMARY KEY,
+    failures        INTEGER     NOT NULL DEFAULT 0,
+    locked_until    TIMESTAMPTZ,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
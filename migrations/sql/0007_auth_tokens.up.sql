@@ -0,0 +1,18 @@
+-- Bearer access/refresh token pairs for mobile and third-party clients.
+-- Only SHA-256 hashes of the tokens are stored.
+CREATE TABLE IF NOT EXISTS auth_tokens (
+    id                 BIGSERIAL PRIMARY KEY,
+    user_id            INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    access_hash        TEXT        NOT NULL UNIQUE,
+    refresh_hash       TEXT        NOT NULL UNIQUE,
+    access_expires_at  TIMESTAMPTZ NOT NULL,
+    refresh_expires_at TIMESTAMPTZ NOT NULL,
+    user_agent         TEXT        NOT NULL DEFAULT '',
+    ip_address         TEXT        NOT NULL DEFAULT '',
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at         TIMESTAMPTZ,
+    -- Set when the refresh token was exchanged; seeing it again means reuse.
+    rotated_at         TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_tokens_user_id ON auth_tokens (user_id);
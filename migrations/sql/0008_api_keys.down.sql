@@ -0,0 +1,2 @@
+ALTER TABLE bookings DROP COLUMN IF EXISTS api_key_id;
+DROP TABLE IF EXISTS api_keys;
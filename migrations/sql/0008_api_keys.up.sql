@@ -0,0 +1,19 @@
+-- API keys for partner integrations. Only the SHA-256 of the key is stored;
+-- prefix keeps the first characters so admins can tell keys apart.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id                    SERIAL PRIMARY KEY,
+    user_id               INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name                  TEXT        NOT NULL DEFAULT '',
+    prefix                TEXT        NOT NULL,
+    key_hash              TEXT        NOT NULL UNIQUE,
+    -- Comma-separated, e.g. 'catalog:read,booking:write'.
+    scopes                TEXT        NOT NULL DEFAULT '',
+    rate_limit_per_minute INTEGER     NOT NULL DEFAULT 60,
+    last_used_at          TIMESTAMPTZ,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at            TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS api_key_id INTEGER REFERENCES api_keys (id) ON DELETE SET NULL;
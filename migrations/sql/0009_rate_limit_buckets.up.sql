@@ -0,0 +1,11 @@
+-- Token buckets for the Postgres rate limit backend. UNLOGGED because the
+-- contents are disposable and written on every limited request; full_at is
+-- when the bucket refilled completely and can be swept.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key        TEXT PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL,
+    full_at    TIMESTAMPTZ      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_full_at ON rate_limit_buckets (full_at);
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	ScopeCatalogRead  = "catalog:read"
+	ScopeBookingRead  = "booking:read"
+	ScopeBookingWrite = "booking:write"
+)
+
+// APIScopes lists every scope an API key can be granted. The oneof rule on
+// the scopes of CreateAPIKey repeats this list.
+var APIScopes = []string{ScopeCatalogRead, ScopeBookingRead, ScopeBookingWrite}
+
+type APIKey struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	PartnerName string     `json:"partner_name,omitempty"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Scopes      []string   `json:"scopes"`
+	RateLimit   int        `json:"rate_limit_per_minute"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
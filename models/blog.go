@@ -15,6 +15,7 @@ type BlogPost struct {
 	Excerpt       string          `json:"excerpt"`
 	Content       string          `json:"content"`
 	Thumbnail     string          `json:"thumbnail"`
+	AuthorID      int             `json:"-"`
 	AuthorName    string          `json:"author_name"`
 	CategoryID    int             `json:"category_id"`
 	CategoryName  string          `json:"category_name"`
@@ -22,4 +23,7 @@ type BlogPost struct {
 	PublishedAt   string          `json:"published_at"`
 	CreatedAt     time.Time       `json:"created_at"`
 	RelatedWisata []PopularWisata `json:"related_wisata,omitempty"`
+	// Published is the time behind PublishedAt, which handlers format
+	// for display.
+	Published time.Time `json:"-"`
 }
@@ -11,6 +11,9 @@ type Category struct {
 	Icon      *string `json:"icon"`
 	SortOrder int     `json:"sort_order"`
 	IsActive  bool    `json:"is_active"`
+	// WisataCount is the number of wisata in the category that are not
+	// deleted.
+	WisataCount int `json:"count"`
 }
 
 type Wisata struct {
@@ -40,6 +43,8 @@ type Booking struct {
 	UserID        int       `json:"user_id"`
 	VisitDate     string    `json:"visit_date"`
 	WisataNama    string    `json:"wisata_nama,omitempty"`
+	WisataImage   string    `json:"wisata_image,omitempty"`
+	UserName      string    `json:"user_name,omitempty"`
 	Quantity      int       `json:"quantity"`
 	TotalPrice    float64   `json:"total_price"`
 	FinalPrice    float64   `json:"final_price"`
@@ -50,6 +55,11 @@ type Booking struct {
 
 type Response struct {
 	Status  int         `json:"status"`
+	Code    string      `json:"code,omitempty"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
+	// Details lists the problems per input field of a rejected request.
+	Details map[string][]string `json:"details,omitempty"`
+	// RequestID is set on error responses.
+	RequestID string `json:"request_id,omitempty"`
 }
@@ -8,6 +8,8 @@ type Review struct {
 	UserID      int       `json:"user_id"`
 	UserName    string    `json:"user_name,omitempty"`
 	UserProfile *string   `json:"user_profile,omitempty"`
+	WisataName  string    `json:"wisata_name,omitempty"`
+	IsApproved  bool      `json:"-"`
 	Rating      int       `json:"rating"`
 	Comment     string    `json:"comment"`
 	CreatedAt   time.Time `json:"created_at"`
@@ -4,6 +4,14 @@ import (
 	"time"
 )
 
+const (
+	RoleUser       = "user"
+	RoleAdmin      = "admin"
+	RoleSuperadmin = "superadmin"
+	// RolePartner is a travel-agent account that integrates through API keys.
+	RolePartner = "partner"
+)
+
 type User struct {
 	ID           int        `json:"id"`
 	UUID         string     `json:"uuid"`
@@ -15,19 +23,58 @@ type User struct {
 	ProfileImage *string    `json:"profile_image"`
 	Role         string     `json:"role"`
 	IsActive     bool       `json:"is_active"`
+	TwoFactor    bool       `json:"two_factor_enabled"`
 	LastLogin    *time.Time `json:"last_login"`
 	CreatedAt    time.Time  `json:"created_at"`
+	// EmailVerifiedAt is only loaded for the login checks.
+	EmailVerifiedAt *time.Time `json:"-"`
+}
+
+// IsAdmin reports whether the user may use the admin endpoints.
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin || u.Role == RoleSuperadmin
 }
 
 type RegisterInput struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	FullName string `json:"full_name"`
-	Phone    string `json:"phone"`
+	Username string `json:"username" validate:"required,min=3,max=50"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	// bcrypt refuses passwords longer than 72 bytes.
+	Password string `json:"password" validate:"required,min=8,maxbytes=72"`
+	FullName string `json:"full_name" validate:"required,max=100"`
+	Phone    string `json:"phone" validate:"phone"`
 }
 
 type LoginInput struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// UserSession is one logged-in device as shown in the "active sessions" list.
+type UserSession struct {
+	ID         int       `json:"id"`
+	Device     string    `json:"device"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// Lockout is a login throttle key that is currently locked. UserID, FullName
+// and Email are only set for keys that belong to an existing account.
+type Lockout struct {
+	Key           string    `json:"key"`
+	Failures      int       `json:"failures"`
+	LockedUntil   time.Time `json:"locked_until"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+	UserID        *int      `json:"user_id"`
+	FullName      *string   `json:"full_name"`
+	Email         *string   `json:"email"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
 }
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+)
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so every node
+// shares the same limits. Time is taken from the database clock to stay
+// consistent across nodes.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO rate_limit_buckets (key, tokens, updated_at, full_at)
+		VALUES ($1, $2, clock_timestamp(), clock_timestamp())
+		ON CONFLICT (key) DO NOTHING`,
+		key, limit.burst(),
+	)
+	if err != nil {
+		return Result{}, err
+	}
+	
+	// The row lock taken by the subquery serialises concurrent requests for
+	// the same key; each one sees the tokens left by the previous one.
+	var available float64
+	err = s.db.QueryRowContext(
+		ctx, `
+		UPDATE rate_limit_buckets b
+		SET tokens = CASE WHEN o.available >= 1 THEN o.available - 1 ELSE o.available END,
+			updated_at = clock_timestamp(),
+			full_at = clock_timestamp() + make_interval(
+				secs => ($2::float8 - CASE WHEN o.available >= 1 THEN o.available - 1 ELSE o.available END) / $3::float8
+			)
+		FROM (
+			SELECT key, LEAST(
+				$2::float8,
+				tokens + GREATEST(EXTRACT(EPOCH FROM clock_timestamp() - updated_at)::float8, 0) * $3::float8
+			) AS available
+			FROM rate_limit_buckets WHERE key = $1
+			FOR UPDATE
+		) o
+		WHERE b.key = o.key
+		RETURNING o.available`,
+		key, limit.burst(), limit.rate(),
+	).Scan(&available)
+	if err != nil {
+		return Result{}, err
+	}
+	
+	res, _ := result(available, limit)
+	return res, nil
+}
+
+func (s *PostgresStore) Sweep(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE full_at < NOW()")
+	return err
+}
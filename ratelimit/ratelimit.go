@@ -0,0 +1,114 @@
+// Package ratelimit implements token-bucket rate limits with an in-memory
+// store for a single node and a Postgres store shared by several nodes.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Limit allows Requests per Per on average with bursts of up to Burst
+// requests. Burst defaults to Requests.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// rate is the refill speed in tokens per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Result describes the bucket after a Take.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until the next request is allowed; zero when
+	// Allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store takes one token from the bucket identified by key.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Sweep forgets buckets that refilled completely.
+	Sweep(ctx context.Context) error
+}
+
+// result turns the tokens available before the request into a Result and
+// returns the tokens left afterwards.
+func result(available float64, limit Limit) (Result, float64) {
+	res := Result{Limit: int(limit.burst())}
+	left := available
+	if available >= 1 {
+		res.Allowed = true
+		left = available - 1
+	} else {
+		res.RetryAfter = seconds((1 - available) / limit.rate())
+	}
+	res.Remaining = int(math.Floor(left))
+	res.Reset = seconds((limit.burst() - left) / limit.rate())
+	return res, left
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// SetHeaders writes the X-RateLimit-* headers, and Retry-After when the
+// request was refused. Durations are rounded up to whole seconds.
+func SetHeaders(w http.ResponseWriter, res Result) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	if !res.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(res.RetryAfter), 1)))
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// StartSweeper periodically calls store.Sweep. The returned function stops
+// it and waits for a running sweep to finish.
+func StartSweeper(store Store, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ticker.C:
+				if err := store.Sweep(context.Background()); err != nil {
+					slog.Error("rate limit sweep failed", "err", err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
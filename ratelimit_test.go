@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	
+	"backend-wisata/config"
+	"backend-wisata/ratelimit"
+)
+
+// withRules rebuilds the route table with the given rate-limit rules, which
+// newRouteEnv leaves empty.
+func (e *routeEnv) withRules(rules map[string]config.RateLimitRule) {
+	mux := http.NewServeMux()
+	registerRoutes(mux, e.Handler, &rateLimiter{store: ratelimit.NewMemoryStore(), rules: rules})
+	e.router = unmatchedErrors(mux)
+}
+
+func TestEmailRateLimit(t *testing.T) {
+	tests := []struct {
+		target string
+		ipRule string
+		rule   string
+	}{
+		{"/api/v1/password/forgot", "password_forgot", "password_forgot_email"},
+		{"/api/password/forgot", "password_forgot", "password_forgot_email"},
+		{"/api/v1/email/resend", "email_resend", "email_resend_email"},
+		{"/api/email/resend", "email_resend", "email_resend_email"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.target, func(t *testing.T) {
+				e := newRouteEnv(t)
+				e.withRules(
+					map[string]config.RateLimitRule{
+						tt.ipRule: {Requests: 4, Per: time.Hour, By: "ip"},
+						tt.rule:   {Requests: 2, Per: time.Hour, By: "email"},
+					},
+				)
+				
+				// The steps run in order against the same buckets. Unknown
+				// addresses get the same answer without sending mail. A request turned
+				// away by the email rule still counts against the IP.
+				steps := []struct {
+					email  string
+					status int
+				}{
+					{"korban@example.test", http.StatusOK},
+					{"KORBAN@example.test", http.StatusOK},
+					{"korban@example.test", http.StatusTooManyRequests},
+					{"lain@example.test", http.StatusOK},
+					{"ketiga@example.test", http.StatusTooManyRequests},
+				}
+				for i, step := range steps {
+					rec, res := e.do(newJSONRequest(http.MethodPost, tt.target, map[string]string{"email": step.email}))
+					code := ""
+					if step.status == http.StatusTooManyRequests {
+						code = "rate_limited"
+					}
+					if rec.Code != step.status || res.Code != code {
+						t.Fatalf("request %d for %q: got %d %q, want %d %q", i, step.email, rec.Code, res.Code, step.status, code)
+					}
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	
+	"backend-wisata/controllers"
+	"backend-wisata/models"
+)
+
+// legacyDeprecation is sent in the Deprecation header (RFC 9745) of the
+// pre-/api/v1 paths: the date /api/v1 replaced them.
+const legacyDeprecation = "@1792281600"
+
+// registerRoutes sets up the /api/v1 route table and the deprecated paths
+// it replaces. Methods are part of every pattern, so ServeMux answers other
+// methods with 405 and an Allow header.
+func registerRoutes(mux *http.ServeMux, h *controllers.Handler, limiter *rateLimiter) {
+	authenticated := requireRole(h, models.RoleUser, models.RoleAdmin, models.RoleSuperadmin)
+	adminOnly := requireRole(h, models.RoleAdmin, models.RoleSuperadmin)
+	partnerAccess := requireRole(h, models.RoleUser, models.RoleAdmin, models.RoleSuperadmin, models.RolePartner)
+	enrolling := requireRoleDuringSetup(h, models.RoleUser, models.RoleAdmin, models.RoleSuperadmin)
+	sessionOptional := requireCSRF(h)
+	
+	catalogRead := requireScope(h, models.ScopeCatalogRead)
+	bookingRead := requireScope(h, models.ScopeBookingRead)
+	bookingWrite := requireScope(h, models.ScopeBookingWrite)
+	
+	mux.HandleFunc("GET /healthz", controllers.Healthz)
+	mux.HandleFunc("GET /readyz", h.Readyz)
+	
+	mux.HandleFunc("POST /api/v1/login", limiter.limit("login")(h.Login))
+	mux.HandleFunc("POST /api/v1/login/2fa", limiter.limit("login")(h.VerifyTwoFactorLogin))
+	mux.HandleFunc("POST /api/v1/logout", sessionOptional(h.Logout))
+	mux.HandleFunc("POST /api/v1/token", limiter.limit("login")(h.IssueToken))
+	mux.HandleFunc("POST /api/v1/token/refresh", h.RefreshToken)
+	mux.HandleFunc("POST /api/v1/register", limiter.limit("register")(h.Register))
+	mux.HandleFunc("GET /api/v1/email/verify", h.VerifyEmail)
+	mux.HandleFunc("POST /api/v1/email/verify", h.VerifyEmail)
+	mux.HandleFunc("POST /api/v1/email/resend", limiter.limit("email_resend")(limiter.limit("email_resend_email")(h.ResendVerification)))
+	mux.HandleFunc("POST /api/v1/password/forgot", limiter.limit("password_forgot")(limiter.limit("password_forgot_email")(h.ForgotPassword)))
+	mux.HandleFunc("POST /api/v1/password/reset", h.ResetPassword)
+	mux.HandleFunc("GET /api/v1/me", enrolling(h.GetMe))
+	mux.HandleFunc("POST /api/v1/2fa/setup", enrolling(h.SetupTwoFactor))
+	mux.HandleFunc("POST /api/v1/2fa/confirm", enrolling(h.ConfirmTwoFactor))
+	mux.HandleFunc("POST /api/v1/2fa/recovery-codes", authenticated(h.RegenerateRecoveryCodes))
+	mux.HandleFunc("POST /api/v1/2fa/disable", authenticated(h.DisableTwoFactor))
+	mux.HandleFunc("GET /api/v1/profile", authenticated(h.GetProfile))
+	mux.HandleFunc("PUT /api/v1/profile", authenticated(h.UpdateProfile))
+	mux.HandleFunc("GET /api/v1/sessions", authenticated(h.GetMySessions))
+	mux.HandleFunc("DELETE /api/v1/sessions/{id}", authenticated(h.RevokeMySession))
+	mux.HandleFunc("POST /api/v1/sessions/revoke-others", authenticated(h.RevokeOtherSessions))
+	
+	mux.HandleFunc("GET /api/v1/wisata", catalogRead(h.GetAllWisata))
+	mux.HandleFunc("GET /api/v1/wisata/{id}", catalogRead(h.GetWisataDetail))
+	mux.HandleFunc("POST /api/v1/wisata", adminOnly(h.CreateWisata))
+	mux.HandleFunc("PUT /api/v1/wisata/{id}", adminOnly(h.UpdateWisata))
+	mux.HandleFunc("DELETE /api/v1/wisata/{id}", adminOnly(h.DeleteWisata))
+	
+	mux.HandleFunc("GET /api/v1/categories", catalogRead(h.GetAllCategories))
+	mux.HandleFunc("POST /api/v1/categories", adminOnly(h.CreateCategory))
+	mux.HandleFunc("PUT /api/v1/categories/{id}", adminOnly(h.UpdateCategory))
+	mux.HandleFunc("DELETE /api/v1/categories/{id}", adminOnly(h.DeleteCategory))
+	
+	mux.HandleFunc("POST /api/v1/bookings", partnerAccess(bookingWrite(limiter.limit("booking_create")(h.CreateBooking))))
+	mux.HandleFunc("GET /api/v1/bookings", partnerAccess(bookingRead(h.GetBookingHistory)))
+	mux.HandleFunc("GET /api/v1/bookings/{code}", partnerAccess(bookingRead(h.GetBookingDetail)))
+	mux.HandleFunc("POST /api/v1/bookings/{code}/pay", authenticated(h.ProcessPayment))
+	mux.HandleFunc("POST /api/v1/bookings/{code}/cancel", authenticated(h.CancelBooking))
+	
+	mux.HandleFunc("GET /api/v1/dashboard/stats", adminOnly(h.GetDashboardStats))
+	mux.HandleFunc("GET /api/v1/dashboard/recent-bookings", adminOnly(h.GetRecentBookings))
+	mux.HandleFunc("GET /api/v1/dashboard/popular-wisata", adminOnly(h.GetPopularWisata))
+	
+	mux.HandleFunc("GET /api/v1/users", adminOnly(h.GetAllUsers))
+	mux.HandleFunc("PUT /api/v1/users/{id}", adminOnly(h.UpdateUserStatus))
+	mux.HandleFunc("DELETE /api/v1/users/{id}", adminOnly(h.DeleteUser))
+	mux.HandleFunc("GET /api/v1/users/lockouts", adminOnly(h.GetLockouts))
+	mux.HandleFunc("POST /api/v1/users/lockouts/unlock", adminOnly(h.UnlockUser))
+	mux.HandleFunc("POST /api/v1/users/{id}/unlock", adminOnly(h.UnlockUser))
+	
+	mux.HandleFunc("POST /api/v1/reviews", authenticated(limiter.limit("review_submit")(h.SubmitReview)))
+	mux.HandleFunc("GET /api/v1/reviews", h.GetReviews)
+	
+	mux.HandleFunc("GET /api/v1/admin/reviews", adminOnly(h.GetAdminReviews))
+	mux.HandleFunc("POST /api/v1/admin/reviews/{id}/approve", adminOnly(h.ApproveReview))
+	mux.HandleFunc("DELETE /api/v1/admin/reviews/{id}", adminOnly(h.DeleteReview))
+	mux.HandleFunc("GET /api/v1/admin/bookings", adminOnly(h.GetAllBookings))
+	mux.HandleFunc("GET /api/v1/admin/api-keys", adminOnly(h.GetAPIKeys))
+	mux.HandleFunc("POST /api/v1/admin/api-keys", adminOnly(h.CreateAPIKey))
+	mux.HandleFunc("DELETE /api/v1/admin/api-keys/{id}", adminOnly(h.RevokeAPIKey))
+	
+	mux.HandleFunc("GET /api/v1/blog/posts", h.GetBlogPosts)
+	mux.HandleFunc("GET /api/v1/blog/posts/{slug}", h.GetBlogDetail)
+	mux.HandleFunc("GET /api/v1/blog/categories", h.GetBlogCategories)
+	mux.HandleFunc("POST /api/v1/blog/posts", adminOnly(h.CreateBlogPost))
+	mux.HandleFunc("PUT /api/v1/blog/posts/{id}", adminOnly(h.UpdateBlogPost))
+	mux.HandleFunc("DELETE /api/v1/blog/posts/{id}", adminOnly(h.DeleteBlogPost))
+	
+	// Deprecated paths, kept with the methods they used to accept. IDs are
+	// read from the query string, see controllers.pathParam.
+	legacy := func(methods, path string, handler http.HandlerFunc) {
+		for _, method := range strings.Fields(methods) {
+			mux.HandleFunc(method+" "+path, deprecated(handler))
+		}
+	}
+	
+	legacy("POST", "/api/login", limiter.limit("login")(h.Login))
+	legacy("POST", "/api/login/2fa", limiter.limit("login")(h.VerifyTwoFactorLogin))
+	legacy("POST", "/api/logout", sessionOptional(h.Logout))
+	legacy("POST", "/api/token", limiter.limit("login")(h.IssueToken))
+	legacy("POST", "/api/token/refresh", h.RefreshToken)
+	legacy("POST", "/api/register", limiter.limit("register")(h.Register))
+	legacy("GET POST", "/api/email/verify", h.VerifyEmail)
+	legacy("POST", "/api/email/resend", limiter.limit("email_resend")(limiter.limit("email_resend_email")(h.ResendVerification)))
+	legacy("POST", "/api/password/forgot", limiter.limit("password_forgot")(limiter.limit("password_forgot_email")(h.ForgotPassword)))
+	legacy("POST", "/api/password/reset", h.ResetPassword)
+	legacy("GET", "/api/me", enrolling(h.GetMe))
+	legacy("POST", "/api/2fa/setup", enrolling(h.SetupTwoFactor))
+	legacy("POST", "/api/2fa/confirm", enrolling(h.ConfirmTwoFactor))
+	legacy("POST", "/api/2fa/recovery-codes", authenticated(h.RegenerateRecoveryCodes))
+	legacy("POST", "/api/2fa/disable", authenticated(h.DisableTwoFactor))
+	legacy("GET", "/api/profile", authenticated(h.GetProfile))
+	legacy("POST PUT", "/api/profile/update", authenticated(h.UpdateProfile))
+	legacy("GET", "/api/sessions", authenticated(h.GetMySessions))
+	legacy("POST DELETE", "/api/sessions/revoke", authenticated(h.RevokeMySession))
+	legacy("POST", "/api/sessions/revoke-others", authenticated(h.RevokeOtherSessions))
+	
+	legacy("GET", "/api/wisata", catalogRead(h.GetAllWisata))
+	legacy("GET", "/api/wisata/detail", catalogRead(h.GetWisataDetail))
+	legacy("POST", "/api/wisata/create", adminOnly(h.CreateWisata))
+	legacy("PUT POST", "/api/wisata/update", adminOnly(h.UpdateWisata))
+	legacy("DELETE POST", "/api/wisata/delete", adminOnly(h.DeleteWisata))
+	
+	legacy("GET", "/api/categories", catalogRead(h.GetAllCategories))
+	legacy("POST", "/api/categories/create", adminOnly(h.CreateCategory))
+	legacy("PUT POST", "/api/categories/update", adminOnly(h.UpdateCategory))
+	legacy("DELETE POST", "/api/categories/delete", adminOnly(h.DeleteCategory))
+	
+	legacy("POST", "/api/booking/create", partnerAccess(bookingWrite(limiter.limit("booking_create")(h.CreateBooking))))
+	legacy("GET", "/api/booking/history", partnerAccess(bookingRead(h.GetBookingHistory)))
+	legacy("GET", "/api/booking/detail", partnerAccess(bookingRead(h.GetBookingDetail)))
+	legacy("POST", "/api/booking/pay", authenticated(h.ProcessPayment))
+	legacy("POST", "/api/booking/cancel", authenticated(h.CancelBooking))
+	
+	legacy("GET", "/api/dashboard/stats", adminOnly(h.GetDashboardStats))
+	legacy("GET", "/api/dashboard/recent-bookings", adminOnly(h.GetRecentBookings))
+	legacy("GET", "/api/dashboard/popular-wisata", adminOnly(h.GetPopularWisata))
+	
+	legacy("GET", "/api/users", adminOnly(h.GetAllUsers))
+	legacy("PUT", "/api/users/update", adminOnly(h.UpdateUserStatus))
+	legacy("DELETE POST", "/api/users/delete", adminOnly(h.DeleteUser))
+	legacy("GET", "/api/users/lockouts", adminOnly(h.GetLockouts))
+	legacy("POST", "/api/users/unlock", adminOnly(h.UnlockUser))
+	
+	legacy("POST", "/api/reviews/submit", authenticated(limiter.limit("review_submit")(h.SubmitReview)))
+	legacy("GET", "/api/reviews/list", h.GetReviews)
+	
+	legacy("GET", "/api/admin/reviews", adminOnly(h.GetAdminReviews))
+	legacy("POST", "/api/admin/reviews/approve", adminOnly(h.ApproveReview))
+	legacy("DELETE POST", "/api/admin/reviews/delete", adminOnly(h.DeleteReview))
+	legacy("GET", "/api/bookings", adminOnly(h.GetAllBookings))
+	legacy("GET", "/api/admin/api-keys", adminOnly(h.GetAPIKeys))
+	legacy("POST", "/api/admin/api-keys/create", adminOnly(h.CreateAPIKey))
+	legacy("POST DELETE", "/api/admin/api-keys/revoke", adminOnly(h.RevokeAPIKey))
+	
+	legacy("GET", "/api/blog/posts", h.GetBlogPosts)
+	legacy("GET", "/api/blog/detail", h.GetBlogDetail)
+	legacy("GET", "/api/blog/categories", h.GetBlogCategories)
+	legacy("POST", "/api/blog/create", adminOnly(h.CreateBlogPost))
+	legacy("PUT POST", "/api/blog/update", adminOnly(h.UpdateBlogPost))
+	legacy("DELETE POST", "/api/blog/delete", adminOnly(h.DeleteBlogPost))
+}
+
+// deprecated marks a response as coming from a path that /api/v1 replaced.
+func deprecated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", legacyDeprecation)
+		next(w, r)
+	}
+}
+
+// unmatchedErrors replaces ServeMux's plain-text 404 and 405 answers for
+// requests no route matches with the JSON error envelope. ServeMux (or
+// requestDeadlines in front of it) sets r.Pattern before calling a matched
+// handler, so responses from handlers pass through untouched.
+func unmatchedErrors(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(&unmatchedWriter{ResponseWriter: w, r: r}, r)
+	})
+}
+
+type unmatchedWriter struct {
+	http.ResponseWriter
+	r *http.Request
+	// replaced is set once the envelope was written; the plain-text body
+	// that follows is dropped.
+	replaced bool
+}
+
+func (w *unmatchedWriter) WriteHeader(status int) {
+	if w.r.Pattern == "" {
+		switch status {
+		case http.StatusNotFound:
+			w.replaced = true
+			respondError(w.ResponseWriter, status, "Endpoint tidak ditemukan")
+			return
+		case http.StatusMethodNotAllowed:
+			w.replaced = true
+			respondError(w.ResponseWriter, status, "Method tidak diizinkan")
+			return
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *unmatchedWriter) Write(b []byte) (int, error) {
+	if w.replaced {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *unmatchedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
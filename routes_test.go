@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	
+	"backend-wisata/controllers"
+	"backend-wisata/models"
+	"backend-wisata/ratelimit"
+	"backend-wisata/store"
+	
+	"github.com/gorilla/sessions"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "rahasia123"
+
+// routeEnv serves the route table of registerRoutes over in-memory stores,
+// so the middleware runs the way it does in production.
+type routeEnv struct {
+	*controllers.Handler
+	t      *testing.T
+	router http.Handler
+	seq    int
+}
+
+func newRouteEnv(t *testing.T) *routeEnv {
+	t.Helper()
+	h := controllers.NewHandler(
+		store.NewMemory(),
+		sessions.NewCookieStore([]byte("test-admin-session-key-0123456789")),
+		sessions.NewCookieStore([]byte("test-user-session-key-01234567890")),
+	)
+	mux := http.NewServeMux()
+	registerRoutes(mux, h, &rateLimiter{store: ratelimit.NewMemoryStore()})
+	return &routeEnv{Handler: h, t: t, router: unmatchedErrors(mux)}
+}
+
+func (e *routeEnv) newUser(role string) *models.User {
+	e.t.Helper()
+	ctx := context.Background()
+	e.seq++
+	n := strconv.Itoa(e.seq)
+	
+	hash, _ := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	u := &models.User{
+		Username: role + n,
+		Email:    role + n + "@example.test",
+		Password: string(hash),
+		FullName: "Test " + role + " " + n,
+		Role:     role,
+		IsActive: true,
+	}
+	if err := e.Users.Create(ctx, u); err != nil {
+		e.t.Fatal("create user:", err)
+	}
+	if err := e.Users.VerifyEmail(ctx, u.ID, u.Email); err != nil {
+		e.t.Fatal("verify user email:", err)
+	}
+	return u
+}
+
+// login signs the user in through the login route and returns the session
+// cookies with the CSRF token of the session.
+func (e *routeEnv) login(user *models.User) ([]*http.Cookie, string) {
+	e.t.Helper()
+	rec, res := e.do(newJSONRequest(http.MethodPost, "/api/v1/login", map[string]string{"username": user.Username, "password": testPassword}))
+	if rec.Code != http.StatusOK {
+		e.t.Fatalf("login: status %d, code %q", rec.Code, res.Code)
+	}
+	return rec.Result().Cookies(), rec.Header().Get(controllers.CSRFHeader)
+}
+
+func (e *routeEnv) do(r *http.Request) (*httptest.ResponseRecorder, models.Response) {
+	e.t.Helper()
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, r)
+	
+	var res models.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		e.t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+	return rec, res
+}
+
+func newJSONRequest(method, target string, body any) *http.Request {
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+	r := httptest.NewRequest(method, target, bytes.NewReader(b))
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+func TestLogoutCSRF(t *testing.T) {
+	e := newRouteEnv(t)
+	
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		session bool
+		csrf    bool
+		status  int
+		code    string
+	}{
+		{"session with token", http.MethodPost, "/api/v1/logout", true, true, http.StatusOK, ""},
+		{"session without token", http.MethodPost, "/api/v1/logout", true, false, http.StatusForbidden, "csrf_token_invalid"},
+		{"no session", http.MethodPost, "/api/v1/logout", false, false, http.StatusOK, ""},
+		{"legacy with token", http.MethodPost, "/api/logout", true, true, http.StatusOK, ""},
+		{"legacy without token", http.MethodPost, "/api/logout", true, false, http.StatusForbidden, "csrf_token_invalid"},
+		{"legacy GET", http.MethodGet, "/api/logout", true, false, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := newJSONRequest(tt.method, tt.target, nil)
+				if tt.session {
+					cookies, token := e.login(e.newUser(models.RoleUser))
+					for _, c := range cookies {
+						r.AddCookie(c)
+					}
+					if tt.csrf {
+						r.Header.Set(controllers.CSRFHeader, token)
+					}
+				}
+				
+				rec, res := e.do(r)
+				if rec.Code != tt.status || res.Code != tt.code {
+					t.Fatalf("got %d %q, want %d %q (%s)", rec.Code, res.Code, tt.status, tt.code, rec.Body)
+				}
+				
+				// A rejected logout leaves the session alone.
+				if rec.Code != http.StatusOK && tt.session {
+					for _, c := range rec.Result().Cookies() {
+						t.Fatalf("rejected logout set cookie %s", c.Name)
+					}
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	
+	"backend-wisata/config"
+	"backend-wisata/migrations"
+	"backend-wisata/seed"
+)
+
+// runSeed implements `seed [-reset | -remove]`.
+func runSeed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	reset := fs.Bool("reset", false, "remove the seed dataset first, then insert it again")
+	remove := fs.Bool("remove", false, "only remove the seed dataset")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	
+	ctx := context.Background()
+	if _, err := migrations.Check(ctx, config.DB); err != nil {
+		return fmt.Errorf("%w; run `migrate up` first", err)
+	}
+	
+	uploadDir := config.App.Uploads.Dir
+	
+	if *reset || *remove {
+		if err := seed.Remove(ctx, config.DB, uploadDir); err != nil {
+			return fmt.Errorf("remove seed data: %w", err)
+		}
+		fmt.Println("seed data removed")
+		if *remove {
+			return nil
+		}
+	}
+	
+	summary, err := seed.Run(ctx, config.DB, uploadDir, config.App.Uploads.URLPath)
+	if errors.Is(err, seed.ErrAlreadySeeded) {
+		return fmt.Errorf("%w; use `seed -reset` to recreate it", err)
+	} else if err != nil {
+		return err
+	}
+	
+	fmt.Printf(
+		"seeded %d users, %d categories, %d wisata, %d bookings, %d reviews, %d blog posts\n",
+		summary.Users, summary.Categories, summary.Wisata, summary.Bookings, summary.Reviews, summary.BlogPosts,
+	)
+	fmt.Printf("every seeded account uses the password %q\n", seed.Password)
+	return nil
+}
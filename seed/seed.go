@@ -0,0 +1,433 @@
+// Package seed fills a development database with a fixed demo dataset:
+// categories, wisata with images, users, several months of bookings,
+// reviews and blog posts. Every row it creates can be recognised again by
+// its email domain, slug or booking code, so Remove only touches seeded data.
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	
+	"golang.org/x/crypto/bcrypt"
+)
+
+//go:embed images/*.jpg
+var images embed.FS
+
+// Password is the password of every seeded account.
+const Password = "password123"
+
+// imagePrefix marks the files Run copies into the upload directory.
+const imagePrefix = "seed-"
+
+// emailDomain marks seeded accounts; Remove never touches other users.
+const emailDomain = "@seed.wisata.test"
+
+// ErrAlreadySeeded is returned by Run when the dataset is already present.
+var ErrAlreadySeeded = errors.New("database already contains the seed dataset")
+
+// anchor is the reference date of the dataset. Bookings are spread over the
+// six months before it; using a fixed date keeps every run identical.
+var anchor = time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+type user struct {
+	Username, Email, FullName, Phone, Role string
+}
+
+var users = []user{
+	{"superadmin", "superadmin@seed.wisata.test", "Super Admin", "081200000001", "superadmin"},
+	{"admin", "admin@seed.wisata.test", "Admin Wisata", "081200000002", "admin"},
+	{"budi", "budi@seed.wisata.test", "Budi Santoso", "081300000001", "user"},
+	{"siti", "siti@seed.wisata.test", "Siti Rahmawati", "081300000002", "user"},
+	{"andi", "andi@seed.wisata.test", "Andi Pratama", "081300000003", "user"},
+	{"dewi", "dewi@seed.wisata.test", "Dewi Lestari", "081300000004", "user"},
+	{"rina", "rina@seed.wisata.test", "Rina Wulandari", "081300000005", "user"},
+	{"travelku", "partner@seed.wisata.test", "PT Travelku Nusantara", "0215550001", "partner"},
+}
+
+type category struct {
+	Name, Slug, Icon string
+}
+
+var categories = []category{
+	{"Pantai", "seed-pantai", "umbrella"},
+	{"Gunung", "seed-gunung", "mountain"},
+	{"Budaya & Sejarah", "seed-budaya", "landmark"},
+	{"Air Terjun", "seed-air-terjun", "droplets"},
+	{"Taman & Danau", "seed-taman", "trees"},
+}
+
+type wisata struct {
+	Nama, Slug, Category, Lokasi, Deskripsi, Fasilitas, Image string
+	Harga                                                     float64
+}
+
+var wisataList = []wisata{
+	{
+		"Pantai Parangtritis", "seed-pantai-parangtritis", "seed-pantai", "Bantul, Yogyakarta",
+		"Pantai berpasir hitam dengan ombak besar dan pemandangan matahari terbenam.",
+		"Parkir, Toilet, Warung Makan, Sewa ATV", "wisata-1.jpg", 15000,
+	},
+	{
+		"Pantai Kuta", "seed-pantai-kuta", "seed-pantai", "Badung, Bali",
+		"Pantai populer untuk berselancar dan menikmati sunset.",
+		"Parkir, Toilet, Sewa Papan Selancar, Restoran", "wisata-1.jpg", 10000,
+	},
+	{
+		"Gunung Bromo", "seed-gunung-bromo", "seed-gunung", "Probolinggo, Jawa Timur",
+		"Kawah aktif dengan lautan pasir dan panorama matahari terbit.",
+		"Parkir, Sewa Jeep, Sewa Kuda, Penginapan", "wisata-2.jpg", 54000,
+	},
+	{
+		"Candi Borobudur", "seed-candi-borobudur", "seed-budaya", "Magelang, Jawa Tengah",
+		"Candi Buddha terbesar di dunia dan situs warisan dunia UNESCO.",
+		"Parkir, Pemandu, Museum, Toilet", "wisata-3.jpg", 50000,
+	},
+	{
+		"Candi Prambanan", "seed-candi-prambanan", "seed-budaya", "Sleman, Yogyakarta",
+		"Kompleks candi Hindu dengan pertunjukan Sendratari Ramayana.",
+		"Parkir, Pemandu, Panggung Terbuka, Toilet", "wisata-3.jpg", 50000,
+	},
+	{
+		"Air Terjun Tumpak Sewu", "seed-tumpak-sewu", "seed-air-terjun", "Lumajang, Jawa Timur",
+		"Air terjun bertingkat berbentuk tirai dengan latar Gunung Semeru.",
+		"Parkir, Pemandu Lokal, Warung", "wisata-4.jpg", 20000,
+	},
+	{
+		"Danau Toba", "seed-danau-toba", "seed-taman", "Samosir, Sumatera Utara",
+		"Danau vulkanik terbesar di Asia Tenggara dengan Pulau Samosir di tengahnya.",
+		"Kapal Penyeberangan, Penginapan, Restoran", "wisata-5.jpg", 0,
+	},
+	{
+		"Kebun Raya Bogor", "seed-kebun-raya-bogor", "seed-taman", "Bogor, Jawa Barat",
+		"Kebun botani bersejarah dengan ribuan koleksi tanaman.",
+		"Parkir, Toilet, Kafe, Sewa Sepeda", "wisata-6.jpg", 25000,
+	},
+}
+
+type blogCategory struct {
+	Name, Slug string
+}
+
+var blogCategories = []blogCategory{
+	{"Tips Perjalanan", "seed-tips"},
+	{"Destinasi", "seed-destinasi"},
+}
+
+type blogPost struct {
+	Title, Slug, Category, Excerpt, Content, Image string
+	Related                                        []string
+}
+
+var blogPosts = []blogPost{
+	{
+		"5 Tips Mendaki Bromo Saat Musim Hujan", "seed-tips-bromo-musim-hujan", "seed-tips",
+		"Persiapan yang perlu dibawa agar perjalanan ke Bromo tetap aman dan nyaman.",
+		"Bawa jaket tebal, jas hujan, dan sepatu anti selip. Berangkat lebih pagi dan selalu cek kondisi cuaca sebelum naik.",
+		"wisata-2.jpg", []string{"seed-gunung-bromo", "seed-tumpak-sewu"},
+	},
+	{
+		"Rute Candi di Jawa Tengah dan Yogyakarta", "seed-rute-candi-jogja", "seed-destinasi",
+		"Menjelajahi Borobudur dan Prambanan dalam satu akhir pekan.",
+		"Mulai dari Borobudur saat matahari terbit, lanjutkan ke Prambanan di sore hari untuk menonton Sendratari Ramayana.",
+		"wisata-3.jpg", []string{"seed-candi-borobudur", "seed-candi-prambanan"},
+	},
+	{
+		"Pantai Terbaik untuk Menikmati Sunset", "seed-pantai-sunset", "seed-destinasi",
+		"Daftar pantai dengan pemandangan matahari terbenam paling indah.",
+		"Parangtritis dan Kuta menawarkan langit jingga yang memukau. Datang satu jam sebelum matahari terbenam untuk tempat terbaik.",
+		"wisata-1.jpg", []string{"seed-pantai-parangtritis", "seed-pantai-kuta"},
+	},
+}
+
+var reviewComments = []string{
+	"Tempatnya bersih dan pemandangannya luar biasa.",
+	"Worth it, tapi datang pagi supaya tidak terlalu ramai.",
+	"Akses jalan agak sulit, tapi terbayar dengan pemandangannya.",
+	"Pemandunya ramah dan informatif.",
+	"Cocok untuk liburan keluarga.",
+	"Harga tiket sebanding dengan fasilitasnya.",
+}
+
+type Summary struct {
+	Users, Categories, Wisata, Bookings, Reviews, BlogPosts int
+}
+
+// Seeded reports whether the seed dataset is present.
+func Seeded(ctx context.Context, db *sql.DB) (bool, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM wisata WHERE slug = $1)", wisataList[0].Slug).Scan(&exists)
+	return exists, err
+}
+
+// Run inserts the dataset in a single transaction and copies the bundled
+// images into uploadDir. Image paths are stored under urlPath, the same way
+// uploaded files are.
+func Run(ctx context.Context, db *sql.DB, uploadDir, urlPath string) (*Summary, error) {
+	seeded, err := Seeded(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if seeded {
+		return nil, ErrAlreadySeeded
+	}
+	
+	if err := copyImages(uploadDir); err != nil {
+		return nil, err
+	}
+	
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	
+	s := &Summary{}
+	hash, err := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	
+	userIDs := map[string]int{}
+	var customers []int
+	for _, u := range users {
+		var id int
+		err := tx.QueryRowContext(
+			ctx, `
+			INSERT INTO users (username, email, password_hash, full_name, phone, role, is_active, email_verified_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, TRUE, $7, $7)
+			RETURNING id`,
+			u.Username, u.Email, string(hash), u.FullName, u.Phone, u.Role, anchor.AddDate(0, -7, 0),
+		).Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("user %s: %w", u.Username, err)
+		}
+		userIDs[u.Username] = id
+		if u.Role == "user" {
+			customers = append(customers, id)
+		}
+		s.Users++
+	}
+	
+	categoryIDs := map[string]int{}
+	for i, c := range categories {
+		var id int
+		err := tx.QueryRowContext(
+			ctx,
+			"INSERT INTO categories (name, slug, icon, sort_order, is_active) VALUES ($1, $2, $3, $4, TRUE) RETURNING id",
+			c.Name, c.Slug, c.Icon, i+1,
+		).Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("category %s: %w", c.Slug, err)
+		}
+		categoryIDs[c.Slug] = id
+		s.Categories++
+	}
+	
+	wisataIDs := map[string]int{}
+	prices := map[int]float64{}
+	var wisataOrder []int
+	for i, w := range wisataList {
+		var id int
+		err := tx.QueryRowContext(
+			ctx, `
+			INSERT INTO wisata (nama_tempat, slug, category_id, lokasi, harga_tiket, deskripsi, fasilitas, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id`,
+			w.Nama, w.Slug, categoryIDs[w.Category], w.Lokasi, w.Harga, w.Deskripsi, w.Fasilitas,
+			anchor.AddDate(0, -7, i),
+		).Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("wisata %s: %w", w.Slug, err)
+		}
+		
+		_, err = tx.ExecContext(
+			ctx, "INSERT INTO wisata_images (wisata_id, image_url, is_primary) VALUES ($1, $2, TRUE)",
+			id, urlPath+imagePrefix+w.Image,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("wisata image %s: %w", w.Slug, err)
+		}
+		
+		wisataIDs[w.Slug] = id
+		prices[id] = w.Harga
+		wisataOrder = append(wisataOrder, id)
+		s.Wisata++
+	}
+	
+	// A fixed seed makes the "random" bookings and reviews identical on
+	// every run.
+	rng := rand.New(rand.NewPCG(2026, 1))
+	statuses := []string{"completed", "completed", "paid", "paid", "pending", "cancelled"}
+	
+	type visit struct{ userID, wisataID int }
+	reviewed := map[visit]bool{}
+	
+	for month := 5; month >= 0; month-- {
+		perMonth := 6 + rng.IntN(5)
+		for i := 0; i < perMonth; i++ {
+			userID := customers[rng.IntN(len(customers))]
+			wisataID := wisataOrder[rng.IntN(len(wisataOrder))]
+			quantity := 1 + rng.IntN(4)
+			status := statuses[rng.IntN(len(statuses))]
+			visitDate := anchor.AddDate(0, -month, -rng.IntN(28))
+			createdAt := visitDate.AddDate(0, 0, -(3 + rng.IntN(10)))
+			price := prices[wisataID] * float64(quantity)
+			
+			s.Bookings++
+			_, err := tx.ExecContext(
+				ctx, `
+				INSERT INTO bookings (booking_code, wisata_id, user_id, visit_date, quantity, total_price, final_price,
+					status, payment_method, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $6, $7, $8, $9, $9)`,
+				fmt.Sprintf("SEED-%04d", s.Bookings), wisataID, userID, visitDate.Format("2006-01-02"), quantity,
+				price, status, []string{"transfer", "qris", "ewallet"}[rng.IntN(3)], createdAt,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("booking %d: %w", s.Bookings, err)
+			}
+			
+			// Only visitors can review, once per place; some reviews wait
+			// for moderation.
+			v := visit{userID, wisataID}
+			if (status == "completed" || status == "paid") && !reviewed[v] && rng.IntN(3) > 0 {
+				reviewed[v] = true
+				_, err := tx.ExecContext(
+					ctx, `
+					INSERT INTO reviews (wisata_id, user_id, rating, comment, is_approved, created_at)
+					VALUES ($1, $2, $3, $4, $5, $6)`,
+					wisataID, userID, 3+rng.IntN(3), reviewComments[rng.IntN(len(reviewComments))],
+					rng.IntN(4) > 0, visitDate.AddDate(0, 0, 1),
+				)
+				if err != nil {
+					return nil, fmt.Errorf("review: %w", err)
+				}
+				s.Reviews++
+			}
+		}
+	}
+	
+	_, err = tx.ExecContext(
+		ctx, `
+		UPDATE wisata w SET
+			rating_total = COALESCE((SELECT ROUND(AVG(rating), 2) FROM reviews r WHERE r.wisata_id = w.id AND r.is_approved), 0),
+			total_reviews = (SELECT COUNT(*) FROM reviews r WHERE r.wisata_id = w.id AND r.is_approved)
+		WHERE w.slug LIKE 'seed-%'`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("wisata ratings: %w", err)
+	}
+	
+	blogCategoryIDs := map[string]int{}
+	for _, c := range blogCategories {
+		var id int
+		err := tx.QueryRowContext(
+			ctx, "INSERT INTO blog_categories (name, slug) VALUES ($1, $2) RETURNING id", c.Name, c.Slug,
+		).Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("blog category %s: %w", c.Slug, err)
+		}
+		blogCategoryIDs[c.Slug] = id
+	}
+	
+	for i, p := range blogPosts {
+		var id int
+		err := tx.QueryRowContext(
+			ctx, `
+			INSERT INTO blog_posts (title, slug, excerpt, content, thumbnail, author_id, blog_category_id, status, published_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, 'published', $8, $8)
+			RETURNING id`,
+			p.Title, p.Slug, p.Excerpt, p.Content, urlPath+imagePrefix+p.Image, userIDs["admin"],
+			blogCategoryIDs[p.Category], anchor.AddDate(0, 0, -14*(len(blogPosts)-i)),
+		).Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("blog post %s: %w", p.Slug, err)
+		}
+		
+		for _, slug := range p.Related {
+			_, err := tx.ExecContext(
+				ctx, "INSERT INTO blog_related_wisata (blog_post_id, wisata_id) VALUES ($1, $2)", id, wisataIDs[slug],
+			)
+			if err != nil {
+				return nil, fmt.Errorf("blog related wisata: %w", err)
+			}
+		}
+		s.BlogPosts++
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Remove deletes everything Run created, including rows other accounts
+// attached to seeded wisata, and the copied images.
+func Remove(ctx context.Context, db *sql.DB, uploadDir string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	
+	statements := []string{
+		"DELETE FROM blog_posts WHERE slug LIKE 'seed-%'",
+		"DELETE FROM blog_categories WHERE slug LIKE 'seed-%'",
+		`DELETE FROM reviews WHERE wisata_id IN (SELECT id FROM wisata WHERE slug LIKE 'seed-%')
+			OR user_id IN (SELECT id FROM users WHERE email LIKE '%' || $1)`,
+		`DELETE FROM bookings WHERE booking_code LIKE 'SEED-%'
+			OR wisata_id IN (SELECT id FROM wisata WHERE slug LIKE 'seed-%')
+			OR user_id IN (SELECT id FROM users WHERE email LIKE '%' || $1)`,
+		"DELETE FROM wisata WHERE slug LIKE 'seed-%'",
+		"DELETE FROM categories WHERE slug LIKE 'seed-%'",
+		"DELETE FROM users WHERE email LIKE '%' || $1",
+	}
+	for _, stmt := range statements {
+		var args []any
+		if strings.Contains(stmt, "$1") {
+			args = append(args, emailDomain)
+		}
+		if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+			return err
+		}
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	
+	matches, _ := filepath.Glob(filepath.Join(uploadDir, imagePrefix+"*"))
+	for _, path := range matches {
+		_ = os.Remove(path)
+	}
+	return nil
+}
+
+func copyImages(uploadDir string) error {
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		return err
+	}
+	
+	return fs.WalkDir(
+		images, "images", func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			
+			data, err := images.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(uploadDir, imagePrefix+d.Name()), data, 0644)
+		},
+	)
+}
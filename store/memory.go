@@ -0,0 +1,99 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+// NewMemory returns stores that keep everything in process memory, for
+// tests. They follow the Postgres stores closely enough for handler tests:
+// unique columns are enforced, foreign keys are not, and "newest first"
+// means the highest ID first.
+func NewMemory() Stores {
+	m := &memory{
+		users:          map[int]*memUser{},
+		categories:     map[int]*models.Category{},
+		wisata:         map[int]*memWisata{},
+		bookings:       map[int]*memBooking{},
+		reviews:        map[int]*models.Review{},
+		blogCategories: map[int]*models.BlogCategory{},
+		posts:          map[int]*memPost{},
+		tokens:         map[int]*AuthToken{},
+		apiKeys:        map[int]*memAPIKey{},
+		throttles:      map[string]*memThrottle{},
+	}
+	return Stores{
+		Wisata:   &memWisataStore{m},
+		Bookings: &memBookingStore{m},
+		Users:    &memUserStore{m},
+		Reviews:  &memReviewStore{m},
+		Blog:     &memBlogStore{m},
+		Sessions: &memSessionStore{m},
+		APIKeys:  &memAPIKeyStore{m},
+		Lockouts: &memLockoutStore{m},
+	}
+}
+
+// memory holds the tables shared by the memory stores. Every store locks mu
+// for the whole call.
+type memory struct {
+	mu     sync.Mutex
+	lastID int
+	
+	users          map[int]*memUser
+	categories     map[int]*models.Category
+	wisata         map[int]*memWisata
+	bookings       map[int]*memBooking
+	reviews        map[int]*models.Review
+	blogCategories map[int]*models.BlogCategory
+	posts          map[int]*memPost
+	resets         []*memReset
+	tokens         map[int]*AuthToken
+	apiKeys        map[int]*memAPIKey
+	throttles      map[string]*memThrottle
+}
+
+// nextID hands out IDs from one sequence for every table, so IDs also order
+// rows by creation time.
+func (m *memory) nextID() int {
+	m.lastID++
+	return m.lastID
+}
+
+// newestFirst sorts the IDs of a table in descending order.
+func newestFirst[T any](rows map[int]T) []int {
+	ids := make([]int, 0, len(rows))
+	for id := range rows {
+		ids = append(ids, id)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(ids)))
+	return ids
+}
+
+// containsFold is the memory version of ILIKE '%' || substr || '%'.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// newBookingCode mimics the column default, e.g. BK250314-3FA9C1.
+func newBookingCode(now time.Time) string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return fmt.Sprintf("BK%s-%s", now.Format("060102"), strings.ToUpper(hex.EncodeToString(b)))
+}
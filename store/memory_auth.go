@@ -0,0 +1,306 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+// memSessionStore only holds token pairs. The user_sessions rows behind
+// ListSessions are written by config.PGStore, which tests replace with a
+// cookie store, so there are no cookie sessions to list or revoke.
+type memSessionStore struct {
+	*memory
+}
+
+func (s *memSessionStore) CreateToken(ctx context.Context, t *AuthToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for _, existing := range s.tokens {
+		if existing.AccessHash == t.AccessHash || existing.RefreshHash == t.RefreshHash {
+			return ErrDuplicate
+		}
+	}
+	
+	t.ID = s.nextID()
+	token := *t
+	s.tokens[t.ID] = &token
+	return nil
+}
+
+func (s *memSessionStore) TokenUser(ctx context.Context, accessHash string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for _, t := range s.tokens {
+		if t.AccessHash == accessHash && t.RevokedAt == nil && t.AccessExpiresAt.After(time.Now()) {
+			return t.UserID, nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+func (s *memSessionStore) FindRefreshToken(ctx context.Context, refreshHash string) (*AuthToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for _, t := range s.tokens {
+		if t.RefreshHash == refreshHash && t.RefreshExpiresAt.After(time.Now()) {
+			token := *t
+			return &token, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memSessionStore) RotateToken(ctx context.Context, id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	t, ok := s.tokens[id]
+	if !ok || t.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.RevokedAt = &now
+	t.RotatedAt = &now
+	return true, nil
+}
+
+func (s *memSessionStore) RevokeAccessToken(ctx context.Context, accessHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for _, t := range s.tokens {
+		if t.AccessHash == accessHash && t.RevokedAt == nil {
+			now := time.Now()
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (s *memSessionStore) ListSessions(ctx context.Context, userID int, currentToken string) ([]models.UserSession, error) {
+	return nil, nil
+}
+
+func (s *memSessionStore) RevokeSession(ctx context.Context, userID, id int) error {
+	return ErrNotFound
+}
+
+func (s *memSessionStore) RevokeOtherSessions(ctx context.Context, userID int, currentToken string) error {
+	return nil
+}
+
+func (s *memSessionStore) RevokeUser(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	now := time.Now()
+	for _, t := range s.tokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+type memAPIKey struct {
+	models.APIKey
+	keyHash string
+}
+
+type memAPIKeyStore struct {
+	*memory
+}
+
+// apiKeyRow returns a copy that does not share Scopes with the stored key.
+func apiKeyRow(row *memAPIKey) models.APIKey {
+	k := row.APIKey
+	k.PartnerName = ""
+	k.Scopes = append([]string{}, row.Scopes...)
+	return k
+}
+
+func (s *memAPIKeyStore) FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for _, row := range s.apiKeys {
+		if row.keyHash == keyHash && row.RevokedAt == nil {
+			k := apiKeyRow(row)
+			return &k, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memAPIKeyStore) TouchLastUsed(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if row, ok := s.apiKeys[id]; ok {
+		now := time.Now()
+		row.LastUsedAt = &now
+	}
+	return nil
+}
+
+func (s *memAPIKeyStore) List(ctx context.Context) ([]models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.APIKey
+	for _, id := range newestFirst(s.apiKeys) {
+		row := s.apiKeys[id]
+		u, ok := s.users[row.UserID]
+		if !ok {
+			continue
+		}
+		k := apiKeyRow(row)
+		k.PartnerName = u.FullName
+		list = append(list, k)
+	}
+	return list, nil
+}
+
+func (s *memAPIKeyStore) Create(ctx context.Context, k *models.APIKey, keyHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for _, row := range s.apiKeys {
+		if row.keyHash == keyHash {
+			return ErrDuplicate
+		}
+	}
+	
+	k.ID = s.nextID()
+	k.CreatedAt = time.Now()
+	row := &memAPIKey{APIKey: *k, keyHash: keyHash}
+	row.Scopes = append([]string{}, k.Scopes...)
+	s.apiKeys[k.ID] = row
+	return nil
+}
+
+func (s *memAPIKeyStore) Revoke(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.apiKeys[id]
+	if !ok || row.RevokedAt != nil {
+		return ErrNotFound
+	}
+	now := time.Now()
+	row.RevokedAt = &now
+	return nil
+}
+
+func (s *memAPIKeyStore) RevokeUser(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	now := time.Now()
+	for _, row := range s.apiKeys {
+		if row.UserID == userID && row.RevokedAt == nil {
+			row.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+type memThrottle struct {
+	failures      int
+	lockedUntil   time.Time
+	lastFailureAt time.Time
+}
+
+type memLockoutStore struct {
+	*memory
+}
+
+func (s *memLockoutStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	t, ok := s.throttles[key]
+	if !ok || !t.lockedUntil.After(time.Now()) {
+		return time.Time{}, nil
+	}
+	return t.lockedUntil, nil
+}
+
+func (s *memLockoutStore) RecordFailure(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	t, ok := s.throttles[key]
+	if !ok {
+		t = &memThrottle{}
+		s.throttles[key] = t
+	}
+	if t.lastFailureAt.Before(windowStart) {
+		t.failures = 1
+	} else {
+		t.failures++
+	}
+	t.lastFailureAt = time.Now()
+	return t.failures, nil
+}
+
+func (s *memLockoutStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if t, ok := s.throttles[key]; ok {
+		t.lockedUntil = until
+	}
+	return nil
+}
+
+func (s *memLockoutStore) Clear(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	delete(s.throttles, key)
+	return nil
+}
+
+func (s *memLockoutStore) Locked(ctx context.Context) ([]models.Lockout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.Lockout
+	for key, t := range s.throttles {
+		if !t.lockedUntil.After(time.Now()) {
+			continue
+		}
+		
+		l := models.Lockout{
+			Key:           key,
+			Failures:      t.failures,
+			LockedUntil:   t.lockedUntil,
+			LastFailureAt: t.lastFailureAt,
+		}
+		if id, err := strconv.Atoi(strings.TrimPrefix(key, "user:")); err == nil && strings.HasPrefix(key, "user:") {
+			if u, ok := s.users[id]; ok {
+				fullName, email := u.FullName, u.Email
+				l.UserID = &id
+				l.FullName = &fullName
+				l.Email = &email
+			}
+		}
+		list = append(list, l)
+	}
+	
+	sort.Slice(
+		list, func(i, j int) bool {
+			return list[i].LockedUntil.After(list[j].LockedUntil)
+		},
+	)
+	return list, nil
+}
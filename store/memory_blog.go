@@ -0,0 +1,200 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+type memPost struct {
+	models.BlogPost
+	relatedWisata []int
+}
+
+type memBlogStore struct {
+	*memory
+}
+
+// postRow returns the post joined with its author and category.
+func (m *memory) postRow(row *memPost) models.BlogPost {
+	p := row.BlogPost
+	p.AuthorName = ""
+	if u, ok := m.users[p.AuthorID]; ok {
+		p.AuthorName = u.FullName
+	}
+	p.CategoryName = ""
+	if c, ok := m.blogCategories[row.CategoryID]; ok {
+		p.CategoryName = c.Name
+	} else {
+		p.CategoryID = 0
+	}
+	return p
+}
+
+func (s *memBlogStore) ListPublished(ctx context.Context, categorySlug, search string) ([]models.BlogPost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.BlogPost
+	for _, id := range newestFirst(s.posts) {
+		row := s.posts[id]
+		if row.Status != "published" {
+			continue
+		}
+		if categorySlug != "" {
+			c, ok := s.blogCategories[row.CategoryID]
+			if !ok || c.Slug != categorySlug {
+				continue
+			}
+		}
+		if search != "" && !containsFold(row.Title, search) {
+			continue
+		}
+		
+		p := s.postRow(row)
+		p.Content = ""
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+func (s *memBlogStore) GetBySlug(ctx context.Context, slug string) (*models.BlogPost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for _, row := range s.posts {
+		if row.Slug != slug {
+			continue
+		}
+		
+		p := s.postRow(row)
+		for _, wisataID := range row.relatedWisata {
+			w, ok := s.wisata[wisataID]
+			if !ok || w.deleted {
+				continue
+			}
+			
+			related := models.PopularWisata{
+				ID:          w.ID,
+				NamaTempat:  w.NamaTempat,
+				Lokasi:      w.Lokasi,
+				HargaTiket:  w.HargaTiket,
+				RatingTotal: w.RatingTotal,
+			}
+			if len(w.images) > 0 {
+				related.ImageURL = w.images[0]
+			}
+			p.RelatedWisata = append(p.RelatedWisata, related)
+		}
+		return &p, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memBlogStore) slugTaken(slug string, exceptID int) bool {
+	for _, p := range s.posts {
+		if p.Slug == slug && p.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// relatedWisata drops the IDs of wisata that do not exist and duplicates.
+func (s *memBlogStore) relatedWisata(wisataIDs []int) []int {
+	var ids []int
+	seen := map[int]bool{}
+	for _, id := range wisataIDs {
+		if _, ok := s.wisata[id]; ok && !seen[id] {
+			ids = append(ids, id)
+			seen[id] = true
+		}
+	}
+	return ids
+}
+
+func (s *memBlogStore) Create(ctx context.Context, p *models.BlogPost, relatedWisata []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if s.slugTaken(p.Slug, 0) {
+		return ErrDuplicate
+	}
+	
+	now := time.Now()
+	p.ID = s.nextID()
+	p.Status = "published"
+	p.Published = now
+	p.CreatedAt = now
+	s.posts[p.ID] = &memPost{BlogPost: *p, relatedWisata: s.relatedWisata(relatedWisata)}
+	return nil
+}
+
+func (s *memBlogStore) Update(ctx context.Context, p *models.BlogPost, relatedWisata []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.posts[p.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if s.slugTaken(p.Slug, p.ID) {
+		return ErrDuplicate
+	}
+	
+	row.Title = p.Title
+	row.Slug = p.Slug
+	row.Excerpt = p.Excerpt
+	row.Content = p.Content
+	row.CategoryID = p.CategoryID
+	if p.Thumbnail != "" {
+		row.Thumbnail = p.Thumbnail
+	}
+	row.relatedWisata = s.relatedWisata(relatedWisata)
+	return nil
+}
+
+func (s *memBlogStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if _, ok := s.posts[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.posts, id)
+	return nil
+}
+
+func (s *memBlogStore) Categories(ctx context.Context) ([]models.BlogCategory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.BlogCategory
+	for _, c := range s.blogCategories {
+		list = append(list, *c)
+	}
+	sort.Slice(
+		list, func(i, j int) bool {
+			return list[i].ID < list[j].ID
+		},
+	)
+	return list, nil
+}
+
+func (s *memBlogStore) CreateCategory(ctx context.Context, c *models.BlogCategory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for _, existing := range s.blogCategories {
+		if existing.Slug == c.Slug {
+			return ErrDuplicate
+		}
+	}
+	
+	c.ID = s.nextID()
+	category := *c
+	s.blogCategories[c.ID] = &category
+	return nil
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"context"
+	"math"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+type memBooking struct {
+	models.Booking
+	apiKeyID *int
+}
+
+type memBookingStore struct {
+	*memory
+}
+
+// bookingRow returns the booking joined with its wisata, or false when the
+// wisata no longer exists.
+func (m *memory) bookingRow(id int) (models.Booking, bool) {
+	row := m.bookings[id]
+	w, ok := m.wisata[row.WisataID]
+	if !ok {
+		return models.Booking{}, false
+	}
+	
+	b := row.Booking
+	b.WisataNama = w.NamaTempat
+	return b, true
+}
+
+func (s *memBookingStore) Create(ctx context.Context, b *models.Booking, apiKeyID *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	now := time.Now()
+	b.ID = s.nextID()
+	b.BookingCode = newBookingCode(now)
+	b.Status = "pending"
+	b.CreatedAt = now
+	s.bookings[b.ID] = &memBooking{Booking: *b, apiKeyID: apiKeyID}
+	return nil
+}
+
+func (s *memBookingStore) GetByCode(ctx context.Context, code string) (*models.Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for id, row := range s.bookings {
+		if row.BookingCode != code {
+			continue
+		}
+		if b, ok := s.bookingRow(id); ok {
+			return &b, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memBookingStore) ListByUser(ctx context.Context, userID int) ([]models.Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.Booking
+	for _, id := range newestFirst(s.bookings) {
+		b, ok := s.bookingRow(id)
+		if !ok || b.UserID != userID {
+			continue
+		}
+		if images := s.wisata[b.WisataID].images; len(images) > 0 {
+			b.WisataImage = images[0]
+		}
+		list = append(list, b)
+	}
+	return list, nil
+}
+
+func (s *memBookingStore) List(ctx context.Context) ([]models.Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.Booking
+	for _, id := range newestFirst(s.bookings) {
+		b, ok := s.bookingRow(id)
+		if !ok {
+			continue
+		}
+		u, ok := s.users[b.UserID]
+		if !ok {
+			continue
+		}
+		b.UserName = u.FullName
+		list = append(list, b)
+	}
+	return list, nil
+}
+
+func (s *memBookingStore) Recent(ctx context.Context, limit int) ([]models.Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.Booking
+	for _, id := range newestFirst(s.bookings) {
+		if len(list) == limit {
+			break
+		}
+		if b, ok := s.bookingRow(id); ok {
+			list = append(list, b)
+		}
+	}
+	return list, nil
+}
+
+func (s *memBookingStore) UpdateStatus(ctx context.Context, id int, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if row, ok := s.bookings[id]; ok {
+		row.Status = status
+	}
+	return nil
+}
+
+func (s *memBookingStore) UpdatePendingStatus(ctx context.Context, id int, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.bookings[id]
+	if !ok || row.Status != "pending" {
+		return ErrNotFound
+	}
+	row.Status = status
+	return nil
+}
+
+func (s *memBookingStore) Count(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	return len(s.bookings), nil
+}
+
+func (s *memBookingStore) HasVisited(ctx context.Context, userID, wisataID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for _, b := range s.bookings {
+		if b.UserID == userID && b.WisataID == wisataID && visited(b.Status) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// visited reports whether a booking with the status counts as a visit.
+func visited(status string) bool {
+	return status == "paid" || status == "completed"
+}
+
+// DashboardStats computes what vw_dashboard_stats selects.
+func (s *memBookingStore) DashboardStats(ctx context.Context) (*models.DashboardStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var stats models.DashboardStats
+	for _, w := range s.wisata {
+		if !w.deleted {
+			stats.TotalWisata++
+			stats.ActiveWisata++
+		}
+	}
+	for _, u := range s.users {
+		if !u.deleted && u.Role == models.RoleUser {
+			stats.TotalUsers++
+		}
+	}
+	for _, b := range s.bookings {
+		stats.TotalBookings++
+		if visited(b.Status) {
+			stats.TotalVisitors += b.Quantity
+			stats.TotalRevenue += b.FinalPrice
+		}
+	}
+	
+	var ratingSum, ratingCount int
+	for _, r := range s.reviews {
+		if r.IsApproved {
+			ratingSum += r.Rating
+			ratingCount++
+		}
+	}
+	if ratingCount > 0 {
+		stats.AverageRating = math.Round(float64(ratingSum)/float64(ratingCount)*100) / 100
+	}
+	return &stats, nil
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+type memReviewStore struct {
+	*memory
+}
+
+func (s *memReviewStore) Create(ctx context.Context, r *models.Review) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	r.ID = s.nextID()
+	r.IsApproved = false
+	r.CreatedAt = time.Now()
+	review := *r
+	s.reviews[r.ID] = &review
+	return nil
+}
+
+func (s *memReviewStore) ListApproved(ctx context.Context, wisataID int) ([]models.Review, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.Review
+	for _, id := range newestFirst(s.reviews) {
+		r := *s.reviews[id]
+		u, ok := s.users[r.UserID]
+		if !ok || r.WisataID != wisataID || !r.IsApproved {
+			continue
+		}
+		r.UserName = u.FullName
+		r.UserProfile = u.ProfileImage
+		list = append(list, r)
+	}
+	return list, nil
+}
+
+func (s *memReviewStore) List(ctx context.Context) ([]models.Review, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.Review
+	for _, id := range newestFirst(s.reviews) {
+		r := *s.reviews[id]
+		u, ok := s.users[r.UserID]
+		if !ok {
+			continue
+		}
+		w, ok := s.wisata[r.WisataID]
+		if !ok {
+			continue
+		}
+		r.UserName = u.FullName
+		r.WisataName = w.NamaTempat
+		list = append(list, r)
+	}
+	return list, nil
+}
+
+func (s *memReviewStore) Approve(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if r, ok := s.reviews[id]; ok {
+		r.IsApproved = true
+	}
+	return nil
+}
+
+func (s *memReviewStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	delete(s.reviews, id)
+	return nil
+}
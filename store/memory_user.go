@@ -0,0 +1,336 @@
+package store
+
+import (
+	"context"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+type memUser struct {
+	models.User
+	deleted      bool
+	totpSecret   *string
+	totpLastStep int64
+	// recoveryCodes maps the code hashes to whether they were used.
+	recoveryCodes map[string]bool
+}
+
+type memReset struct {
+	userID    int
+	tokenHash string
+	expiresAt time.Time
+	used      bool
+}
+
+type memUserStore struct {
+	*memory
+}
+
+// userRow returns the user without its credentials, the way userColumns
+// reads it.
+func (m *memory) userRow(row *memUser) *models.User {
+	u := row.User
+	u.Password = ""
+	u.EmailVerifiedAt = nil
+	return &u
+}
+
+// activeUser returns the user that is not deleted.
+func (m *memory) activeUser(id int) (*memUser, bool) {
+	row, ok := m.users[id]
+	if !ok || row.deleted {
+		return nil, false
+	}
+	return row, true
+}
+
+func (s *memUserStore) Get(ctx context.Context, id int) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.activeUser(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.userRow(row), nil
+}
+
+func (s *memUserStore) FindByLogin(ctx context.Context, login string) (*models.User, error) {
+	return s.findWithCredentials(
+		func(u *memUser) bool {
+			return u.Username == login || u.Email == login
+		},
+	)
+}
+
+func (s *memUserStore) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return s.findWithCredentials(
+		func(u *memUser) bool {
+			return u.Email == email
+		},
+	)
+}
+
+func (s *memUserStore) findWithCredentials(match func(*memUser) bool) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for _, row := range s.users {
+		if row.deleted || !match(row) {
+			continue
+		}
+		u := s.userRow(row)
+		u.Password = row.Password
+		u.EmailVerifiedAt = row.EmailVerifiedAt
+		return u, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memUserStore) PasswordHash(ctx context.Context, id int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.activeUser(id)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return row.Password, nil
+}
+
+func (s *memUserStore) Create(ctx context.Context, u *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	// Like the unique constraints, this includes deleted users.
+	for _, row := range s.users {
+		if row.Username == u.Username || row.Email == u.Email {
+			return ErrDuplicate
+		}
+	}
+	
+	u.ID = s.nextID()
+	u.UUID = newUUID()
+	u.CreatedAt = time.Now()
+	
+	row := &memUser{User: *u, recoveryCodes: map[string]bool{}}
+	row.TwoFactor = false
+	row.EmailVerifiedAt = nil
+	s.users[u.ID] = row
+	return nil
+}
+
+func (s *memUserStore) List(ctx context.Context) ([]models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.User
+	for _, id := range newestFirst(s.users) {
+		if row, ok := s.activeUser(id); ok {
+			list = append(list, *s.userRow(row))
+		}
+	}
+	return list, nil
+}
+
+func (s *memUserStore) TouchLastLogin(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if row, ok := s.users[id]; ok {
+		now := time.Now()
+		row.LastLogin = &now
+	}
+	return nil
+}
+
+func (s *memUserStore) UpdateStatus(ctx context.Context, id int, isActive bool, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.activeUser(id)
+	if !ok {
+		return ErrNotFound
+	}
+	row.IsActive = isActive
+	row.Role = role
+	return nil
+}
+
+func (s *memUserStore) UpdateProfile(ctx context.Context, id int, fullName, phone, imageURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.users[id]
+	if !ok {
+		return nil
+	}
+	row.FullName = fullName
+	row.Phone = &phone
+	if imageURL != "" {
+		row.ProfileImage = &imageURL
+	}
+	return nil
+}
+
+func (s *memUserStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.activeUser(id)
+	if !ok {
+		return ErrNotFound
+	}
+	row.deleted = true
+	return nil
+}
+
+func (s *memUserStore) VerifyEmail(ctx context.Context, id int, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.activeUser(id)
+	if !ok || row.Email != email {
+		return ErrNotFound
+	}
+	if row.EmailVerifiedAt == nil {
+		now := time.Now()
+		row.EmailVerifiedAt = &now
+	}
+	return nil
+}
+
+func (s *memUserStore) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for _, reset := range s.resets {
+		if reset.tokenHash == tokenHash {
+			return ErrDuplicate
+		}
+	}
+	for _, reset := range s.resets {
+		if reset.userID == userID {
+			reset.used = true
+		}
+	}
+	s.resets = append(s.resets, &memReset{userID: userID, tokenHash: tokenHash, expiresAt: expiresAt})
+	return nil
+}
+
+func (s *memUserStore) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for _, reset := range s.resets {
+		if reset.tokenHash != tokenHash || reset.used || !reset.expiresAt.After(time.Now()) {
+			continue
+		}
+		reset.used = true
+		
+		// Opening the reset link proves ownership of the address as well.
+		if row, ok := s.users[reset.userID]; ok {
+			row.Password = passwordHash
+			if row.EmailVerifiedAt == nil {
+				now := time.Now()
+				row.EmailVerifiedAt = &now
+			}
+		}
+		return reset.userID, nil
+	}
+	return 0, ErrNotFound
+}
+
+func (s *memUserStore) TwoFactor(ctx context.Context, userID int) (*string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.users[userID]
+	if !ok {
+		return nil, false, ErrNotFound
+	}
+	return row.totpSecret, row.TwoFactor, nil
+}
+
+func (s *memUserStore) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if row, ok := s.users[userID]; ok && !row.TwoFactor {
+		row.totpSecret = &secret
+	}
+	return nil
+}
+
+func (s *memUserStore) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.users[userID]
+	if !ok || row.totpLastStep >= step {
+		return false, nil
+	}
+	row.totpLastStep = step
+	return true, nil
+}
+
+func (s *memUserStore) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.users[userID]
+	if !ok {
+		return false, nil
+	}
+	if used, exists := row.recoveryCodes[codeHash]; !exists || used {
+		return false, nil
+	}
+	row.recoveryCodes[codeHash] = true
+	return true, nil
+}
+
+func (s *memUserStore) EnableTwoFactor(ctx context.Context, userID int, step int64, codeHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.users[userID]
+	if !ok {
+		return nil
+	}
+	row.TwoFactor = true
+	row.totpLastStep = step
+	row.recoveryCodes = newRecoveryCodes(codeHashes)
+	return nil
+}
+
+func (s *memUserStore) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if row, ok := s.users[userID]; ok {
+		row.recoveryCodes = newRecoveryCodes(codeHashes)
+	}
+	return nil
+}
+
+func newRecoveryCodes(codeHashes []string) map[string]bool {
+	codes := make(map[string]bool, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes[hash] = false
+	}
+	return codes
+}
+
+func (s *memUserStore) DisableTwoFactor(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if row, ok := s.users[userID]; ok {
+		row.TwoFactor = false
+		row.totpSecret = nil
+		row.totpLastStep = 0
+		row.recoveryCodes = map[string]bool{}
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+type memWisata struct {
+	models.Wisata
+	deleted bool
+	// images lists the image URLs, the primary one first.
+	images []string
+}
+
+type memWisataStore struct {
+	*memory
+}
+
+// wisataRow returns the wisata joined with its category and primary image,
+// or false for deleted wisata and those without a category.
+func (m *memory) wisataRow(id int) (models.Wisata, bool) {
+	row, ok := m.wisata[id]
+	if !ok || row.deleted {
+		return models.Wisata{}, false
+	}
+	category, ok := m.categories[row.CategoryID]
+	if !ok {
+		return models.Wisata{}, false
+	}
+	
+	w := row.Wisata
+	w.CategoryName = category.Name
+	w.ImageURL = ""
+	if len(row.images) > 0 {
+		w.ImageURL = row.images[0]
+	}
+	return w, true
+}
+
+func (s *memWisataStore) List(ctx context.Context, search string) ([]models.Wisata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.Wisata
+	for _, id := range newestFirst(s.wisata) {
+		w, ok := s.wisataRow(id)
+		if !ok || (search != "" && !containsFold(w.NamaTempat, search)) {
+			continue
+		}
+		list = append(list, w)
+	}
+	return list, nil
+}
+
+func (s *memWisataStore) Get(ctx context.Context, id int) (*models.Wisata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	w, ok := s.wisataRow(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &w, nil
+}
+
+func (s *memWisataStore) Create(ctx context.Context, w *models.Wisata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	w.ID = s.nextID()
+	w.UUID = newUUID()
+	w.CreatedAt = time.Now()
+	s.wisata[w.ID] = &memWisata{Wisata: *w}
+	return nil
+}
+
+func (s *memWisataStore) Update(ctx context.Context, w *models.Wisata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	row, ok := s.wisata[w.ID]
+	if !ok {
+		return nil
+	}
+	row.NamaTempat = w.NamaTempat
+	row.CategoryID = w.CategoryID
+	row.Lokasi = w.Lokasi
+	row.HargaTiket = w.HargaTiket
+	row.Deskripsi = w.Deskripsi
+	row.Fasilitas = w.Fasilitas
+	return nil
+}
+
+func (s *memWisataStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if row, ok := s.wisata[id]; ok {
+		row.deleted = true
+	}
+	return nil
+}
+
+func (s *memWisataStore) SetImage(ctx context.Context, wisataID int, imageURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if row, ok := s.wisata[wisataID]; ok {
+		row.images = []string{imageURL}
+	}
+	return nil
+}
+
+func (s *memWisataStore) Popular(ctx context.Context, limit int) ([]models.PopularWisata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.PopularWisata
+	for id, row := range s.wisata {
+		if row.deleted {
+			continue
+		}
+		
+		p := models.PopularWisata{
+			ID:         id,
+			NamaTempat: row.NamaTempat,
+			Lokasi:     row.Lokasi,
+			HargaTiket: row.HargaTiket,
+		}
+		if row.Deskripsi != nil {
+			p.Deskripsi = *row.Deskripsi
+		}
+		if len(row.images) > 0 {
+			p.ImageURL = row.images[0]
+		}
+		for _, b := range s.bookings {
+			if b.WisataID == id {
+				p.TotalVisits++
+			}
+		}
+		
+		var ratingSum int
+		for _, r := range s.reviews {
+			if r.WisataID == id {
+				ratingSum += r.Rating
+				p.TotalReviews++
+			}
+		}
+		if p.TotalReviews > 0 {
+			p.RatingTotal = float64(ratingSum) / float64(p.TotalReviews)
+		}
+		
+		list = append(list, p)
+	}
+	
+	sort.Slice(
+		list, func(i, j int) bool {
+			if list[i].TotalVisits != list[j].TotalVisits {
+				return list[i].TotalVisits > list[j].TotalVisits
+			}
+			return list[i].ID < list[j].ID
+		},
+	)
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (s *memWisataStore) Categories(ctx context.Context) ([]models.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var list []models.Category
+	for _, c := range s.categories {
+		category := *c
+		category.WisataCount = s.countInCategory(c.ID)
+		list = append(list, category)
+	}
+	
+	sort.Slice(
+		list, func(i, j int) bool {
+			if list[i].SortOrder != list[j].SortOrder {
+				return list[i].SortOrder < list[j].SortOrder
+			}
+			return list[i].ID < list[j].ID
+		},
+	)
+	return list, nil
+}
+
+func (s *memWisataStore) slugTaken(slug string, exceptID int) bool {
+	for _, c := range s.categories {
+		if c.Slug == slug && c.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *memWisataStore) CreateCategory(ctx context.Context, c *models.Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if s.slugTaken(c.Slug, 0) {
+		return ErrDuplicate
+	}
+	c.ID = s.nextID()
+	category := *c
+	s.categories[c.ID] = &category
+	return nil
+}
+
+func (s *memWisataStore) UpdateCategory(ctx context.Context, c *models.Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	category, ok := s.categories[c.ID]
+	if !ok {
+		return nil
+	}
+	if s.slugTaken(c.Slug, c.ID) {
+		return ErrDuplicate
+	}
+	category.Name = c.Name
+	category.Slug = c.Slug
+	category.IsActive = c.IsActive
+	return nil
+}
+
+func (s *memWisataStore) DeleteCategory(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	delete(s.categories, id)
+	return nil
+}
+
+func (s *memWisataStore) CountInCategory(ctx context.Context, categoryID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	return s.countInCategory(categoryID), nil
+}
+
+func (m *memory) countInCategory(categoryID int) int {
+	var count int
+	for _, w := range m.wisata {
+		if w.CategoryID == categoryID && !w.deleted {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// NewPostgres returns the stores backed by db.
+func NewPostgres(db *sql.DB) Stores {
+	return Stores{
+		Wisata:   &pgWisata{db: db},
+		Bookings: &pgBookings{db: db},
+		Users:    &pgUsers{db: db},
+		Reviews:  &pgReviews{db: db},
+		Blog:     &pgBlog{db: db},
+		Sessions: &pgSessions{db: db},
+		APIKeys:  &pgAPIKeys{db: db},
+		Lockouts: &pgLockouts{db: db},
+	}
+}
+
+// primaryImage selects the primary image of the wisata aliased w, or ""
+// when it has none.
+const primaryImage = `COALESCE((SELECT image_url FROM wisata_images WHERE wisata_id = w.id AND is_primary = true LIMIT 1), '')`
+
+// pgError translates the driver errors callers branch on into ErrNotFound
+// and ErrDuplicate.
+func pgError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// requireRow returns ErrNotFound when an update or delete matched nothing.
+func requireRow(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// changedRow reports whether an update matched a row.
+func changedRow(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// inTx runs fn in a transaction that is committed when fn succeeds.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
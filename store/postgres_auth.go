@@ -0,0 +1,294 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+type pgSessions struct {
+	db *sql.DB
+}
+
+func (s *pgSessions) CreateToken(ctx context.Context, t *AuthToken) error {
+	query := `
+		INSERT INTO auth_tokens (user_id, access_hash, refresh_hash, access_expires_at, refresh_expires_at, user_agent, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	return s.db.QueryRowContext(
+		ctx, query,
+		t.UserID, t.AccessHash, t.RefreshHash, t.AccessExpiresAt, t.RefreshExpiresAt, t.UserAgent, t.IPAddress,
+	).Scan(&t.ID)
+}
+
+func (s *pgSessions) TokenUser(ctx context.Context, accessHash string) (int, error) {
+	var userID int
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT user_id FROM auth_tokens WHERE access_hash = $1 AND revoked_at IS NULL AND access_expires_at > NOW()",
+		accessHash,
+	).Scan(&userID)
+	return userID, pgError(err)
+}
+
+func (s *pgSessions) FindRefreshToken(ctx context.Context, refreshHash string) (*AuthToken, error) {
+	var t AuthToken
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT id, user_id, access_hash, refresh_hash, access_expires_at, refresh_expires_at,
+			user_agent, ip_address, revoked_at, rotated_at
+		FROM auth_tokens WHERE refresh_hash = $1 AND refresh_expires_at > NOW()`,
+		refreshHash,
+	).Scan(
+		&t.ID, &t.UserID, &t.AccessHash, &t.RefreshHash, &t.AccessExpiresAt, &t.RefreshExpiresAt,
+		&t.UserAgent, &t.IPAddress, &t.RevokedAt, &t.RotatedAt,
+	)
+	if err != nil {
+		return nil, pgError(err)
+	}
+	return &t, nil
+}
+
+func (s *pgSessions) RotateToken(ctx context.Context, id int) (bool, error) {
+	return changedRow(
+		s.db.ExecContext(
+			ctx,
+			"UPDATE auth_tokens SET revoked_at = NOW(), rotated_at = NOW() WHERE id = $1 AND revoked_at IS NULL",
+			id,
+		),
+	)
+}
+
+func (s *pgSessions) RevokeAccessToken(ctx context.Context, accessHash string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		"UPDATE auth_tokens SET revoked_at = NOW() WHERE access_hash = $1 AND revoked_at IS NULL",
+		accessHash,
+	)
+	return err
+}
+
+func (s *pgSessions) ListSessions(ctx context.Context, userID int, currentToken string) ([]models.UserSession, error) {
+	query := `
+		SELECT id, token, user_agent, ip_address, created_at, last_seen_at, expires_at
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC
+	`
+	
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.UserSession
+	for rows.Next() {
+		var us models.UserSession
+		var token string
+		if err := rows.Scan(&us.ID, &token, &us.Device, &us.IPAddress, &us.CreatedAt, &us.LastSeenAt, &us.ExpiresAt); err != nil {
+			return nil, err
+		}
+		us.Current = token == currentToken
+		list = append(list, us)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgSessions) RevokeSession(ctx context.Context, userID, id int) error {
+	return requireRow(
+		s.db.ExecContext(
+			ctx,
+			"UPDATE user_sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL",
+			id, userID,
+		),
+	)
+}
+
+func (s *pgSessions) RevokeOtherSessions(ctx context.Context, userID int, currentToken string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		"UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND token <> $2 AND revoked_at IS NULL",
+		userID, currentToken,
+	)
+	return err
+}
+
+func (s *pgSessions) RevokeUser(ctx context.Context, userID int) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		"UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL",
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	
+	_, err = s.db.ExecContext(
+		ctx,
+		"UPDATE auth_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL",
+		userID,
+	)
+	return err
+}
+
+type pgAPIKeys struct {
+	db *sql.DB
+}
+
+func splitScopes(scopes string) []string {
+	if scopes == "" {
+		return []string{}
+	}
+	return strings.Split(scopes, ",")
+}
+
+func (s *pgAPIKeys) FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var k models.APIKey
+	var scopes string
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT id, user_id, name, prefix, scopes, rate_limit_per_minute, last_used_at, created_at
+		FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL`,
+		keyHash,
+	).Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &scopes, &k.RateLimit, &k.LastUsedAt, &k.CreatedAt)
+	if err != nil {
+		return nil, pgError(err)
+	}
+	k.Scopes = splitScopes(scopes)
+	return &k, nil
+}
+
+func (s *pgAPIKeys) TouchLastUsed(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = NOW() WHERE id = $1", id)
+	return err
+}
+
+func (s *pgAPIKeys) List(ctx context.Context) ([]models.APIKey, error) {
+	query := `
+		SELECT k.id, k.user_id, u.full_name, k.name, k.prefix, k.scopes, k.rate_limit_per_minute,
+			k.last_used_at, k.created_at, k.revoked_at
+		FROM api_keys k
+		JOIN users u ON k.user_id = u.id
+		ORDER BY k.created_at DESC
+	`
+	
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.APIKey
+	for rows.Next() {
+		var k models.APIKey
+		var scopes string
+		if err := rows.Scan(
+			&k.ID, &k.UserID, &k.PartnerName, &k.Name, &k.Prefix, &scopes, &k.RateLimit,
+			&k.LastUsedAt, &k.CreatedAt, &k.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		k.Scopes = splitScopes(scopes)
+		list = append(list, k)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgAPIKeys) Create(ctx context.Context, k *models.APIKey, keyHash string) error {
+	return s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, rate_limit_per_minute)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		k.UserID, k.Name, k.Prefix, keyHash, strings.Join(k.Scopes, ","), k.RateLimit,
+	).Scan(&k.ID, &k.CreatedAt)
+}
+
+func (s *pgAPIKeys) Revoke(ctx context.Context, id int) error {
+	return requireRow(
+		s.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL", id),
+	)
+}
+
+func (s *pgAPIKeys) RevokeUser(ctx context.Context, userID int) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	return err
+}
+
+type pgLockouts struct {
+	db *sql.DB
+}
+
+func (s *pgLockouts) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	var lockedUntil time.Time
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT locked_until FROM login_throttles WHERE key = $1 AND locked_until > NOW()",
+		key,
+	).Scan(&lockedUntil)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return lockedUntil, err
+}
+
+func (s *pgLockouts) RecordFailure(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	query := `
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE
+				WHEN login_throttles.last_failure_at < $2 THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = NOW()
+		RETURNING failures
+	`
+	
+	var failures int
+	err := s.db.QueryRowContext(ctx, query, key, windowStart).Scan(&failures)
+	return failures, err
+}
+
+func (s *pgLockouts) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE login_throttles SET locked_until = $1 WHERE key = $2", until, key)
+	return err
+}
+
+func (s *pgLockouts) Clear(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM login_throttles WHERE key = $1", key)
+	return err
+}
+
+func (s *pgLockouts) Locked(ctx context.Context) ([]models.Lockout, error) {
+	query := `
+		SELECT t.key, t.failures, t.locked_until, t.last_failure_at, u.id, u.full_name, u.email
+		FROM login_throttles t
+		LEFT JOIN users u ON t.key = 'user:' || u.id
+		WHERE t.locked_until > NOW()
+		ORDER BY t.locked_until DESC
+	`
+	
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.Lockout
+	for rows.Next() {
+		var l models.Lockout
+		if err := rows.Scan(
+			&l.Key, &l.Failures, &l.LockedUntil, &l.LastFailureAt, &l.UserID, &l.FullName, &l.Email,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
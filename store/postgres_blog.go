@@ -0,0 +1,199 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	
+	"backend-wisata/models"
+)
+
+type pgBlog struct {
+	db *sql.DB
+}
+
+func (s *pgBlog) ListPublished(ctx context.Context, categorySlug, search string) ([]models.BlogPost, error) {
+	query := `
+		SELECT
+			b.id, b.title, b.slug, b.excerpt, COALESCE(b.thumbnail, ''),
+			b.published_at, COALESCE(u.full_name, ''), COALESCE(c.name, ''), COALESCE(c.id, 0)
+		FROM blog_posts b
+		LEFT JOIN users u ON b.author_id = u.id
+		LEFT JOIN blog_categories c ON b.blog_category_id = c.id
+		WHERE b.status = 'published'
+	`
+	
+	var args []interface{}
+	counter := 1
+	
+	if categorySlug != "" {
+		query += " AND c.slug = $" + strconv.Itoa(counter)
+		args = append(args, categorySlug)
+		counter++
+	}
+	
+	if search != "" {
+		query += " AND b.title ILIKE '%' || $" + strconv.Itoa(counter) + " || '%'"
+		args = append(args, search)
+		counter++
+	}
+	
+	query += " ORDER BY b.published_at DESC"
+	
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.BlogPost
+	for rows.Next() {
+		var p models.BlogPost
+		if err := rows.Scan(
+			&p.ID, &p.Title, &p.Slug, &p.Excerpt, &p.Thumbnail,
+			&p.Published, &p.AuthorName, &p.CategoryName, &p.CategoryID,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgBlog) GetBySlug(ctx context.Context, slug string) (*models.BlogPost, error) {
+	query := `
+		SELECT
+			b.id, b.title, b.slug, b.excerpt, b.content, COALESCE(b.thumbnail, ''),
+			b.published_at, COALESCE(u.full_name, ''), COALESCE(c.name, ''), COALESCE(c.id, 0)
+		FROM blog_posts b
+		LEFT JOIN users u ON b.author_id = u.id
+		LEFT JOIN blog_categories c ON b.blog_category_id = c.id
+		WHERE b.slug = $1
+	`
+	
+	var p models.BlogPost
+	err := s.db.QueryRowContext(ctx, query, slug).Scan(
+		&p.ID, &p.Title, &p.Slug, &p.Excerpt, &p.Content, &p.Thumbnail,
+		&p.Published, &p.AuthorName, &p.CategoryName, &p.CategoryID,
+	)
+	if err != nil {
+		return nil, pgError(err)
+	}
+	
+	relatedQuery := `
+		SELECT w.id, w.nama_tempat, w.lokasi, w.harga_tiket, w.rating_total, ` + primaryImage + `
+		FROM blog_related_wisata br
+		JOIN wisata w ON br.wisata_id = w.id
+		WHERE br.blog_post_id = $1 AND w.deleted_at IS NULL
+	`
+	rows, err := s.db.QueryContext(ctx, relatedQuery, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	for rows.Next() {
+		var w models.PopularWisata
+		if err := rows.Scan(&w.ID, &w.NamaTempat, &w.Lokasi, &w.HargaTiket, &w.RatingTotal, &w.ImageURL); err != nil {
+			return nil, err
+		}
+		p.RelatedWisata = append(p.RelatedWisata, w)
+	}
+	return &p, rows.Err()
+}
+
+func (s *pgBlog) Create(ctx context.Context, p *models.BlogPost, relatedWisata []int) error {
+	return inTx(
+		ctx, s.db, func(tx *sql.Tx) error {
+			query := `
+				INSERT INTO blog_posts (title, slug, excerpt, content, thumbnail, author_id, blog_category_id, status)
+				VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, 'published')
+				RETURNING id, published_at, created_at
+			`
+			err := tx.QueryRowContext(
+				ctx, query,
+				p.Title, p.Slug, p.Excerpt, p.Content, p.Thumbnail, p.AuthorID, p.CategoryID,
+			).Scan(&p.ID, &p.Published, &p.CreatedAt)
+			if err != nil {
+				return pgError(err)
+			}
+			p.Status = "published"
+			
+			return insertRelatedWisata(ctx, tx, p.ID, relatedWisata)
+		},
+	)
+}
+
+func (s *pgBlog) Update(ctx context.Context, p *models.BlogPost, relatedWisata []int) error {
+	return inTx(
+		ctx, s.db, func(tx *sql.Tx) error {
+			err := requireRow(
+				tx.ExecContext(
+					ctx,
+					`UPDATE blog_posts
+				SET title=$1, slug=$2, excerpt=$3, content=$4, blog_category_id=$5,
+					thumbnail=COALESCE(NULLIF($6, ''), thumbnail), updated_at=NOW()
+				WHERE id=$7`,
+					p.Title, p.Slug, p.Excerpt, p.Content, p.CategoryID, p.Thumbnail, p.ID,
+				),
+			)
+			if err != nil {
+				return pgError(err)
+			}
+			
+			if _, err := tx.ExecContext(ctx, "DELETE FROM blog_related_wisata WHERE blog_post_id = $1", p.ID); err != nil {
+				return err
+			}
+			return insertRelatedWisata(ctx, tx, p.ID, relatedWisata)
+		},
+	)
+}
+
+// insertRelatedWisata links the post to the wisata, skipping IDs that do not
+// exist.
+func insertRelatedWisata(ctx context.Context, tx *sql.Tx, postID int, wisataIDs []int) error {
+	for _, wisataID := range wisataIDs {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO blog_related_wisata (blog_post_id, wisata_id)
+			SELECT $1, id FROM wisata WHERE id = $2
+			ON CONFLICT DO NOTHING`,
+			postID, wisataID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *pgBlog) Delete(ctx context.Context, id int) error {
+	return requireRow(s.db.ExecContext(ctx, "DELETE FROM blog_posts WHERE id = $1", id))
+}
+
+func (s *pgBlog) Categories(ctx context.Context) ([]models.BlogCategory, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, slug FROM blog_categories ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.BlogCategory
+	for rows.Next() {
+		var c models.BlogCategory
+		if err := rows.Scan(&c.ID, &c.Name, &c.Slug); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgBlog) CreateCategory(ctx context.Context, c *models.BlogCategory) error {
+	err := s.db.QueryRowContext(
+		ctx,
+		"INSERT INTO blog_categories (name, slug) VALUES ($1, $2) RETURNING id",
+		c.Name, c.Slug,
+	).Scan(&c.ID)
+	return pgError(err)
+}
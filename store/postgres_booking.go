@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+type pgBookings struct {
+	db *sql.DB
+}
+
+// bookingColumns are read by scanBooking, for bookings b joined with
+// wisata w.
+const bookingColumns = `
+	b.id, b.booking_code, b.wisata_id, w.nama_tempat, b.user_id,
+	b.visit_date, b.quantity, b.total_price, b.final_price,
+	b.status, b.payment_method, b.created_at`
+
+// scanBooking reads bookingColumns followed by extra.
+func scanBooking(row interface{ Scan(...any) error }, extra ...any) (*models.Booking, error) {
+	var b models.Booking
+	var visitDate time.Time
+	
+	dest := []any{
+		&b.ID, &b.BookingCode, &b.WisataID, &b.WisataNama, &b.UserID,
+		&visitDate, &b.Quantity, &b.TotalPrice, &b.FinalPrice,
+		&b.Status, &b.PaymentMethod, &b.CreatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	
+	b.VisitDate = visitDate.Format(time.DateOnly)
+	return &b, nil
+}
+
+func (s *pgBookings) Create(ctx context.Context, b *models.Booking, apiKeyID *int) error {
+	query := `
+		INSERT INTO bookings (wisata_id, user_id, visit_date, quantity, total_price, final_price, status, payment_method, api_key_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 'pending', $7, $8, NOW())
+		RETURNING id, booking_code, created_at
+	`
+	
+	b.Status = "pending"
+	return s.db.QueryRowContext(
+		ctx, query,
+		b.WisataID, b.UserID, b.VisitDate, b.Quantity, b.TotalPrice, b.FinalPrice, b.PaymentMethod, apiKeyID,
+	).Scan(&b.ID, &b.BookingCode, &b.CreatedAt)
+}
+
+func (s *pgBookings) GetByCode(ctx context.Context, code string) (*models.Booking, error) {
+	query := `
+		SELECT ` + bookingColumns + `
+		FROM bookings b
+		JOIN wisata w ON b.wisata_id = w.id
+		WHERE b.booking_code = $1
+	`
+	
+	b, err := scanBooking(s.db.QueryRowContext(ctx, query, code))
+	if err != nil {
+		return nil, pgError(err)
+	}
+	return b, nil
+}
+
+func (s *pgBookings) ListByUser(ctx context.Context, userID int) ([]models.Booking, error) {
+	query := `
+		SELECT ` + bookingColumns + `, ` + primaryImage + `
+		FROM bookings b
+		JOIN wisata w ON b.wisata_id = w.id
+		WHERE b.user_id = $1
+		ORDER BY b.created_at DESC
+	`
+	
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.Booking
+	for rows.Next() {
+		var image string
+		b, err := scanBooking(rows, &image)
+		if err != nil {
+			return nil, err
+		}
+		b.WisataImage = image
+		list = append(list, *b)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgBookings) List(ctx context.Context) ([]models.Booking, error) {
+	query := `
+		SELECT ` + bookingColumns + `, u.full_name
+		FROM bookings b
+		JOIN users u ON b.user_id = u.id
+		JOIN wisata w ON b.wisata_id = w.id
+		ORDER BY b.created_at DESC
+	`
+	
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.Booking
+	for rows.Next() {
+		var userName string
+		b, err := scanBooking(rows, &userName)
+		if err != nil {
+			return nil, err
+		}
+		b.UserName = userName
+		list = append(list, *b)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgBookings) Recent(ctx context.Context, limit int) ([]models.Booking, error) {
+	query := `
+		SELECT ` + bookingColumns + `
+		FROM bookings b
+		JOIN wisata w ON b.wisata_id = w.id
+		ORDER BY b.created_at DESC
+		LIMIT $1
+	`
+	
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.Booking
+	for rows.Next() {
+		b, err := scanBooking(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *b)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgBookings) UpdateStatus(ctx context.Context, id int, status string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		"UPDATE bookings SET status = $1, updated_at = NOW() WHERE id = $2",
+		status, id,
+	)
+	return err
+}
+
+func (s *pgBookings) UpdatePendingStatus(ctx context.Context, id int, status string) error {
+	return requireRow(
+		s.db.ExecContext(
+			ctx,
+			"UPDATE bookings SET status = $1, updated_at = NOW() WHERE id = $2 AND status = 'pending'",
+			status, id,
+		),
+	)
+}
+
+func (s *pgBookings) Count(ctx context.Context) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM bookings").Scan(&count)
+	return count, err
+}
+
+func (s *pgBookings) HasVisited(ctx context.Context, userID, wisataID int) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM bookings
+			WHERE user_id = $1 AND wisata_id = $2 AND status IN ('paid', 'completed')
+		)`
+	
+	var visited bool
+	err := s.db.QueryRowContext(ctx, query, userID, wisataID).Scan(&visited)
+	return visited, err
+}
+
+func (s *pgBookings) DashboardStats(ctx context.Context) (*models.DashboardStats, error) {
+	query := `
+		SELECT
+			total_wisata, active_wisata, total_users, total_visitors,
+			total_revenue, total_bookings, average_rating
+		FROM vw_dashboard_stats
+	`
+	
+	var stats models.DashboardStats
+	err := s.db.QueryRowContext(ctx, query).Scan(
+		&stats.TotalWisata,
+		&stats.ActiveWisata,
+		&stats.TotalUsers,
+		&stats.TotalVisitors,
+		&stats.TotalRevenue,
+		&stats.TotalBookings,
+		&stats.AverageRating,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	
+	"backend-wisata/models"
+)
+
+type pgReviews struct {
+	db *sql.DB
+}
+
+func (s *pgReviews) Create(ctx context.Context, r *models.Review) error {
+	query := `
+		INSERT INTO reviews (wisata_id, user_id, rating, comment, is_approved)
+		VALUES ($1, $2, $3, $4, FALSE)
+		RETURNING id, created_at
+	`
+	return s.db.QueryRowContext(ctx, query, r.WisataID, r.UserID, r.Rating, r.Comment).Scan(&r.ID, &r.CreatedAt)
+}
+
+func (s *pgReviews) ListApproved(ctx context.Context, wisataID int) ([]models.Review, error) {
+	query := `
+		SELECT r.id, r.wisata_id, r.user_id, r.rating, r.comment, r.is_approved, r.created_at,
+			u.full_name, u.profile_image
+		FROM reviews r
+		JOIN users u ON r.user_id = u.id
+		WHERE r.wisata_id = $1 AND r.is_approved = TRUE
+		ORDER BY r.created_at DESC
+	`
+	
+	rows, err := s.db.QueryContext(ctx, query, wisataID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.Review
+	for rows.Next() {
+		var r models.Review
+		if err := rows.Scan(
+			&r.ID, &r.WisataID, &r.UserID, &r.Rating, &r.Comment, &r.IsApproved, &r.CreatedAt,
+			&r.UserName, &r.UserProfile,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgReviews) List(ctx context.Context) ([]models.Review, error) {
+	query := `
+		SELECT r.id, r.wisata_id, r.user_id, r.rating, r.comment, r.is_approved, r.created_at,
+			u.full_name, w.nama_tempat
+		FROM reviews r
+		JOIN users u ON r.user_id = u.id
+		JOIN wisata w ON r.wisata_id = w.id
+		ORDER BY r.created_at DESC
+	`
+	
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.Review
+	for rows.Next() {
+		var r models.Review
+		if err := rows.Scan(
+			&r.ID, &r.WisataID, &r.UserID, &r.Rating, &r.Comment, &r.IsApproved, &r.CreatedAt,
+			&r.UserName, &r.WisataName,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgReviews) Approve(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE reviews SET is_approved = TRUE WHERE id = $1", id)
+	return err
+}
+
+func (s *pgReviews) Delete(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM reviews WHERE id = $1", id)
+	return err
+}
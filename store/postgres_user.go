@@ -0,0 +1,314 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+type pgUsers struct {
+	db *sql.DB
+}
+
+// userColumns are read by scanUser.
+const userColumns = `
+	id, uuid, username, email, full_name, phone, role, is_active, totp_enabled,
+	profile_image, last_login, created_at`
+
+func scanUser(row interface{ Scan(...any) error }, extra ...any) (*models.User, error) {
+	var u models.User
+	dest := []any{
+		&u.ID, &u.UUID, &u.Username, &u.Email, &u.FullName, &u.Phone, &u.Role, &u.IsActive, &u.TwoFactor,
+		&u.ProfileImage, &u.LastLogin, &u.CreatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *pgUsers) Get(ctx context.Context, id int) (*models.User, error) {
+	row := s.db.QueryRowContext(
+		ctx,
+		"SELECT "+userColumns+" FROM users WHERE id = $1 AND deleted_at IS NULL",
+		id,
+	)
+	u, err := scanUser(row)
+	if err != nil {
+		return nil, pgError(err)
+	}
+	return u, nil
+}
+
+func (s *pgUsers) FindByLogin(ctx context.Context, login string) (*models.User, error) {
+	return s.findWithCredentials(ctx, "(username = $1 OR email = $1)", login)
+}
+
+func (s *pgUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return s.findWithCredentials(ctx, "email = $1", email)
+}
+
+func (s *pgUsers) findWithCredentials(ctx context.Context, where, arg string) (*models.User, error) {
+	row := s.db.QueryRowContext(
+		ctx,
+		"SELECT "+userColumns+", password_hash, email_verified_at FROM users WHERE "+where+" AND deleted_at IS NULL",
+		arg,
+	)
+	
+	var passwordHash string
+	var verifiedAt *time.Time
+	u, err := scanUser(row, &passwordHash, &verifiedAt)
+	if err != nil {
+		return nil, pgError(err)
+	}
+	u.Password = passwordHash
+	u.EmailVerifiedAt = verifiedAt
+	return u, nil
+}
+
+func (s *pgUsers) PasswordHash(ctx context.Context, id int) (string, error) {
+	var hash string
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT password_hash FROM users WHERE id = $1 AND deleted_at IS NULL",
+		id,
+	).Scan(&hash)
+	return hash, pgError(err)
+}
+
+func (s *pgUsers) Create(ctx context.Context, u *models.User) error {
+	query := `
+		INSERT INTO users (username, email, password_hash, full_name, phone, role, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, uuid, created_at
+	`
+	
+	err := s.db.QueryRowContext(
+		ctx, query,
+		u.Username, u.Email, u.Password, u.FullName, u.Phone, u.Role, u.IsActive,
+	).Scan(&u.ID, &u.UUID, &u.CreatedAt)
+	return pgError(err)
+}
+
+func (s *pgUsers) List(ctx context.Context) ([]models.User, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		"SELECT "+userColumns+" FROM users WHERE deleted_at IS NULL ORDER BY created_at DESC",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *u)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgUsers) TouchLastLogin(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET last_login = NOW() WHERE id = $1", id)
+	return err
+}
+
+func (s *pgUsers) UpdateStatus(ctx context.Context, id int, isActive bool, role string) error {
+	return requireRow(
+		s.db.ExecContext(
+			ctx,
+			"UPDATE users SET is_active=$1, role=$2, updated_at=NOW() WHERE id=$3 AND deleted_at IS NULL",
+			isActive, role, id,
+		),
+	)
+}
+
+func (s *pgUsers) UpdateProfile(ctx context.Context, id int, fullName, phone, imageURL string) error {
+	var err error
+	if imageURL != "" {
+		_, err = s.db.ExecContext(
+			ctx,
+			"UPDATE users SET full_name=$1, phone=$2, profile_image=$3, updated_at=NOW() WHERE id=$4",
+			fullName, phone, imageURL, id,
+		)
+	} else {
+		_, err = s.db.ExecContext(
+			ctx,
+			"UPDATE users SET full_name=$1, phone=$2, updated_at=NOW() WHERE id=$3",
+			fullName, phone, id,
+		)
+	}
+	return err
+}
+
+func (s *pgUsers) Delete(ctx context.Context, id int) error {
+	return requireRow(s.db.ExecContext(ctx, "UPDATE users SET deleted_at=NOW() WHERE id=$1 AND deleted_at IS NULL", id))
+}
+
+func (s *pgUsers) VerifyEmail(ctx context.Context, id int, email string) error {
+	return requireRow(
+		s.db.ExecContext(
+			ctx,
+			"UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1 AND email = $2 AND deleted_at IS NULL",
+			id, email,
+		),
+	)
+}
+
+func (s *pgUsers) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	return inTx(
+		ctx, s.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(
+				ctx,
+				"UPDATE password_resets SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL",
+				userID,
+			)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(
+				ctx,
+				"INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES ($1, $2, $3)",
+				userID, tokenHash, expiresAt,
+			)
+			return err
+		},
+	)
+}
+
+func (s *pgUsers) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	var userID int
+	err := inTx(
+		ctx, s.db, func(tx *sql.Tx) error {
+			var resetID int
+			err := tx.QueryRowContext(
+				ctx,
+				`SELECT id, user_id FROM password_resets
+				WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+				FOR UPDATE`,
+				tokenHash,
+			).Scan(&resetID, &userID)
+			if err != nil {
+				return pgError(err)
+			}
+			
+			// Opening the reset link proves ownership of the address as well.
+			_, err = tx.ExecContext(
+				ctx,
+				"UPDATE users SET password_hash = $1, email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW() WHERE id = $2",
+				passwordHash, userID,
+			)
+			if err != nil {
+				return err
+			}
+			
+			_, err = tx.ExecContext(ctx, "UPDATE password_resets SET used_at = NOW() WHERE id = $1", resetID)
+			return err
+		},
+	)
+	return userID, err
+}
+
+func (s *pgUsers) TwoFactor(ctx context.Context, userID int) (*string, bool, error) {
+	var secret *string
+	var enabled bool
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT totp_secret, totp_enabled FROM users WHERE id = $1",
+		userID,
+	).Scan(&secret, &enabled)
+	return secret, enabled, pgError(err)
+}
+
+func (s *pgUsers) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		"UPDATE users SET totp_secret = $1 WHERE id = $2 AND totp_enabled = FALSE",
+		secret, userID,
+	)
+	return err
+}
+
+func (s *pgUsers) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	return changedRow(
+		s.db.ExecContext(
+			ctx,
+			"UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1",
+			step, userID,
+		),
+	)
+}
+
+func (s *pgUsers) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	return changedRow(
+		s.db.ExecContext(
+			ctx,
+			"UPDATE user_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL",
+			userID, codeHash,
+		),
+	)
+}
+
+func (s *pgUsers) EnableTwoFactor(ctx context.Context, userID int, step int64, codeHashes []string) error {
+	return inTx(
+		ctx, s.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(
+				ctx,
+				"UPDATE users SET totp_enabled = TRUE, totp_last_step = $1 WHERE id = $2",
+				step, userID,
+			)
+			if err != nil {
+				return err
+			}
+			return replaceRecoveryCodes(ctx, tx, userID, codeHashes)
+		},
+	)
+}
+
+func (s *pgUsers) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	return inTx(
+		ctx, s.db, func(tx *sql.Tx) error {
+			return replaceRecoveryCodes(ctx, tx, userID, codeHashes)
+		},
+	)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	
+	for _, hash := range codeHashes {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)",
+			userID, hash,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *pgUsers) DisableTwoFactor(ctx context.Context, userID int) error {
+	return inTx(
+		ctx, s.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(
+				ctx,
+				"UPDATE users SET totp_enabled = FALSE, totp_secret = NULL, totp_last_step = 0 WHERE id = $1",
+				userID,
+			)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, "DELETE FROM user_recovery_codes WHERE user_id = $1", userID)
+			return err
+		},
+	)
+}
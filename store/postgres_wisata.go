@@ -0,0 +1,225 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	
+	"backend-wisata/models"
+)
+
+type pgWisata struct {
+	db *sql.DB
+}
+
+func (s *pgWisata) List(ctx context.Context, search string) ([]models.Wisata, error) {
+	query := `
+		SELECT
+			w.id, w.uuid, w.nama_tempat, w.slug, w.lokasi,
+			w.harga_tiket, w.rating_total, w.category_id,
+			` + primaryImage + ` as image_url,
+			c.name as category_name
+		FROM wisata w
+		JOIN categories c ON w.category_id = c.id
+		WHERE w.deleted_at IS NULL
+	`
+	
+	var args []interface{}
+	if search != "" {
+		query += " AND w.nama_tempat ILIKE '%' || $1 || '%'"
+		args = append(args, search)
+	}
+	
+	query += " ORDER BY w.created_at DESC"
+	
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.Wisata
+	for rows.Next() {
+		var w models.Wisata
+		if err := rows.Scan(
+			&w.ID, &w.UUID, &w.NamaTempat, &w.Slug, &w.Lokasi,
+			&w.HargaTiket, &w.RatingTotal, &w.CategoryID, &w.ImageURL,
+			&w.CategoryName,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, w)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgWisata) Get(ctx context.Context, id int) (*models.Wisata, error) {
+	query := `
+		SELECT
+			w.id, w.uuid, w.nama_tempat, w.slug, w.deskripsi, w.fasilitas,
+			w.harga_tiket, w.lokasi, w.category_id,
+			c.name as category_name,
+			` + primaryImage + ` as image_url
+		FROM wisata w
+		JOIN categories c ON w.category_id = c.id
+		WHERE w.id = $1 AND w.deleted_at IS NULL
+	`
+	
+	var w models.Wisata
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&w.ID, &w.UUID, &w.NamaTempat, &w.Slug, &w.Deskripsi, &w.Fasilitas,
+		&w.HargaTiket, &w.Lokasi, &w.CategoryID,
+		&w.CategoryName, &w.ImageURL,
+	)
+	if err != nil {
+		return nil, pgError(err)
+	}
+	return &w, nil
+}
+
+func (s *pgWisata) Create(ctx context.Context, w *models.Wisata) error {
+	query := `
+		INSERT INTO wisata (nama_tempat, slug, category_id, lokasi, harga_tiket, deskripsi, fasilitas)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	
+	err := s.db.QueryRowContext(
+		ctx, query,
+		w.NamaTempat, w.Slug, w.CategoryID, w.Lokasi, w.HargaTiket, w.Deskripsi, w.Fasilitas,
+	).Scan(&w.ID)
+	return pgError(err)
+}
+
+func (s *pgWisata) Update(ctx context.Context, w *models.Wisata) error {
+	query := `
+		UPDATE wisata
+		SET nama_tempat=$1, category_id=$2, lokasi=$3, harga_tiket=$4, deskripsi=$5, fasilitas=$6, updated_at=NOW()
+		WHERE id=$7
+	`
+	_, err := s.db.ExecContext(
+		ctx, query,
+		w.NamaTempat, w.CategoryID, w.Lokasi, w.HargaTiket, w.Deskripsi, w.Fasilitas, w.ID,
+	)
+	return pgError(err)
+}
+
+func (s *pgWisata) Delete(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE wisata SET deleted_at = NOW() WHERE id = $1", id)
+	return err
+}
+
+func (s *pgWisata) SetImage(ctx context.Context, wisataID int, imageURL string) error {
+	return inTx(
+		ctx, s.db, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM wisata_images WHERE wisata_id = $1", wisataID); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(
+				ctx,
+				"INSERT INTO wisata_images (wisata_id, image_url, is_primary) VALUES ($1, $2, true)",
+				wisataID, imageURL,
+			)
+			return err
+		},
+	)
+}
+
+func (s *pgWisata) Popular(ctx context.Context, limit int) ([]models.PopularWisata, error) {
+	query := `
+		SELECT
+			w.id,
+			w.nama_tempat,
+			COALESCE(w.deskripsi, ''),
+			w.lokasi,
+			w.harga_tiket,
+			` + primaryImage + ` as image_url,
+			COUNT(DISTINCT b.id) as total_visits,
+			COALESCE(AVG(r.rating), 0) as rating_total,
+			COUNT(DISTINCT r.id) as total_reviews
+		FROM wisata w
+		LEFT JOIN bookings b ON w.id = b.wisata_id
+		LEFT JOIN reviews r ON w.id = r.wisata_id
+		WHERE w.deleted_at IS NULL
+		GROUP BY w.id, w.nama_tempat, w.deskripsi, w.lokasi, w.harga_tiket
+		ORDER BY total_visits DESC
+		LIMIT $1
+	`
+	
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.PopularWisata
+	for rows.Next() {
+		var p models.PopularWisata
+		if err := rows.Scan(
+			&p.ID, &p.NamaTempat, &p.Deskripsi, &p.Lokasi, &p.HargaTiket,
+			&p.ImageURL, &p.TotalVisits, &p.RatingTotal, &p.TotalReviews,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgWisata) Categories(ctx context.Context) ([]models.Category, error) {
+	query := `
+		SELECT
+			c.id, c.name, c.slug, c.icon, c.sort_order, c.is_active,
+			(SELECT COUNT(*) FROM wisata w WHERE w.category_id = c.id AND w.deleted_at IS NULL) as count
+		FROM categories c
+		ORDER BY c.sort_order ASC, c.id ASC
+	`
+	
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var list []models.Category
+	for rows.Next() {
+		var c models.Category
+		if err := rows.Scan(&c.ID, &c.Name, &c.Slug, &c.Icon, &c.SortOrder, &c.IsActive, &c.WisataCount); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func (s *pgWisata) CreateCategory(ctx context.Context, c *models.Category) error {
+	err := s.db.QueryRowContext(
+		ctx,
+		"INSERT INTO categories (name, slug, icon, is_active) VALUES ($1, $2, $3, $4) RETURNING id",
+		c.Name, c.Slug, c.Icon, c.IsActive,
+	).Scan(&c.ID)
+	return pgError(err)
+}
+
+func (s *pgWisata) UpdateCategory(ctx context.Context, c *models.Category) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		"UPDATE categories SET name=$1, slug=$2, is_active=$3, updated_at=NOW() WHERE id=$4",
+		c.Name, c.Slug, c.IsActive, c.ID,
+	)
+	return pgError(err)
+}
+
+func (s *pgWisata) DeleteCategory(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", id)
+	return err
+}
+
+func (s *pgWisata) CountInCategory(ctx context.Context, categoryID int) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT COUNT(*) FROM wisata WHERE category_id = $1 AND deleted_at IS NULL",
+		categoryID,
+	).Scan(&count)
+	return count, err
+}
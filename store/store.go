@@ -0,0 +1,249 @@
+// Package store is the data access layer of the API. Handlers only talk to
+// the interfaces below; NewPostgres implements them on the database and
+// NewMemory keeps everything in process memory for tests.
+//
+// Lookups of a single row return ErrNotFound when nothing matches, and
+// writes that hit a unique column return ErrDuplicate. Updates and deletes
+// of a row that does not exist do nothing unless their comment says they
+// return ErrNotFound.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+	
+	"backend-wisata/models"
+)
+
+var (
+	ErrNotFound  = errors.New("store: not found")
+	ErrDuplicate = errors.New("store: duplicate value")
+)
+
+// Stores bundles every store the handlers need.
+type Stores struct {
+	Wisata   WisataStore
+	Bookings BookingStore
+	Users    UserStore
+	Reviews  ReviewStore
+	Blog     BlogStore
+	Sessions SessionStore
+	APIKeys  APIKeyStore
+	Lockouts LockoutStore
+}
+
+// WisataStore holds the wisata catalog and its categories. Image URLs are
+// returned as stored, without the public base URL.
+type WisataStore interface {
+	// List returns the wisata that are not deleted, newest first, optionally
+	// filtered by a case-insensitive match on the name.
+	List(ctx context.Context, search string) ([]models.Wisata, error)
+	Get(ctx context.Context, id int) (*models.Wisata, error)
+	// Create stores w and sets its ID. Only the editable fields are used.
+	Create(ctx context.Context, w *models.Wisata) error
+	Update(ctx context.Context, w *models.Wisata) error
+	Delete(ctx context.Context, id int) error
+	// SetImage replaces the images of a wisata with imageURL as the primary
+	// image.
+	SetImage(ctx context.Context, wisataID int, imageURL string) error
+	// Popular returns the wisata with the most bookings.
+	Popular(ctx context.Context, limit int) ([]models.PopularWisata, error)
+	
+	// Categories returns every category with WisataCount filled in.
+	Categories(ctx context.Context) ([]models.Category, error)
+	CreateCategory(ctx context.Context, c *models.Category) error
+	UpdateCategory(ctx context.Context, c *models.Category) error
+	DeleteCategory(ctx context.Context, id int) error
+	// CountInCategory counts the wisata that are not deleted in a category.
+	CountInCategory(ctx context.Context, categoryID int) (int, error)
+}
+
+// BookingStore holds bookings. Booking lists carry WisataNama, and
+// ListByUser also WisataImage.
+type BookingStore interface {
+	// Create stores a pending booking and sets its ID and BookingCode.
+	// apiKeyID is the partner key that made it, if any.
+	Create(ctx context.Context, b *models.Booking, apiKeyID *int) error
+	GetByCode(ctx context.Context, code string) (*models.Booking, error)
+	// ListByUser returns the bookings of a user, newest first.
+	ListByUser(ctx context.Context, userID int) ([]models.Booking, error)
+	// List returns every booking with UserName, newest first.
+	List(ctx context.Context) ([]models.Booking, error)
+	Recent(ctx context.Context, limit int) ([]models.Booking, error)
+	UpdateStatus(ctx context.Context, id int, status string) error
+	// UpdatePendingStatus moves a booking that is still pending to status.
+	// It returns ErrNotFound when the booking is missing or no longer
+	// pending, so concurrent payments and cancellations cannot both win.
+	UpdatePendingStatus(ctx context.Context, id int, status string) error
+	// Count counts all bookings, whatever their status.
+	Count(ctx context.Context) (int, error)
+	// HasVisited reports whether the user has a paid or completed booking
+	// for the wisata.
+	HasVisited(ctx context.Context, userID, wisataID int) (bool, error)
+	DashboardStats(ctx context.Context) (*models.DashboardStats, error)
+}
+
+// UserStore holds accounts together with their credentials, email
+// verification, password resets and two-factor settings. Deleted users are
+// never returned.
+type UserStore interface {
+	Get(ctx context.Context, id int) (*models.User, error)
+	// FindByLogin looks a user up by username or email. Password and
+	// EmailVerifiedAt are filled in.
+	FindByLogin(ctx context.Context, login string) (*models.User, error)
+	// FindByEmail is FindByLogin for the email only.
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	// PasswordHash returns the stored bcrypt hash.
+	PasswordHash(ctx context.Context, id int) (string, error)
+	// Create stores u with u.Password as the bcrypt hash and sets its ID.
+	// The email starts out unverified.
+	Create(ctx context.Context, u *models.User) error
+	List(ctx context.Context) ([]models.User, error)
+	TouchLastLogin(ctx context.Context, id int) error
+	// UpdateStatus returns ErrNotFound when the user is missing or deleted.
+	UpdateStatus(ctx context.Context, id int, isActive bool, role string) error
+	// UpdateProfile changes the name and phone, and the profile image when
+	// imageURL is not empty.
+	UpdateProfile(ctx context.Context, id int, fullName, phone, imageURL string) error
+	// Delete returns ErrNotFound when the user is missing or already deleted.
+	Delete(ctx context.Context, id int) error
+	
+	// VerifyEmail marks the email verified if it still belongs to the user.
+	// It returns ErrNotFound otherwise.
+	VerifyEmail(ctx context.Context, id int, email string) error
+	// CreatePasswordReset invalidates the user's open reset tokens and
+	// stores a new one.
+	CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	// ResetPassword uses an unexpired reset token to set a new password
+	// hash, which also verifies the email. It returns the user ID, or
+	// ErrNotFound for an unknown, used or expired token.
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error)
+	
+	// TwoFactor returns the TOTP secret, nil before setup, and whether
+	// two-factor authentication is enabled.
+	TwoFactor(ctx context.Context, userID int) (secret *string, enabled bool, err error)
+	// SetTOTPSecret stores a new secret while two-factor is still disabled.
+	SetTOTPSecret(ctx context.Context, userID int, secret string) error
+	// UseTOTPStep records step as used and reports false when it, or a
+	// later step, was used before.
+	UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+	// UseRecoveryCode consumes an unused recovery code.
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	// EnableTwoFactor turns two-factor on with step as the first used step
+	// and codeHashes as the recovery codes.
+	EnableTwoFactor(ctx context.Context, userID int, step int64, codeHashes []string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	// DisableTwoFactor clears the secret and the recovery codes.
+	DisableTwoFactor(ctx context.Context, userID int) error
+}
+
+// ReviewStore holds reviews, which are hidden until an admin approves them.
+type ReviewStore interface {
+	// Create stores an unapproved review and sets its ID.
+	Create(ctx context.Context, r *models.Review) error
+	// ListApproved returns the approved reviews of a wisata with the
+	// reviewer's name and profile image.
+	ListApproved(ctx context.Context, wisataID int) ([]models.Review, error)
+	// List returns every review with UserName and WisataName.
+	List(ctx context.Context) ([]models.Review, error)
+	Approve(ctx context.Context, id int) error
+	Delete(ctx context.Context, id int) error
+}
+
+// BlogStore holds blog posts and their categories. Thumbnails and image
+// URLs are returned as stored.
+type BlogStore interface {
+	// ListPublished returns published posts, newest first, optionally
+	// filtered by category slug and a case-insensitive title match.
+	ListPublished(ctx context.Context, categorySlug, search string) ([]models.BlogPost, error)
+	// GetBySlug returns a post with its related wisata.
+	GetBySlug(ctx context.Context, slug string) (*models.BlogPost, error)
+	// Create stores a published post and sets its ID.
+	Create(ctx context.Context, p *models.BlogPost, relatedWisata []int) error
+	// Update changes a post and replaces its related wisata. An empty
+	// Thumbnail keeps the current one. It returns ErrNotFound when the post
+	// is missing.
+	Update(ctx context.Context, p *models.BlogPost, relatedWisata []int) error
+	// Delete returns ErrNotFound when the post is missing.
+	Delete(ctx context.Context, id int) error
+	
+	Categories(ctx context.Context) ([]models.BlogCategory, error)
+	// CreateCategory stores c and sets its ID.
+	CreateCategory(ctx context.Context, c *models.BlogCategory) error
+}
+
+// AuthToken is an access and refresh token pair, identified by the SHA-256
+// of each token.
+type AuthToken struct {
+	ID               int
+	UserID           int
+	AccessHash       string
+	RefreshHash      string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+	UserAgent        string
+	IPAddress        string
+	RevokedAt        *time.Time
+	RotatedAt        *time.Time
+}
+
+// SessionStore holds what keeps a user logged in: bearer token pairs and the
+// cookie sessions listed to the user. The cookie session data itself lives
+// in the gorilla session store.
+type SessionStore interface {
+	CreateToken(ctx context.Context, t *AuthToken) error
+	// TokenUser returns the user ID of a valid, unexpired access token.
+	TokenUser(ctx context.Context, accessHash string) (int, error)
+	// FindRefreshToken returns the token pair with an unexpired refresh
+	// token, revoked or not.
+	FindRefreshToken(ctx context.Context, refreshHash string) (*AuthToken, error)
+	// RotateToken revokes a pair because its refresh token was used. It
+	// reports false when the pair was already revoked.
+	RotateToken(ctx context.Context, id int) (bool, error)
+	RevokeAccessToken(ctx context.Context, accessHash string) error
+	
+	// ListSessions returns the user's active cookie sessions, marking the one
+	// identified by currentToken.
+	ListSessions(ctx context.Context, userID int, currentToken string) ([]models.UserSession, error)
+	// RevokeSession revokes one of the user's sessions. It returns
+	// ErrNotFound when the user has no such active session.
+	RevokeSession(ctx context.Context, userID, id int) error
+	RevokeOtherSessions(ctx context.Context, userID int, currentToken string) error
+	// RevokeUser logs the user out everywhere: every cookie session and
+	// every token pair.
+	RevokeUser(ctx context.Context, userID int) error
+}
+
+// APIKeyStore holds partner API keys, identified by the SHA-256 of the key.
+type APIKeyStore interface {
+	// FindByHash returns an API key that is not revoked.
+	FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	TouchLastUsed(ctx context.Context, id int) error
+	// List returns every key with PartnerName, newest first.
+	List(ctx context.Context) ([]models.APIKey, error)
+	// Create stores k and sets its ID and CreatedAt.
+	Create(ctx context.Context, k *models.APIKey, keyHash string) error
+	// Revoke returns ErrNotFound when the key does not exist or was
+	// already revoked.
+	Revoke(ctx context.Context, id int) error
+	// RevokeUser revokes every key of the user.
+	RevokeUser(ctx context.Context, userID int) error
+}
+
+// LockoutStore holds the failed login counters, see the login_throttles
+// migration for the key format.
+type LockoutStore interface {
+	// LockedUntil returns when the key's lock ends, or the zero time when
+	// it is not locked.
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	// RecordFailure counts a failed login and returns the number of
+	// failures. The count starts over when the previous failure is older
+	// than windowStart.
+	RecordFailure(ctx context.Context, key string, windowStart time.Time) (int, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Clear(ctx context.Context, key string) error
+	// Locked returns the keys that are currently locked, with the account
+	// behind "user:<id>" keys.
+	Locked(ctx context.Context) ([]models.Lockout, error)
+}
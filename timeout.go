@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	
+	"backend-wisata/config"
+	"backend-wisata/logger"
+	"backend-wisata/metrics"
+)
+
+// requestDeadlines gives every request the deadline of its route, see
+// config.TimeoutConfig. It must wrap the ServeMux directly so the route can
+// be looked up before the mux runs the handler.
+//
+// Handlers are not interrupted: they notice the deadline through the request
+// context, usually as a failed database call, and answer 500. That answer is
+// replaced with 504 and counted.
+func requestDeadlines(mux *http.ServeMux, cfg config.TimeoutConfig) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			// The mux sets the pattern on the copy made below; setting it
+			// here as well keeps it visible to the outer middleware.
+			_, route := mux.Handler(r)
+			r.Pattern = route
+			
+			timeout := routeTimeout(cfg, route)
+			if timeout <= 0 {
+				mux.ServeHTTP(w, r)
+				return
+			}
+			
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+			
+			mux.ServeHTTP(&deadlineWriter{ResponseWriter: w, r: r, route: route}, r)
+		},
+	)
+}
+
+// routeTimeout returns the timeout configured for the pattern, then for its
+// path, then the default.
+func routeTimeout(cfg config.TimeoutConfig, route string) time.Duration {
+	if timeout, ok := cfg.Routes[route]; ok {
+		return timeout
+	}
+	if _, path, ok := strings.Cut(route, " "); ok {
+		if timeout, ok := cfg.Routes[path]; ok {
+			return timeout
+		}
+	}
+	return cfg.Default
+}
+
+type deadlineWriter struct {
+	http.ResponseWriter
+	r     *http.Request
+	route string
+	// replaced is set once the timeout envelope was written; the error
+	// body of the handler that follows is dropped.
+	replaced bool
+}
+
+func (w *deadlineWriter) WriteHeader(status int) {
+	if status >= http.StatusInternalServerError && errors.Is(w.r.Context().Err(), context.DeadlineExceeded) {
+		w.replaced = true
+		metrics.RequestTimedOut(w.r, w.route)
+		logger.FromContext(w.r.Context()).Warn("request deadline exceeded", "route", w.route)
+		respondErrorCode(w.ResponseWriter, http.StatusGatewayTimeout, "request_timeout", "Permintaan terlalu lama diproses, coba lagi")
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *deadlineWriter) Write(b []byte) (int, error) {
+	if w.replaced {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *deadlineWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
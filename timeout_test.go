@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	
+	"backend-wisata/config"
+	"backend-wisata/models"
+)
+
+func TestRequestDeadlines(t *testing.T) {
+	mux := http.NewServeMux()
+	// waitForDeadline stands in for a handler whose database call fails
+	// once the request context expires.
+	waitForDeadline := func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			respondError(w, http.StatusInternalServerError, "Tanpa deadline")
+			return
+		}
+		<-r.Context().Done()
+		respondError(w, http.StatusInternalServerError, "Gagal mengambil data")
+	}
+	mux.HandleFunc("GET /api/v1/slow", waitForDeadline)
+	mux.HandleFunc("POST /api/v1/upload", waitForDeadline)
+	mux.HandleFunc("POST /api/legacy/upload", waitForDeadline)
+	mux.HandleFunc("GET /api/v1/unlimited", waitForDeadline)
+	mux.HandleFunc(
+		"GET /api/v1/missing/{id}", func(w http.ResponseWriter, r *http.Request) {
+			respondError(w, http.StatusNotFound, "Wisata tidak ditemukan")
+		},
+	)
+	
+	cfg := config.TimeoutConfig{
+		Default: 10 * time.Millisecond,
+		Routes: map[string]time.Duration{
+			"POST /api/v1/upload":   20 * time.Millisecond,
+			"/api/legacy/upload":    20 * time.Millisecond,
+			"GET /api/v1/unlimited": 0,
+		},
+	}
+	handler := unmatchedErrors(requestDeadlines(mux, cfg))
+	
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		status  int
+		code    string
+		message string
+		// atLeast is the deadline the route should have been given.
+		atLeast time.Duration
+	}{
+		{"default deadline", http.MethodGet, "/api/v1/slow", http.StatusGatewayTimeout, "request_timeout", "", 10 * time.Millisecond},
+		{"route deadline", http.MethodPost, "/api/v1/upload", http.StatusGatewayTimeout, "request_timeout", "", 20 * time.Millisecond},
+		{"path deadline", http.MethodPost, "/api/legacy/upload", http.StatusGatewayTimeout, "request_timeout", "", 20 * time.Millisecond},
+		{"disabled", http.MethodGet, "/api/v1/unlimited", http.StatusInternalServerError, "internal_server_error", "Tanpa deadline", 0},
+		{"handler not found", http.MethodGet, "/api/v1/missing/7", http.StatusNotFound, "not_found", "Wisata tidak ditemukan", 0},
+		{"no route", http.MethodGet, "/api/v1/nothing", http.StatusNotFound, "not_found", "Endpoint tidak ditemukan", 0},
+	}
+	
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				start := time.Now()
+				handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+				took := time.Since(start)
+				
+				var res models.Response
+				if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+					t.Fatalf("body %q: %v", rec.Body, err)
+				}
+				if rec.Code != tt.status || res.Status != tt.status || res.Code != tt.code {
+					t.Fatalf("got %d %s, want %d %s (%s)", rec.Code, res.Code, tt.status, tt.code, rec.Body)
+				}
+				if tt.message != "" && res.Message != tt.message {
+					t.Fatalf("message %q, want %q", res.Message, tt.message)
+				}
+				if took < tt.atLeast {
+					t.Fatalf("answered after %s, before the %s deadline", took, tt.atLeast)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,286 @@
+// Package validate checks request input against `validate` struct tags and
+// decodes form values into structs.
+//
+// Rules are separated by commas, parameters follow an equals sign:
+//
+//	Quantity  int    `json:"quantity" validate:"required,min=1,max=100"`
+//	VisitDate string `json:"visit_date" validate:"required,date"`
+//
+// Available rules:
+//
+//	required    the value is not empty (for forms: the field was sent)
+//	min=N       numbers: at least N; strings: at least N characters; lists: at least N items
+//	max=N       numbers: at most N; strings: at most N characters; lists: at most N items
+//	maxbytes=N  strings: at most N bytes, for limits like bcrypt's that count bytes
+//	email       an email address without display name
+//	phone       8 to 15 digits with an optional leading +; spaces and dashes are ignored
+//	date        a date formatted as YYYY-MM-DD
+//	slug        lowercase letters and digits separated by single dashes
+//	oneof=a b   the value (or every list item) is one of the listed words
+//
+// Rules other than required are skipped for empty values, so optional
+// fields only need to be valid when they are sent.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Errors maps a field name, as the client sent it, to its problems.
+type Errors map[string][]string
+
+func (e Errors) Add(field, message string) {
+	e[field] = append(e[field], message)
+}
+
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, field+": "+strings.Join(e[field], ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Struct checks the exported fields of the struct v points to. Fields are
+// named after their json tag. It returns nil when every rule passes.
+func Struct(v any) Errors {
+	errs := Errors{}
+	eachField(v, "json", func(name string, rules []rule, value reflect.Value) {
+		check(errs, name, rules, value, !value.IsZero())
+	})
+	return result(errs)
+}
+
+// Form fills the struct dst points to from form values, using the form tag
+// as the field name, and checks it. Supported field types are strings,
+// integers, floats, bools and string slices. A value that cannot be
+// converted to the field type is reported instead of the other rules.
+func Form(form url.Values, dst any) Errors {
+	errs := Errors{}
+	eachField(dst, "form", func(name string, rules []rule, value reflect.Value) {
+		values := form[name]
+		present := len(values) > 0 && values[0] != ""
+		if present {
+			if msg := set(value, values); msg != "" {
+				errs.Add(name, msg)
+				return
+			}
+		}
+		check(errs, name, rules, value, present)
+	})
+	return result(errs)
+}
+
+func result(errs Errors) Errors {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+func eachField(v any, tag string, fn func(name string, rules []rule, value reflect.Value)) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		panic("validate: expected a pointer to a struct, got " + rv.Type().String())
+	}
+	eachStructField(rv.Elem(), tag, fn)
+}
+
+// eachStructField walks the fields of rv, including those of embedded
+// structs so input structs can share a common part.
+func eachStructField(rv reflect.Value, tag string, fn func(name string, rules []rule, value reflect.Value)) {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			eachStructField(rv.Field(i), tag, fn)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fn(name, parseRules(field.Tag.Get("validate")), rv.Field(i))
+	}
+}
+
+func parseRules(tag string) []rule {
+	if tag == "" {
+		return nil
+	}
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, rule{name: name, param: param})
+	}
+	return rules
+}
+
+// set converts the form values to the field type. It returns the message
+// for a value of the wrong type.
+func set(value reflect.Value, values []string) string {
+	raw := values[0]
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, value.Type().Bits())
+		if err != nil {
+			return "harus berupa bilangan bulat"
+		}
+		value.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), value.Type().Bits())
+		if err != nil {
+			return "harus berupa angka"
+		}
+		value.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return "harus bernilai true atau false"
+		}
+		value.SetBool(b)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			panic("validate: unsupported form field type " + value.Type().String())
+		}
+		value.Set(reflect.ValueOf(slices.Clone(values)))
+	default:
+		panic("validate: unsupported form field type " + value.Type().String())
+	}
+	return ""
+}
+
+var (
+	phonePattern = regexp.MustCompile(`^\+?[0-9]{8,15}$`)
+	slugPattern  = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// check applies the rules to one field. present tells whether the field
+// counts as filled in for the required rule.
+func check(errs Errors, name string, rules []rule, value reflect.Value, present bool) {
+	if value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "" {
+		present = false
+	}
+	if !present {
+		if slices.ContainsFunc(rules, func(r rule) bool { return r.name == "required" }) {
+			errs.Add(name, "wajib diisi")
+		}
+		return
+	}
+	
+	for _, r := range rules {
+		if msg := apply(r, value); msg != "" {
+			errs.Add(name, msg)
+		}
+	}
+}
+
+// apply returns the message for a value that breaks the rule.
+func apply(r rule, value reflect.Value) string {
+	switch r.name {
+	case "required":
+		return ""
+	case "min", "max":
+		return checkBound(r, value)
+	case "maxbytes":
+		limit, err := strconv.Atoi(r.param)
+		if err != nil || value.Kind() != reflect.String {
+			panic("validate: maxbytes=" + r.param + " does not apply to " + value.Type().String())
+		}
+		if len(value.String()) > limit {
+			return fmt.Sprintf("maksimal %d byte", limit)
+		}
+	case "email":
+		s := value.String()
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Name != "" || addr.Address != s {
+			return "format email tidak valid"
+		}
+	case "phone":
+		s := strings.NewReplacer(" ", "", "-", "").Replace(value.String())
+		if !phonePattern.MatchString(s) {
+			return "format nomor telepon tidak valid"
+		}
+	case "date":
+		if _, err := time.Parse(time.DateOnly, value.String()); err != nil {
+			return "format tanggal harus YYYY-MM-DD"
+		}
+	case "slug":
+		if !slugPattern.MatchString(value.String()) {
+			return "hanya boleh huruf kecil, angka dan tanda hubung"
+		}
+	case "oneof":
+		allowed := strings.Fields(r.param)
+		items := []string{value.String()}
+		if value.Kind() == reflect.Slice {
+			items = value.Interface().([]string)
+		}
+		for _, item := range items {
+			if !slices.Contains(allowed, item) {
+				return "harus salah satu dari: " + strings.Join(allowed, ", ")
+			}
+		}
+	default:
+		panic("validate: unknown rule " + r.name)
+	}
+	return ""
+}
+
+func checkBound(r rule, value reflect.Value) string {
+	limit, err := strconv.ParseFloat(r.param, 64)
+	if err != nil {
+		panic("validate: invalid parameter for " + r.name + ": " + r.param)
+	}
+	
+	var n float64
+	var unit string
+	switch value.Kind() {
+	case reflect.String:
+		n, unit = float64(utf8.RuneCountInString(value.String())), " karakter"
+	case reflect.Slice:
+		n, unit = float64(value.Len()), " item"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(value.Int())
+	case reflect.Float32, reflect.Float64:
+		n = value.Float()
+	default:
+		panic("validate: " + r.name + " does not apply to " + value.Type().String())
+	}
+	
+	if r.name == "min" && n < limit {
+		return fmt.Sprintf("minimal %s%s", r.param, unit)
+	}
+	if r.name == "max" && n > limit {
+		return fmt.Sprintf("maksimal %s%s", r.param, unit)
+	}
+	return ""
+}